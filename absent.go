@@ -0,0 +1,64 @@
+package jsondiff
+
+// absentRules are the values that are treated as missing fields
+type absentRules int
+
+const (
+	nullAbsent absentRules = 1 << iota
+	emptyObjectAbsent
+	emptyArrayAbsent
+)
+
+// TreatNullAsAbsent compares fields set to null as if they are
+// missing, so {"a":null} and {} are equal
+func TreatNullAsAbsent() Option {
+	return func(d *Differ) {
+		d.absent |= nullAbsent
+	}
+}
+
+// TreatEmptyObjectAsAbsent compares fields set to an empty object as
+// if they are missing, so {"a":{}} and {} are equal
+func TreatEmptyObjectAsAbsent() Option {
+	return func(d *Differ) {
+		d.absent |= emptyObjectAbsent
+	}
+}
+
+// TreatEmptyArrayAsAbsent compares fields set to an empty array as if
+// they are missing, so {"a":[]} and {} are equal
+func TreatEmptyArrayAsAbsent() Option {
+	return func(d *Differ) {
+		d.absent |= emptyArrayAbsent
+	}
+}
+
+// hasAbsentRules returns if some field values are treated as missing
+// fields
+func (d *Differ) hasAbsentRules() bool {
+	return d.absent != 0 || d.schema != nil
+}
+
+// isAbsent returns if the field with the value is treated as a
+// missing field, because of the Treat...AsAbsent options or the
+// SchemaDefaults option
+func (d *Differ) isAbsent(name FieldName, value interface{}) bool {
+	if !d.hasAbsentRules() {
+		return false
+	}
+	switch v := value.(type) {
+	case nil:
+		if d.absent&nullAbsent != 0 {
+			return true
+		}
+	case map[string]interface{}:
+		if len(v) == 0 && d.absent&emptyObjectAbsent != 0 {
+			return true
+		}
+	case []interface{}:
+		if len(v) == 0 && d.absent&emptyArrayAbsent != 0 {
+			return true
+		}
+	}
+	return d.isSchemaDefault(name, value)
+}
@@ -0,0 +1,32 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestTreatAsAbsent(t *testing.T) {
+	tests := []struct {
+		doc1, doc2 string
+		opts       []Option
+		n          int
+	}{
+		{`{"a":null}`, `{}`, nil, 1},
+		{`{"a":null}`, `{}`, []Option{TreatNullAsAbsent()}, 0},
+		{`{}`, `{"a":null,"b":1}`, []Option{TreatNullAsAbsent()}, 1},
+		{`{"a":[]}`, `{}`, []Option{TreatNullAsAbsent()}, 1},
+		{`{"a":[]}`, `{}`, []Option{TreatEmptyArrayAsAbsent()}, 0},
+		{`{"a":{}}`, `{}`, []Option{TreatEmptyArrayAsAbsent()}, 1},
+		{`{"a":{"b":{}}}`, `{"a":{}}`, []Option{TreatEmptyObjectAsAbsent()}, 0},
+		{`{"a":null}`, `{"a":[]}`, []Option{TreatNullAsAbsent(), TreatEmptyArrayAsAbsent()}, 0},
+		{`{"a":null}`, `{"a":[]}`, []Option{TreatNullAsAbsent()}, 1},
+		{`[{"id":1,"x":null}]`, `[{"id":1}]`, []Option{TreatNullAsAbsent()}, 0},
+	}
+	for _, test := range tests {
+		doc1, _ := parse(test.doc1)
+		doc2, _ := parse(test.doc2)
+		deltas := NewDiffer(test.opts...).Difference(doc1, doc2)
+		if len(deltas) != test.n {
+			t.Errorf("Wrong diff for %s %s: %v", test.doc1, test.doc2, deltas)
+		}
+	}
+}
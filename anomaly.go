@@ -0,0 +1,126 @@
+package jsondiff
+
+import (
+	"strings"
+)
+
+// AnomalyKind describes why a delta is flagged
+type AnomalyKind string
+
+// Anomaly kinds
+const (
+	// AnomalyNewPath flags a change to a field that was never changed
+	// in the earlier runs
+	AnomalyNewPath AnomalyKind = "new-path"
+	// AnomalyVolumeSpike flags the changes of a run that has many more
+	// deltas than the recent runs
+	AnomalyVolumeSpike AnomalyKind = "volume-spike"
+)
+
+// FlaggedDelta is a delta flagged as anomalous
+type FlaggedDelta struct {
+	Delta     Delta
+	Run       DiffRun
+	Anomalies []AnomalyKind
+}
+
+// AnomalyConfig configures an AnomalyDetector. Zero values select the
+// defaults.
+type AnomalyConfig struct {
+	// Warmup is the number of runs observed before anything is
+	// flagged. The default is 1, so the first run establishes the
+	// baseline
+	Warmup int
+	// Window is the number of recent runs used to compute the average
+	// number of deltas per run. The default is 10
+	Window int
+	// SpikeFactor is how many times the recent average the number of
+	// deltas in a run must be to be a volume spike. The default is 3
+	SpikeFactor float64
+	// MinSpike is the minimum number of deltas in a run for a volume
+	// spike, so that small absolute changes are not flagged. The
+	// default is 10
+	MinSpike int
+}
+
+// AnomalyDetector flags unusual changes in a stream of difference
+// runs of the same document. It keeps the set of fields changed
+// before, and the number of deltas in the recent runs. Array indexes
+// are ignored when fields are compared, so a change to any element
+// of an array counts as a change to the same field. An AnomalyDetector
+// is not safe for concurrent use.
+type AnomalyDetector struct {
+	cfg    AnomalyConfig
+	seen   map[string]struct{}
+	recent []int
+	runs   int
+}
+
+// NewAnomalyDetector returns a new AnomalyDetector
+func NewAnomalyDetector(cfg AnomalyConfig) *AnomalyDetector {
+	if cfg.Warmup <= 0 {
+		cfg.Warmup = 1
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 10
+	}
+	if cfg.SpikeFactor <= 0 {
+		cfg.SpikeFactor = 3
+	}
+	if cfg.MinSpike <= 0 {
+		cfg.MinSpike = 10
+	}
+	return &AnomalyDetector{cfg: cfg, seen: make(map[string]struct{})}
+}
+
+// Observe records the run, and returns its deltas that are flagged as
+// anomalous. Runs must be observed in time order.
+func (a *AnomalyDetector) Observe(run DiffRun) []FlaggedDelta {
+	warm := a.runs >= a.cfg.Warmup
+	spike := false
+	if warm && len(a.recent) > 0 && len(run.Deltas) >= a.cfg.MinSpike {
+		total := 0
+		for _, n := range a.recent {
+			total += n
+		}
+		avg := float64(total) / float64(len(a.recent))
+		spike = float64(len(run.Deltas)) > a.cfg.SpikeFactor*avg
+	}
+	var ret []FlaggedDelta
+	for _, d := range run.Deltas {
+		var anomalies []AnomalyKind
+		key := generalPath(d.GetField())
+		if _, ok := a.seen[key]; !ok {
+			a.seen[key] = struct{}{}
+			if warm {
+				anomalies = append(anomalies, AnomalyNewPath)
+			}
+		}
+		if spike {
+			anomalies = append(anomalies, AnomalyVolumeSpike)
+		}
+		if len(anomalies) > 0 {
+			ret = append(ret, FlaggedDelta{Delta: d, Run: run, Anomalies: anomalies})
+		}
+	}
+	a.recent = append(a.recent, len(run.Deltas))
+	if len(a.recent) > a.cfg.Window {
+		a.recent = a.recent[1:]
+	}
+	a.runs++
+	return ret
+}
+
+// generalPath returns the field name with all array indexes replaced
+// by "*"
+func generalPath(name FieldName) string {
+	parts := make([]string, len(name))
+	for i, s := range name {
+		if s.IsIndex {
+			parts[i] = "*"
+		} else {
+			parts[i] = pointerEscaper.Replace(s.Key)
+		}
+	}
+	return strings.Join(parts, "/")
+}
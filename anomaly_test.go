@@ -0,0 +1,42 @@
+package jsondiff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	det := NewAnomalyDetector(AnomalyConfig{MinSpike: 3, SpikeFactor: 2})
+	mod := func(path ...string) Delta {
+		return Modification{Name: keys(path...), Old: float64(1), New: float64(2)}
+	}
+	elem := func(ix int) Delta {
+		return Insertion{Name: FieldName{KeySegment("arr"), IndexSegment(ix)}, NewNode: float64(ix)}
+	}
+	now := time.Now()
+	runs := [][]Delta{
+		{mod("a"), elem(0)},
+		{mod("a")},
+		{mod("a"), elem(5)},
+		{mod("b")},
+		{mod("a"), mod("a", "x"), mod("a", "y"), mod("a", "z")},
+	}
+	var flagged [][]FlaggedDelta
+	for i, r := range runs {
+		flagged = append(flagged, det.Observe(DiffRun{Time: now.Add(time.Duration(i) * time.Minute), Deltas: r}))
+	}
+	if len(flagged[0]) != 0 || len(flagged[1]) != 0 || len(flagged[2]) != 0 {
+		t.Errorf("Unexpected flags: %v", flagged[:3])
+	}
+	if len(flagged[3]) != 1 || flagged[3][0].Anomalies[0] != AnomalyNewPath {
+		t.Errorf("Expected new path: %v", flagged[3])
+	}
+	if len(flagged[4]) != 4 {
+		t.Errorf("Expected volume spike: %v", flagged[4])
+		return
+	}
+	if len(flagged[4][0].Anomalies) != 1 || flagged[4][0].Anomalies[0] != AnomalyVolumeSpike ||
+		len(flagged[4][1].Anomalies) != 2 {
+		t.Errorf("Wrong anomalies: %v", flagged[4])
+	}
+}
@@ -0,0 +1,233 @@
+package jsondiff
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Anonymizer replaces the values in deltas and documents with fake
+// values of the same type, so a difference can be shared without the
+// data. Object keys, and so the paths of the deltas, are not changed.
+// Null and boolean values are kept.
+//
+// Strings are split into words, white space and punctuation as for
+// TextWords. Each word is replaced by a fake word of the same length,
+// with letters replaced by letters of the same case and digits by
+// digits. White space and punctuation are kept. Numbers are replaced
+// by numbers with the same number of digits, so integers remain
+// integers. Values that are not JSON values are replaced by fake
+// strings.
+//
+// The same value is always replaced by the same fake, and different
+// values by different fakes, so anonymized deltas computed from two
+// documents are the deltas between the anonymized documents, and
+// can be applied to them. An Anonymizer must not be used concurrently.
+type Anonymizer struct {
+	key   []byte
+	fakes map[string]string
+	used  map[string]bool
+}
+
+// NewAnonymizer returns an anonymizer that derives the fakes from
+// key. The same key gives the same fakes. If key is empty, a random
+// key is used.
+func NewAnonymizer(key []byte) *Anonymizer {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(err)
+		}
+	}
+	return &Anonymizer{key: key, fakes: make(map[string]string), used: make(map[string]bool)}
+}
+
+// Deltas returns the deltas with their values replaced by fakes
+func (a *Anonymizer) Deltas(deltas []Delta) []Delta {
+	ret := make([]Delta, 0, len(deltas))
+	for _, d := range deltas {
+		switch x := d.(type) {
+		case Insertion:
+			x.NewNode = a.Document(x.NewNode)
+			d = x
+		case Deletion:
+			x.DeletedNode = a.Document(x.DeletedNode)
+			d = x
+		case Move:
+			x.Old = a.Document(x.Old)
+			x.New = a.Document(x.New)
+			d = x
+		case Rename:
+			x.Value = a.Document(x.Value)
+			d = x
+		case Modification:
+			x.Old = a.Document(x.Old)
+			x.New = a.Document(x.New)
+			if x.TextDiff != nil {
+				edits := make([]TextEdit, len(x.TextDiff))
+				for i, e := range x.TextDiff {
+					edits[i] = TextEdit{Op: e.Op, Text: a.text(e.Text)}
+				}
+				x.TextDiff = edits
+			}
+			d = x
+		}
+		ret = append(ret, d)
+	}
+	return ret
+}
+
+// Document returns a copy of doc with its values replaced by fakes
+func (a *Anonymizer) Document(doc interface{}) interface{} {
+	switch k := doc.(type) {
+	case nil, bool:
+		return k
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(k))
+		for key, v := range k {
+			ret[key] = a.Document(v)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(k))
+		for i, v := range k {
+			ret[i] = a.Document(v)
+		}
+		return ret
+	case string:
+		return a.text(k)
+	case float64:
+		f, _ := strconv.ParseFloat(a.number(strconv.FormatFloat(k, 'g', -1, 64)), 64)
+		return f
+	case json.Number:
+		return json.Number(a.number(string(k)))
+	case TruncatedValue:
+		k.Hash = a.text(k.Hash)
+		return k
+	}
+	if KindOf(doc) == KindNumber {
+		return json.Number(a.number(fmt.Sprint(doc)))
+	}
+	return a.text(fmt.Sprint(doc))
+}
+
+// text replaces the words of s
+func (a *Anonymizer) text(s string) string {
+	var sb strings.Builder
+	for _, w := range splitWords(s) {
+		r := []rune(w)
+		if unicode.IsLetter(r[0]) || unicode.IsDigit(r[0]) || r[0] == '_' {
+			w = a.fake("s:", w, fakeWord)
+		}
+		sb.WriteString(w)
+	}
+	return sb.String()
+}
+
+// number replaces the digits of the number s, keeping the exponent
+func (a *Anonymizer) number(s string) string {
+	if strings.Trim(s, "-0.") == "" {
+		// Zero
+		return s
+	}
+	return a.fake("n:", s, fakeNumber)
+}
+
+// fake returns the fake for the value s of a kind. gen generates a
+// fake using a function returning pseudo-random numbers
+func (a *Anonymizer) fake(kind, s string, gen func(s string, random func(n int) int) string) string {
+	if f, ok := a.fakes[kind+s]; ok {
+		return f
+	}
+	for attempt := 0; ; attempt++ {
+		mac := hmac.New(sha256.New, a.key)
+		fmt.Fprintf(mac, "%s%d:%s", kind, attempt, s)
+		seed := mac.Sum(nil)
+		counter := uint32(0)
+		random := func(n int) int {
+			var buf [4]byte
+			binary.BigEndian.PutUint32(buf[:], counter)
+			counter++
+			h := hmac.New(sha256.New, seed)
+			h.Write(buf[:])
+			return int(binary.BigEndian.Uint64(h.Sum(nil)) % uint64(n))
+		}
+		input := s
+		// After many collisions, all fakes of that length may be
+		// taken, so use a longer one
+		for i := 0; i < attempt/64; i++ {
+			input = lengthen(kind, input)
+		}
+		f := gen(input, random)
+		if !a.used[kind+f] {
+			a.fakes[kind+s] = f
+			a.used[kind+f] = true
+			return f
+		}
+	}
+}
+
+// lengthen returns s with one more digit or letter
+func lengthen(kind, s string) string {
+	if kind == "n:" {
+		ix := strings.IndexAny(s, "123456789")
+		return s[:ix] + "1" + s[ix:]
+	}
+	return s + "a"
+}
+
+// fakeWord replaces the letters and digits of a word
+func fakeWord(s string, random func(n int) int) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			sb.WriteByte(byte('A' + random(26)))
+		case unicode.IsLetter(r):
+			sb.WriteByte(byte('a' + random(26)))
+		case unicode.IsDigit(r):
+			sb.WriteByte(byte('0' + random(10)))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// fakeNumber replaces the digits of the mantissa of a number. The
+// first digit is not zero unless it is the only integer digit, and
+// the last digit of a fraction is not zero, so different fakes are
+// different numbers
+func fakeNumber(s string, random func(n int) int) string {
+	mantissa, exp := s, ""
+	if ix := strings.IndexAny(s, "eE"); ix != -1 {
+		mantissa, exp = s[:ix], s[ix:]
+	}
+	b := []byte(mantissa)
+	last := len(b) - 1
+	if !strings.Contains(mantissa, ".") {
+		last = -1
+	}
+	first := true
+	for i, c := range b {
+		if c < '0' || c > '9' {
+			continue
+		}
+		switch {
+		case first && c == '0':
+			// 0.x
+		case first || i == last:
+			b[i] = byte('1' + random(9))
+		default:
+			b[i] = byte('0' + random(10))
+		}
+		first = false
+	}
+	return string(b) + exp
+}
@@ -0,0 +1,75 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnonymizer(t *testing.T) {
+	doc1, _ := parse(`{"user":{"name":"Alice Smith","email":"alice@example.com","age":42,"score":0.75,"active":true},"tags":["a","b"],"note":null}`)
+	doc2, _ := parse(`{"user":{"name":"Alice Jones","email":"alice@example.com","age":43,"score":0,"active":false},"tags":["b","a","c"]}`)
+	d := NewDiffer(TextDiffs(1, TextWords))
+	deltas := d.Difference(doc1, doc2)
+	a := NewAnonymizer([]byte("key"))
+	anon := a.Deltas(deltas)
+	adoc1, adoc2 := a.Document(doc1), a.Document(doc2)
+
+	// The anonymized deltas are the deltas between the anonymized
+	// documents
+	if result, err := Apply(adoc1, anon); err != nil || !IsEqual(result, adoc2) {
+		t.Errorf("Cannot apply anonymized deltas: %v %v", result, err)
+	}
+	if expected := d.Difference(adoc1, adoc2); len(expected) != len(anon) {
+		t.Errorf("Wrong deltas: %v, expected %v", anon, expected)
+	}
+
+	data, _ := json.Marshal([]interface{}{anon, adoc1, adoc2})
+	for _, s := range []string{"Alice", "Smith", "Jones", "alice", "example", "42", "43", "0.75"} {
+		if strings.Contains(string(data), s) {
+			t.Errorf("Anonymized data contains %s: %s", s, data)
+		}
+	}
+
+	user := adoc1.(map[string]interface{})["user"].(map[string]interface{})
+	if name := user["name"].(string); len(name) != len("Alice Smith") || name[5] != ' ' || name[0] < 'A' || name[0] > 'Z' {
+		t.Errorf("Wrong fake name: %s", name)
+	}
+	if email := user["email"].(string); !strings.Contains(email, "@") || len(email) != len("alice@example.com") {
+		t.Errorf("Wrong fake email: %s", email)
+	}
+	if age := user["age"].(float64); age < 10 || age > 99 || age != float64(int(age)) {
+		t.Errorf("Wrong fake age: %v", age)
+	}
+	if user["active"] != true || adoc1.(map[string]interface{})["note"] != nil {
+		t.Errorf("Wrong fake constants: %v", adoc1)
+	}
+	if adoc2.(map[string]interface{})["user"].(map[string]interface{})["score"] != 0.0 {
+		t.Errorf("Zero is not kept: %v", adoc2)
+	}
+
+	// The same key gives the same fakes
+	if !reflect.DeepEqual(NewAnonymizer([]byte("key")).Document(doc1), adoc1) {
+		t.Errorf("Fakes are not deterministic")
+	}
+}
+
+func TestAnonymizerUnique(t *testing.T) {
+	a := NewAnonymizer(nil)
+	fakes := make(map[interface{}]bool)
+	for i := 0; i < 100; i++ {
+		f := a.Document(float64(i))
+		if fakes[f] {
+			t.Errorf("Duplicate fake for %d: %v", i, f)
+		}
+		fakes[f] = true
+	}
+	for _, s := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y", "z", "aa"} {
+		f := a.Document(s)
+		if fakes[f] {
+			t.Errorf("Duplicate fake for %s: %v", s, f)
+		}
+		fakes[f] = true
+	}
+}
@@ -0,0 +1,285 @@
+package jsondiff
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Apply applies deltas to doc, and returns the resulting
+// document. doc is not modified, containers along the changed paths
+// are copied. The deltas are expected to be in the form returned by
+// Difference: array Deletions and Move sources refer to the indexes
+// of the original array, and Insertions, Move destinations and
+// nested changes refer to the indexes of the resulting array. A
+// Modification whose New value is nil removes the field from its
+// parent object, because that is how Difference reports removed
+// fields, unless the SetNullFields option is given.
+//
+// By default, Apply fails if the parent of a changed field does not
+// exist. Use WithPathPolicy to create the missing parents instead. A
+// null parent is treated as missing when parents are created.
+func Apply(doc interface{}, deltas []Delta, opts ...ApplyOption) (interface{}, error) {
+	a := applier{}
+	for _, opt := range opts {
+		opt(&a)
+	}
+	return a.applyNode(doc, FieldName{}, deltas)
+}
+
+// PathPolicy selects what Apply does when the parent of a changed
+// field does not exist
+type PathPolicy int
+
+// Path policies
+const (
+	// PathMustExist fails if a parent does not exist
+	PathMustExist PathPolicy = iota
+	// CreateObjects creates missing parents as empty objects. It
+	// fails if an array index is needed under a missing parent
+	CreateObjects
+	// CreateArrays creates missing parents as empty objects or empty
+	// arrays, depending on whether the field under them is an object
+	// key or an array index. Arrays are padded with nulls up to the
+	// changed index
+	CreateArrays
+)
+
+// ApplyOption configures Apply
+type ApplyOption func(*applier)
+
+// WithPathPolicy sets the policy for changes whose parents do not exist
+func WithPathPolicy(policy PathPolicy) ApplyOption {
+	return func(a *applier) {
+		a.policy = policy
+	}
+}
+
+// SetNullFields makes a Modification whose New value is nil set the
+// field to null instead of removing it. Use it to apply deltas
+// computed with the ObjectFieldDeltas option, which reports removed
+// fields as Deletions.
+func SetNullFields() ApplyOption {
+	return func(a *applier) {
+		a.setNulls = true
+	}
+}
+
+// applier applies deltas to a document
+type applier struct {
+	policy   PathPolicy
+	backup   bool
+	setNulls bool
+}
+
+// missingParent returns a new container for a missing or null
+// parent whose child is seg
+func (a applier) missingParent(path FieldName, seg Segment) (interface{}, error) {
+	switch {
+	case !seg.IsIndex:
+		return map[string]interface{}{}, nil
+	case a.policy == CreateArrays:
+		return []interface{}{}, nil
+	}
+	return nil, fmt.Errorf("cannot create array %s", path)
+}
+
+// applyNode applies deltas to node. All the deltas are under path
+func (a applier) applyNode(node interface{}, path FieldName, deltas []Delta) (interface{}, error) {
+	if len(deltas) == 0 {
+		return node, nil
+	}
+	depth := len(path)
+	// Partition deltas as changes to this node, and changes to children
+	var own []Delta
+	children := make(map[Segment][]Delta)
+	keys := make([]Segment, 0)
+	for _, d := range deltas {
+		name := d.GetField()
+		if len(name) == depth {
+			own = append(own, d)
+			continue
+		}
+		key := name[depth]
+		if _, ok := children[key]; !ok {
+			keys = append(keys, key)
+		}
+		children[key] = append(children[key], d)
+	}
+	if len(own) > 0 {
+		m, ok := own[0].(Modification)
+		if !ok || len(own) > 1 || len(children) > 0 {
+			return nil, fmt.Errorf("conflicting changes at %s", path)
+		}
+		return m.New, nil
+	}
+	if node == nil && a.policy != PathMustExist {
+		var err error
+		if node, err = a.missingParent(path, keys[0]); err != nil {
+			return nil, err
+		}
+	}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		return a.applyObject(n, path, keys, children)
+	case []interface{}:
+		return a.applyArray(n, path, children)
+	}
+	return nil, fmt.Errorf("cannot apply changes under %s: not a container", path)
+}
+
+func (a applier) applyObject(node map[string]interface{}, path FieldName, keys []Segment, children map[Segment][]Delta) (interface{}, error) {
+	ret := make(map[string]interface{}, len(node))
+	for k, v := range node {
+		ret[k] = v
+	}
+	for _, seg := range keys {
+		if seg.IsIndex {
+			return nil, fmt.Errorf("invalid field %s under %s: not an array", seg, path)
+		}
+		key := seg.Key
+		childPath := path.child(seg)
+		var nested []Delta
+		for _, d := range children[seg] {
+			if len(d.GetField()) > len(childPath) {
+				nested = append(nested, d)
+				continue
+			}
+			switch x := d.(type) {
+			case Modification:
+				if x.New == nil && !a.setNulls {
+					delete(ret, key)
+				} else {
+					ret[key] = x.New
+				}
+			case Insertion:
+				ret[key] = x.NewNode
+			case Deletion:
+				delete(ret, key)
+			case Rename:
+				if _, ok := node[x.OldKey]; !ok {
+					return nil, fmt.Errorf("field does not exist: %s", path.child(KeySegment(x.OldKey)))
+				}
+				delete(ret, x.OldKey)
+				ret[key] = x.Value
+			case Move:
+				// Moves of object fields, reported by the OrderedObjects
+				// option, change the order of fields that maps do not keep
+				if !sameField(x.From, x.To) {
+					return nil, fmt.Errorf("cannot apply %v to an object field", d)
+				}
+			default:
+				return nil, fmt.Errorf("cannot apply %v to an object field", d)
+			}
+		}
+		if len(nested) > 0 {
+			v, ok := ret[key]
+			if !ok && a.policy == PathMustExist {
+				return nil, fmt.Errorf("field does not exist: %s", childPath)
+			}
+			newValue, err := a.applyNode(v, childPath, nested)
+			if err != nil {
+				return nil, err
+			}
+			ret[key] = newValue
+		}
+	}
+	return ret, nil
+}
+
+func (a applier) applyArray(node []interface{}, path FieldName, children map[Segment][]Delta) (interface{}, error) {
+	n1 := len(node)
+	deleted := make(map[int]struct{})
+	inserted := make(map[int]interface{})
+	replaced := make(map[int]interface{})
+	nested := make(map[int][]Delta)
+	for seg, deltas := range children {
+		if !seg.IsIndex || seg.Index < 0 {
+			return nil, fmt.Errorf("invalid array index %s under %s", seg, path)
+		}
+		ix := seg.Index
+		for _, d := range deltas {
+			if len(d.GetField()) > len(path)+1 {
+				nested[ix] = append(nested[ix], d)
+				continue
+			}
+			switch x := d.(type) {
+			case Deletion:
+				if ix >= n1 {
+					return nil, fmt.Errorf("index out of range: %s", x.Name)
+				}
+				deleted[ix] = struct{}{}
+			case Insertion:
+				inserted[ix] = x.NewNode
+			case Move:
+				if len(x.From) == 0 || !x.From[len(x.From)-1].IsIndex {
+					return nil, fmt.Errorf("invalid move source: %s", x.From)
+				}
+				from := x.From[len(x.From)-1].Index
+				if from < 0 || from >= n1 {
+					return nil, fmt.Errorf("invalid move source: %s", x.From)
+				}
+				deleted[from] = struct{}{}
+				inserted[ix] = node[from]
+			case Modification:
+				replaced[ix] = x.New
+			default:
+				return nil, fmt.Errorf("cannot apply %v to an array element", d)
+			}
+		}
+	}
+	n2 := n1 - len(deleted) + len(inserted)
+	if a.policy == CreateArrays {
+		// Pad the array with nulls up to the largest changed index
+		for ix := range children {
+			if ix.Index >= n2 {
+				n2 = ix.Index + 1
+			}
+		}
+	}
+	ret := make([]interface{}, n2)
+	// Elements that are not deleted or moved keep their relative
+	// order, and fill the positions not taken by insertions and moves
+	pos1 := 0
+	for pos2 := 0; pos2 < n2; pos2++ {
+		if x, ok := inserted[pos2]; ok {
+			ret[pos2] = x
+			continue
+		}
+		for {
+			if _, ok := deleted[pos1]; !ok {
+				break
+			}
+			pos1++
+		}
+		if pos1 >= n1 {
+			if a.policy == CreateArrays {
+				continue
+			}
+			return nil, fmt.Errorf("inconsistent array changes under %s", path)
+		}
+		ret[pos2] = node[pos1]
+		pos1++
+	}
+	for ix, x := range replaced {
+		if ix >= n2 {
+			return nil, fmt.Errorf("index out of range: %s/%d", path, ix)
+		}
+		ret[ix] = x
+	}
+	indexes := make([]int, 0, len(nested))
+	for ix := range nested {
+		indexes = append(indexes, ix)
+	}
+	sort.Ints(indexes)
+	for _, ix := range indexes {
+		if ix >= n2 {
+			return nil, fmt.Errorf("index out of range: %s/%d", path, ix)
+		}
+		x, err := a.applyNode(ret[ix], path.child(IndexSegment(ix)), nested[ix])
+		if err != nil {
+			return nil, err
+		}
+		ret[ix] = x
+	}
+	return ret, nil
+}
@@ -0,0 +1,99 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func testApplyRoundTrip(t *testing.T, s1, s2 string) {
+	doc1, err := parse(s1)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(s2)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	delta := Difference(doc1, doc2)
+	result, err := Apply(doc1, delta)
+	if err != nil {
+		t.Errorf("Cannot apply %v: %s", delta, err)
+		return
+	}
+	if !IsEqual(result, doc2) {
+		t.Errorf("Wrong result: %v, expected %v, delta: %v", result, doc2, delta)
+	}
+}
+
+func TestApply(t *testing.T) {
+	testApplyRoundTrip(t, `{"f1":"value1","f2":2,"f3":null,"f4":true}`, `{"f1":"value2","f2":2,"f4":false,"f5":1}`)
+	testApplyRoundTrip(t, `{"f1":[1,2,3,4,5,6]}`, `{"f1":[1,3,8,4,6]}`)
+	testApplyRoundTrip(t, `{"f1":[]}`, `{"f1":[1,2]}`)
+	testApplyRoundTrip(t, `{"f1":[1,2]}`, `{"f1":[]}`)
+	testApplyRoundTrip(t, `{"f1":[1,2,3]}`, `{"f1":[3,2,1]}`)
+	testApplyRoundTrip(t, `{"f1":[1,2,3]}`, `{"f1":[2,1,3,4]}`)
+	testApplyRoundTrip(t, `{"f1":[{"a":"b","c":1,"d":[1,2,3]},{"a":"e","c":2,"d":[4,5]}]}`,
+		`{"f1":[{"a":"1"},{"a":"2"},{"a":"e","c":2,"d":[4,5]},{"a":"4"}]}`)
+	testApplyRoundTrip(t, `{"a":{"b":{"c":1}}}`, `{"a":{"b":{"c":2,"d":3}}}`)
+	testApplyRoundTrip(t, `{"a":1}`, `[1,2]`)
+}
+
+func TestApplyDoesNotModify(t *testing.T) {
+	doc1, _ := parse(`{"a":{"b":1},"c":[1,2]}`)
+	doc2, _ := parse(`{"a":{"b":2},"c":[2]}`)
+	copy1, _ := parse(`{"a":{"b":1},"c":[1,2]}`)
+	_, err := Apply(doc1, Difference(doc1, doc2))
+	if err != nil {
+		t.Errorf("Cannot apply: %s", err)
+	}
+	if !IsEqual(doc1, copy1) {
+		t.Errorf("Document modified: %v", doc1)
+	}
+}
+
+func TestApplyBadPath(t *testing.T) {
+	doc, _ := parse(`{"a":1}`)
+	_, err := Apply(doc, []Delta{Modification{Name: FieldName{KeySegment("b"), KeySegment("c")}, New: 1.0}})
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestApplyPathPolicy(t *testing.T) {
+	doc, _ := parse(`{"a":{"b":1},"n":null}`)
+	deltas := []Delta{
+		Modification{Name: keys("x", "y", "z"), New: float64(1)},
+		Modification{Name: keys("n", "y"), New: float64(2)},
+		Modification{Name: FieldName{KeySegment("arr"), IndexSegment(2), KeySegment("k")}, New: float64(3)},
+	}
+	if _, err := Apply(doc, deltas); err == nil {
+		t.Errorf("Expected error for missing parents")
+	}
+	if _, err := Apply(doc, deltas, WithPathPolicy(CreateObjects)); err == nil {
+		t.Errorf("Expected error for missing array")
+	}
+	result, err := Apply(doc, deltas, WithPathPolicy(CreateArrays))
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected, _ := parse(`{"a":{"b":1},"x":{"y":{"z":1}},"n":{"y":2},"arr":[null,null,{"k":3}]}`)
+	if !IsEqual(result, expected) {
+		t.Errorf("Wrong result: %v", result)
+	}
+	result, err = Apply(doc, deltas[:2], WithPathPolicy(CreateObjects))
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected, _ = parse(`{"a":{"b":1},"x":{"y":{"z":1}},"n":{"y":2}}`)
+	if !IsEqual(result, expected) {
+		t.Errorf("Wrong result: %v", result)
+	}
+	result, err = Apply([]interface{}{}, []Delta{Insertion{Name: FieldName{IndexSegment(1)}, NewNode: "x"}},
+		WithPathPolicy(CreateArrays))
+	if err != nil || !IsEqual(result, []interface{}{nil, "x"}) {
+		t.Errorf("Wrong result: %v %v", result, err)
+	}
+}
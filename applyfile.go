@@ -0,0 +1,119 @@
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WithBackup makes ApplyToFile keep a copy of the original file with
+// a ".bak" suffix
+func WithBackup() ApplyOption {
+	return func(a *applier) {
+		a.backup = true
+	}
+}
+
+// ApplyToFile applies deltas to the JSON document in the file at
+// path, and writes the result back to the file. The result is written
+// to a temporary file in the same directory first, which is then
+// renamed over the original, so the file is never left partially
+// written. The file mode is preserved.
+//
+// The document is re-encoded, so the formatting of the original file
+// is not preserved exactly: object keys are sorted, and the
+// indentation of the second line of the file, if any, is used for all
+// lines. If WithBackup is given, the original file is kept with a
+// ".bak" suffix.
+func ApplyToFile(path string, deltas []Delta, opts ...ApplyOption) error {
+	a := applier{}
+	for _, opt := range opts {
+		opt(&a)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return newParseError(1, -1, err)
+	}
+	result, err := a.applyNode(doc, FieldName{}, deltas)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", detectIndent(data))
+	if err := enc.Encode(result); err != nil {
+		return err
+	}
+	out := buf.Bytes()
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		// Encode always adds a newline
+		out = bytes.TrimSuffix(out, []byte("\n"))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if a.backup {
+		if err := writeFileAtomic(path+".bak", data, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("cannot write backup: %w", err)
+		}
+	}
+	return writeFileAtomic(path, out, info.Mode().Perm())
+}
+
+// detectIndent returns the indentation used in a JSON document: the
+// leading white space of its second line, or "" if the document is on
+// a single line
+func detectIndent(data []byte) string {
+	data = bytes.TrimSpace(data)
+	nl := bytes.IndexByte(data, '\n')
+	if nl == -1 {
+		return ""
+	}
+	line := data[nl+1:]
+	n := 0
+	for n < len(line) && (line[n] == ' ' || line[n] == '\t') {
+		n++
+	}
+	return string(line[:n])
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of
+// path, and renames it to path
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package jsondiff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyToFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	original := "{\n    \"a\": 1,\n    \"b\": \"<x>\"\n}\n"
+	if err := os.WriteFile(path, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+	deltas := []Delta{Modification{Name: keys("a"), Old: float64(1), New: float64(2)}}
+	if err := ApplyToFile(path, deltas, WithBackup()); err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	data, _ := os.ReadFile(path)
+	expected := "{\n    \"a\": 2,\n    \"b\": \"<x>\"\n}\n"
+	if string(data) != expected {
+		t.Errorf("Wrong result: %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Errorf("Wrong mode: %v", info.Mode())
+	}
+	if bak, _ := os.ReadFile(path + ".bak"); string(bak) != original {
+		t.Errorf("Wrong backup: %q", bak)
+	}
+	// Failed patches do not change the file
+	bad := []Delta{Modification{Name: keys("x", "y"), New: float64(1)}}
+	if err := ApplyToFile(path, bad); err == nil {
+		t.Errorf("Expected error")
+	}
+	if after, _ := os.ReadFile(path); string(after) != expected {
+		t.Errorf("File changed: %q", after)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("Unexpected files: %v", entries)
+	}
+}
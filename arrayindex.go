@@ -0,0 +1,166 @@
+package jsondiff
+
+import (
+	"sort"
+	"strconv"
+)
+
+// arrayChanges are the element changes of an array: the old indexes
+// removed from the array, the new indexes added to it, and the moved
+// elements. Moved elements are included in both removed and added
+// indexes. The elements that are not removed or moved keep their
+// order, and fill the positions that are not added. Array indexes
+// are converted between the old and the new array using these
+// changes
+type arrayChanges struct {
+	removed map[int]struct{}
+	added   map[int]struct{}
+	// moves maps the old indexes of moved elements to the new
+	// indexes, and movedFrom maps the new indexes to the old ones
+	moves     map[int]int
+	movedFrom map[int]int
+	// sortedRemoved and sortedAdded are computed when they are first
+	// needed
+	sorted                     bool
+	sortedRemoved, sortedAdded []int
+}
+
+func newArrayChanges() *arrayChanges {
+	return &arrayChanges{removed: make(map[int]struct{}),
+		added:     make(map[int]struct{}),
+		moves:     make(map[int]int),
+		movedFrom: make(map[int]int),
+	}
+}
+
+// remove records that the element at old index ix is removed
+func (a *arrayChanges) remove(ix int) {
+	a.removed[ix] = struct{}{}
+	a.sorted = false
+}
+
+// add records that an element is added at new index ix
+func (a *arrayChanges) add(ix int) {
+	a.added[ix] = struct{}{}
+	a.sorted = false
+}
+
+// move records that the element at old index from is moved to new
+// index to
+func (a *arrayChanges) move(from, to int) {
+	a.moves[from] = to
+	a.movedFrom[to] = from
+	a.remove(from)
+	a.add(to)
+}
+
+// newIndex returns the index of the element at old index ix after
+// the changes. It returns false if the element is removed
+func (a *arrayChanges) newIndex(ix int) (int, bool) {
+	if to, ok := a.moves[ix]; ok {
+		return to, true
+	}
+	if _, ok := a.removed[ix]; ok {
+		return 0, false
+	}
+	a.sortIndexes()
+	return fillIndex(ix, a.sortedRemoved, a.sortedAdded), true
+}
+
+// oldIndex returns the index of the element at new index ix before
+// the changes. It returns false if the element is added
+func (a *arrayChanges) oldIndex(ix int) (int, bool) {
+	if from, ok := a.movedFrom[ix]; ok {
+		return from, true
+	}
+	if _, ok := a.added[ix]; ok {
+		return 0, false
+	}
+	a.sortIndexes()
+	return fillIndex(ix, a.sortedAdded, a.sortedRemoved), true
+}
+
+func (a *arrayChanges) sortIndexes() {
+	if a.sorted {
+		return
+	}
+	a.sortedRemoved = sortedIndexes(a.removed)
+	a.sortedAdded = sortedIndexes(a.added)
+	a.sorted = true
+}
+
+func sortedIndexes(m map[int]struct{}) []int {
+	ret := make([]int, 0, len(m))
+	for ix := range m {
+		ret = append(ret, ix)
+	}
+	sort.Ints(ret)
+	return ret
+}
+
+// fillIndex returns the index of the unmoved element at index ix
+// after the elements in skip are removed, and the positions in taken
+// are filled by other elements. skip and taken are sorted
+func fillIndex(ix int, skip, taken []int) int {
+	pos := ix - sort.SearchInts(skip, ix)
+	for _, t := range taken {
+		if t > pos {
+			break
+		}
+		pos++
+	}
+	return pos
+}
+
+// collectArrayChanges returns the element changes of the arrays in
+// the deltas, keyed by the fieldKey of the array field names
+func collectArrayChanges(deltas []Delta) map[string]*arrayChanges {
+	arrays := make(map[string]*arrayChanges)
+	array := func(name FieldName) (*arrayChanges, int, bool) {
+		if len(name) == 0 || !name[len(name)-1].IsIndex {
+			return nil, 0, false
+		}
+		key := fieldKey(name[:len(name)-1])
+		a, ok := arrays[key]
+		if !ok {
+			a = newArrayChanges()
+			arrays[key] = a
+		}
+		return a, name[len(name)-1].Index, true
+	}
+	for _, d := range deltas {
+		switch x := d.(type) {
+		case Deletion:
+			if a, ix, ok := array(x.Name); ok {
+				a.remove(ix)
+			}
+		case Insertion:
+			if a, ix, ok := array(x.Name); ok {
+				a.add(ix)
+			}
+		case Move:
+			if len(x.From) == len(x.To) && hasPrefix(x.From, x.To[:len(x.To)-1]) {
+				if a, to, ok := array(x.To); ok && x.From[len(x.From)-1].IsIndex {
+					a.move(x.From[len(x.From)-1].Index, to)
+				}
+			}
+		}
+	}
+	return arrays
+}
+
+// fieldKey returns a map key for the field name. Unlike
+// FieldName.String, it distinguishes array indexes from numeric keys
+func fieldKey(name FieldName) string {
+	var buf []byte
+	for _, s := range name {
+		if s.IsIndex {
+			buf = append(buf, '#')
+			buf = strconv.AppendInt(buf, int64(s.Index), 10)
+		} else {
+			buf = append(buf, '.')
+			buf = strconv.AppendQuote(buf, s.Key)
+		}
+	}
+	return string(buf)
+}
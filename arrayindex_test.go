@@ -0,0 +1,34 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestArrayChanges(t *testing.T) {
+	// [a b c d e] -> [x c e b y]: a and d are removed, b is moved to
+	// 3, x and y are inserted
+	a := newArrayChanges()
+	a.remove(0)
+	a.remove(3)
+	a.move(1, 3)
+	a.add(0)
+	a.add(4)
+	newIndexes := map[int]int{1: 3, 2: 1, 4: 2}
+	for old := 0; old < 5; old++ {
+		ix, ok := a.newIndex(old)
+		expected, exists := newIndexes[old]
+		if ok != exists || (ok && ix != expected) {
+			t.Errorf("Wrong new index of %d: %d %v", old, ix, ok)
+		}
+		if ok {
+			if back, ok := a.oldIndex(ix); !ok || back != old {
+				t.Errorf("Wrong old index of %d: %d %v, expected %d", ix, back, ok, old)
+			}
+		}
+	}
+	for _, ix := range []int{0, 4} {
+		if _, ok := a.oldIndex(ix); ok {
+			t.Errorf("Expected %d to be added", ix)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package jsondiff
+
+// ArrayMatcher pairs the elements of two arrays. Paired elements are
+// considered to be the same element, possibly modified or moved.
+// Elements of the old array that are not paired are reported as
+// deleted, and elements of the new array that are not paired are
+// reported as inserted.
+type ArrayMatcher interface {
+	// Match returns the pairs of matching elements as a map from the
+	// indexes of node1 to the indexes of node2. Each index can be
+	// used in at most one pair.
+	Match(node1, node2 []interface{}) map[int]int
+}
+
+// builtinMatcher is implemented by the matchers of this package. It
+// returns the equivalence function for the array at fieldName, and
+// whether the paired elements have to be compared recursively.
+// computeEq is the value based equivalence of the Differ
+type builtinMatcher interface {
+	equivalence(d *Differ, fieldName FieldName, computeEq func(node1, node2 []interface{}) dualMap) (func(node1, node2 []interface{}) dualMap, bool)
+}
+
+// pathArrayMatcher is the matcher for the arrays matching a pattern
+type pathArrayMatcher struct {
+	pattern string
+	matcher ArrayMatcher
+}
+
+// MatchArrays uses m to pair the elements of the arrays at paths
+// matching the pattern. Patterns use the same syntax as ArraysAsSets.
+// If more than one pattern matches an array, the first registered
+// matcher is used. Paired elements that are not equal are compared
+// recursively, unless m is ValueMatcher or LCSMatcher, which only
+// pair equal elements.
+func MatchArrays(path string, m ArrayMatcher) Option {
+	return func(d *Differ) {
+		d.arrayMatchers = append(d.arrayMatchers, pathArrayMatcher{pattern: path, matcher: m})
+	}
+}
+
+// arrayMatcher returns the matcher for the array at fieldName
+func (d *Differ) arrayMatcher(fieldName FieldName) (ArrayMatcher, bool) {
+	for _, m := range d.arrayMatchers {
+		if MatchPath(m.pattern, fieldName) {
+			return m.matcher, true
+		}
+	}
+	return d.strategicMatcher(fieldName)
+}
+
+// matcherEquivalence returns the equivalence function for the array
+// at fieldName using m, and whether paired elements are compared
+// recursively
+func (d *Differ) matcherEquivalence(m ArrayMatcher, fieldName FieldName, computeEq func(node1, node2 []interface{}) dualMap) (func(node1, node2 []interface{}) dualMap, bool) {
+	if b, ok := m.(builtinMatcher); ok {
+		return b.equivalence(d, fieldName, computeEq)
+	}
+	return func(node1, node2 []interface{}) dualMap {
+		equivalence := newDualMap(len(node1), len(node2))
+		for i, j := range m.Match(node1, node2) {
+			// Ignore invalid pairs
+			if i < 0 || i >= len(node1) || j < 0 || j >= len(node2) || equivalence.getOldIndex(j) != -1 {
+				continue
+			}
+			equivalence.insert(i, j)
+		}
+		return equivalence
+	}, true
+}
+
+// builtinMatch implements ArrayMatcher.Match for the builtin matchers
+// using a Differ with default options
+func builtinMatch(m builtinMatcher, node1, node2 []interface{}) map[int]int {
+	d := NewDiffer()
+	eq, _ := m.equivalence(d, FieldName{}, d.valueBasedEquivalence)
+	return eq(node1, node2).pairs()
+}
+
+type valueMatcher struct{}
+
+// ValueMatcher pairs equal elements. This is the default matcher.
+// Equal elements are paired even if their order is different, in
+// which case they are reported as moved.
+func ValueMatcher() ArrayMatcher { return valueMatcher{} }
+
+func (m valueMatcher) Match(node1, node2 []interface{}) map[int]int {
+	return builtinMatch(m, node1, node2)
+}
+
+func (valueMatcher) equivalence(d *Differ, fieldName FieldName, computeEq func(node1, node2 []interface{}) dualMap) (func(node1, node2 []interface{}) dualMap, bool) {
+	return computeEq, false
+}
+
+type keyMatcher struct {
+	key string
+}
+
+// KeyMatcher pairs object elements that have the same value for the
+// key field. Elements that are not objects, or that do not have the
+// key field are paired by value. This is the matcher used by
+// ArrayKey.
+func KeyMatcher(key string) ArrayMatcher { return keyMatcher{key: key} }
+
+func (m keyMatcher) Match(node1, node2 []interface{}) map[int]int {
+	return builtinMatch(m, node1, node2)
+}
+
+func (m keyMatcher) equivalence(d *Differ, fieldName FieldName, computeEq func(node1, node2 []interface{}) dualMap) (func(node1, node2 []interface{}) dualMap, bool) {
+	return d.keyBasedEquivalence(m.key, computeEq), true
+}
+
+type lcsMatcher struct{}
+
+// LCSMatcher pairs the longest common subsequence of equal elements,
+// like a line based text diff. Elements are never reported as moved:
+// an element that changes its position relative to the others is
+// reported as deleted and inserted.
+func LCSMatcher() ArrayMatcher { return lcsMatcher{} }
+
+func (m lcsMatcher) Match(node1, node2 []interface{}) map[int]int {
+	return builtinMatch(m, node1, node2)
+}
+
+func (lcsMatcher) equivalence(d *Differ, fieldName FieldName, computeEq func(node1, node2 []interface{}) dualMap) (func(node1, node2 []interface{}) dualMap, bool) {
+	return func(node1, node2 []interface{}) dualMap {
+		var equivalence dualMap
+		if !d.customEquality() && len(node1)*len(node2) <= maxLCSCells {
+			ids1, ids2, _ := d.elementClasses(node1, node2)
+			equivalence = newDualMap(len(node1), len(node2))
+			d.lcsPairs(ids1, ids2, 0, len(ids1), len(ids2), equivalence)
+			return equivalence
+		}
+		// Keep the longest sequence of pairs that are in the same order
+		return unmovedMatches(computeEq(node1, node2), len(node1), len(node2))
+	}, false
+}
+
+type positionalMatcher struct{}
+
+// PositionalMatcher pairs the elements at the same index. Paired
+// elements are compared recursively, so changes inside the elements
+// are reported as changes to their fields. If the arrays have
+// different lengths, the extra elements are reported as deleted or
+// inserted.
+func PositionalMatcher() ArrayMatcher { return positionalMatcher{} }
+
+func (m positionalMatcher) Match(node1, node2 []interface{}) map[int]int {
+	return builtinMatch(m, node1, node2)
+}
+
+func (positionalMatcher) equivalence(d *Differ, fieldName FieldName, computeEq func(node1, node2 []interface{}) dualMap) (func(node1, node2 []interface{}) dualMap, bool) {
+	return func(node1, node2 []interface{}) dualMap {
+		equivalence := newDualMap(len(node1), len(node2))
+		for i := 0; i < len(node1) && i < len(node2); i++ {
+			equivalence.insert(i, i)
+		}
+		return equivalence
+	}, true
+}
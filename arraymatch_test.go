@@ -0,0 +1,49 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+// evenMatcher pairs the elements at even indexes
+type evenMatcher struct{}
+
+func (evenMatcher) Match(node1, node2 []interface{}) map[int]int {
+	ret := make(map[int]int)
+	for i := 0; i < len(node1) && i < len(node2); i += 2 {
+		ret[i] = i
+	}
+	// Invalid pairs are ignored
+	ret[100] = 0
+	return ret
+}
+
+func testMatcher(t *testing.T, m ArrayMatcher, s1, s2 string, ins, del, mov, mod int) {
+	doc1, _ := parse(s1)
+	doc2, _ := parse(s2)
+	deltas := NewDiffer(MatchArrays("a", m)).Difference(doc1, doc2)
+	counts := map[DiffType]int{}
+	for _, d := range deltas {
+		counts[d.GetType()]++
+	}
+	if counts[DiffIns] != ins || counts[DiffDel] != del || counts[DiffMove] != mov || counts[DiffMod] != mod {
+		t.Errorf("Wrong deltas for %T %s -> %s: %v", m, s1, s2, deltas)
+	}
+	result, err := Apply(doc1, deltas)
+	if err != nil || !IsEqual(result, doc2) {
+		t.Errorf("Wrong result for %T %s -> %s: %v %v", m, s1, s2, result, err)
+	}
+}
+
+func TestArrayMatchers(t *testing.T) {
+	testMatcher(t, ValueMatcher(), `{"a":[1,2,3]}`, `{"a":[3,1,2]}`, 0, 0, 1, 0)
+	testMatcher(t, LCSMatcher(), `{"a":[1,2,3]}`, `{"a":[3,1,2]}`, 1, 1, 0, 0)
+	testMatcher(t, PositionalMatcher(), `{"a":[1,2,3]}`, `{"a":[3,1]}`, 0, 1, 0, 2)
+	testMatcher(t, PositionalMatcher(), `{"a":[{"x":1},{"x":2}]}`, `{"a":[{"x":1},{"x":3},{"x":4}]}`, 1, 0, 0, 1)
+	testMatcher(t, KeyMatcher("id"), `{"a":[{"id":1,"x":1},{"id":2}]}`, `{"a":[{"id":2},{"id":1,"x":2}]}`, 0, 0, 1, 1)
+	testMatcher(t, evenMatcher{}, `{"a":[1,2,3]}`, `{"a":[4,5,6]}`, 1, 1, 0, 2)
+
+	m := LCSMatcher().Match([]interface{}{1.0, 2.0, 3.0, 4.0}, []interface{}{2.0, 4.0, 3.0})
+	if len(m) != 2 || m[1] != 0 {
+		t.Errorf("Wrong LCS match: %v", m)
+	}
+}
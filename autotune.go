@@ -0,0 +1,38 @@
+package jsondiff
+
+// WithAutoTune selects array matching options for each pair of
+// documents from their profiles: arrays of objects with a unique key
+// field in both documents are matched by that key, as if ArrayKey was
+// given, so changes to their elements are reported as changes to
+// their fields. Arrays that already have a matcher, or that are
+// compared as sets, are not changed. Each choice is reported as a
+// WarnAutoTuned warning, see OnWarning.
+func WithAutoTune() Option {
+	return func(d *Differ) {
+		d.autoTune = true
+	}
+}
+
+// tune applies the options recommended for the profiles of the
+// documents to the run
+func (d *Differ) tune(node1, node2 interface{}) {
+	if !d.autoTune {
+		return
+	}
+	p := Profile(node1).merge(Profile(node2))
+	// Options append to the matchers, which must not change the
+	// matchers of the Differ the run is copied from
+	d.arrayMatchers = d.arrayMatchers[:len(d.arrayMatchers):len(d.arrayMatchers)]
+	for _, r := range p.Recommend() {
+		a, ok := p.Arrays[r.pattern]
+		if !ok {
+			// Only array strategies are selected
+			continue
+		}
+		if _, ok := d.arrayMatcher(a.Path); ok || d.arraysAsSets.matches(a.Path) {
+			continue
+		}
+		r.Option(d)
+		d.warnf(WarnAutoTuned, a.Path, "%s: %s", r.Description, r.Reason)
+	}
+}
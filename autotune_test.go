@@ -0,0 +1,33 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestWithAutoTune(t *testing.T) {
+	doc1, _ := parse(`{"users":[{"id":1,"name":"a"},{"id":2,"name":"b"}],"sets":[{"k":1},{"k":2}]}`)
+	doc2, _ := parse(`{"users":[{"id":2,"name":"c"},{"id":1,"name":"a"}],"sets":[{"k":2},{"k":1}]}`)
+	var warnings []Warning
+	d := NewDiffer(WithAutoTune(), ArraysAsSets("sets"), OnWarning(func(w Warning) {
+		warnings = append(warnings, w)
+	}))
+	deltas := d.Difference(doc1, doc2)
+	// users/1/name is modified, and users/0 is moved
+	if len(deltas) != 2 {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+	if len(warnings) != 1 || warnings[0].Kind != WarnAutoTuned || warnings[0].Name.String() != "users" {
+		t.Errorf("Wrong warnings: %v", warnings)
+	}
+	if len(d.arrayMatchers) != 0 {
+		t.Errorf("Differ is changed")
+	}
+
+	// The key must be unique in both documents
+	doc2, _ = parse(`{"users":[{"id":2,"name":"a"},{"id":2,"name":"a"}]}`)
+	warnings = nil
+	d.Difference(doc1, doc2)
+	if len(warnings) != 0 {
+		t.Errorf("Wrong warnings: %v", warnings)
+	}
+}
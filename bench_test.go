@@ -0,0 +1,103 @@
+package jsondiff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// benchDoc returns a document with n records. Records with an index
+// that is a multiple of change have different values in the second
+// version of the document
+func benchDoc(n, change int, second bool) interface{} {
+	records := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		status := "active"
+		if second && change > 0 && i%change == 0 {
+			status = "inactive"
+		}
+		records = append(records, map[string]interface{}{
+			"id":     float64(i),
+			"name":   fmt.Sprintf("record %d", i),
+			"status": status,
+			"tags":   []interface{}{"a", "b", fmt.Sprint(i % 7)},
+			"nested": map[string]interface{}{"x": float64(i % 3), "y": true},
+		})
+	}
+	return map[string]interface{}{"records": records, "count": float64(n)}
+}
+
+// benchWideArray returns an array of n numbers, with every change'th
+// element removed in the second version
+func benchWideArray(n, change int, second bool) interface{} {
+	ret := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		if second && i%change == 0 {
+			continue
+		}
+		ret = append(ret, float64(i))
+	}
+	return ret
+}
+
+func benchmarkDifference(b *testing.B, doc1, doc2 interface{}, opts ...Option) {
+	d := NewDiffer(opts...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Difference(doc1, doc2)
+	}
+}
+
+func BenchmarkSmall(b *testing.B) {
+	benchmarkDifference(b, benchDoc(5, 2, false), benchDoc(5, 2, true))
+}
+
+func BenchmarkMedium(b *testing.B) {
+	benchmarkDifference(b, benchDoc(100, 10, false), benchDoc(100, 10, true))
+}
+
+func BenchmarkLarge(b *testing.B) {
+	benchmarkDifference(b, benchDoc(5000, 50, false), benchDoc(5000, 50, true))
+}
+
+func BenchmarkLargeKeyed(b *testing.B) {
+	benchmarkDifference(b, benchDoc(5000, 50, false), benchDoc(5000, 50, true), ArrayKey("records", "id"))
+}
+
+func BenchmarkDeep(b *testing.B) {
+	benchmarkDifference(b, deepDoc(7, 2, "a"), deepDoc(7, 2, "b"))
+}
+
+func BenchmarkWideArray(b *testing.B) {
+	benchmarkDifference(b, benchWideArray(20000, 100, false), benchWideArray(20000, 100, true))
+}
+
+func BenchmarkEqualLarge(b *testing.B) {
+	doc := benchDoc(5000, 0, false)
+	benchmarkDifference(b, doc, benchDoc(5000, 0, false))
+}
+
+// benchStrings returns an array of n objects with long string
+// values. Every change'th object is different in the second version
+func benchStrings(n, change int, second bool) interface{} {
+	text := strings.Repeat("lorem ipsum dolor sit amet ", 20)
+	ret := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		obj := map[string]interface{}{
+			"description": text + strconv.Itoa(i),
+			"summary":     text[:100] + strconv.Itoa(i),
+			"body":        text + text,
+		}
+		if second && i%change == 0 {
+			obj["summary"] = "changed"
+		}
+		ret = append(ret, obj)
+	}
+	return ret
+}
+
+func BenchmarkStrings(b *testing.B) {
+	benchmarkDifference(b, benchStrings(5000, 50, false), benchStrings(5000, 50, true))
+}
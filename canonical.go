@@ -0,0 +1,103 @@
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// CanonicalJSON returns the canonical JSON encoding of a value, as
+// written in serialized deltas and by the formatters. The encoding is
+// the same across runs and Go versions: object keys are sorted,
+// numbers use the shortest representation that reads back to the same
+// float64, -0 is written as 0, and json.Number values are written as
+// they are, so integers decoded with UseNumber stay integers. Values
+// that are not part of the JSON node model are encoded using
+// encoding/json. An error is returned for values that cannot be
+// encoded, such as NaN.
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// canonicalValue returns the canonical encoding of v to be embedded
+// in a serialized delta, or nil if v is nil or cannot be encoded
+// canonically. A nil value is omitted from the delta
+func canonicalValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	data, err := CanonicalJSON(v)
+	if err != nil {
+		return v
+	}
+	return json.RawMessage(data)
+}
+
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch k := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(k))
+		for key := range k {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, key); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, k[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, x := range k {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, x); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case float64:
+		if k == 0 {
+			// -0 == 0
+			k = 0
+		}
+		v = k
+	case float32:
+		if k == 0 {
+			k = 0
+		}
+		v = k
+	case big.Int:
+		buf.WriteString(k.String())
+		return nil
+	case big.Float:
+		buf.WriteString(k.Text('g', -1))
+		return nil
+	}
+	// encoding/json writes floats in the shortest form that reads back
+	// to the same value, which does not depend on the Go version
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("cannot encode %v: %w", v, err)
+	}
+	buf.Write(data)
+	return nil
+}
@@ -0,0 +1,49 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	for _, tc := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{math.Copysign(0, -1), `0`},
+		{0.1, `0.1`},
+		{1e21, `1e+21`},
+		{1e-7, `1e-7`},
+		{float32(0.1), `0.1`},
+		{123456789.0, `123456789`},
+		{json.Number("1.50"), `1.50`},
+		{json.Number("12345678901234567890"), `12345678901234567890`},
+		{*big.NewInt(42), `42`},
+		{"<a&b>", `"\u003ca\u0026b\u003e"`},
+		{map[string]interface{}{"b": []interface{}{math.Copysign(0, -1), nil}, "a": true}, `{"a":true,"b":[0,null]}`},
+	} {
+		data, err := CanonicalJSON(tc.value)
+		if err != nil {
+			t.Errorf("Error for %v: %v", tc.value, err)
+			continue
+		}
+		if string(data) != tc.expected {
+			t.Errorf("Wrong encoding for %v: %s, expected %s", tc.value, data, tc.expected)
+		}
+	}
+	if _, err := CanonicalJSON(math.NaN()); err == nil {
+		t.Errorf("Expected error for NaN")
+	}
+}
+
+func TestCanonicalDeltas(t *testing.T) {
+	data, err := json.Marshal(Modification{Name: FieldName{KeySegment("a")}, Old: json.Number("10"), New: math.Copysign(0, -1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"v":2,"op":"*","path":["a"],"old":10,"new":0}` {
+		t.Errorf("Wrong encoding: %s", data)
+	}
+}
@@ -0,0 +1,98 @@
+package jsondiff
+
+import (
+	"sort"
+	"strings"
+)
+
+// CaseInsensitiveKeys compares object keys ignoring case, so
+// {"UserName":"x"} and {"username":"x"} are equal. A field whose key
+// only differs in case is compared with the field of the other
+// object, and its deltas are reported using the key in the new
+// document. If an object has several keys that only differ in case,
+// exact matches are paired first, and the remaining keys are paired
+// in sorted order.
+func CaseInsensitiveKeys() Option {
+	return func(d *Differ) {
+		d.foldKeys = true
+	}
+}
+
+// fieldPair is a field of the old object paired with a field of the
+// new object
+type fieldPair struct {
+	oldKey string
+	newKey string
+}
+
+// pairFields pairs the fields of two objects by their keys. It returns
+// the paired fields, the keys of node1 that are not paired, and the
+// keys of node2 that are not paired
+func (d *Differ) pairFields(node1, node2 map[string]interface{}) (pairs []fieldPair, removed, added []string) {
+	for key := range node1 {
+		if _, ok := node2[key]; ok {
+			pairs = append(pairs, fieldPair{oldKey: key, newKey: key})
+		} else {
+			removed = append(removed, key)
+		}
+	}
+	for key := range node2 {
+		if _, ok := node1[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	if !d.foldKeys || len(removed) == 0 || len(added) == 0 {
+		return
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	paired := make([]bool, len(added))
+	unpaired := make([]string, 0, len(removed))
+	for _, oldKey := range removed {
+		found := false
+		for i, newKey := range added {
+			if !paired[i] && strings.EqualFold(oldKey, newKey) {
+				paired[i] = true
+				found = true
+				pairs = append(pairs, fieldPair{oldKey: oldKey, newKey: newKey})
+				break
+			}
+		}
+		if !found {
+			unpaired = append(unpaired, oldKey)
+		}
+	}
+	removed = unpaired
+	unpaired = make([]string, 0, len(added))
+	for i, newKey := range added {
+		if !paired[i] {
+			unpaired = append(unpaired, newKey)
+		}
+	}
+	return pairs, removed, unpaired
+}
+
+// foldedObjectEqual checks if two objects are equal using the custom
+// equality functions of the Differ, pairing their fields with
+// pairFields
+func (d *Differ) foldedObjectEqual(fieldName FieldName, node1, node2 map[string]interface{}) bool {
+	pairs, removed, added := d.pairFields(node1, node2)
+	for _, p := range pairs {
+		name := fieldName.child(KeySegment(p.newKey))
+		v1, v2 := node1[p.oldKey], node2[p.newKey]
+		if !d.isEqual(name, v1, v2) && (!d.isAbsent(name, v1) || !d.isAbsent(name, v2)) {
+			return false
+		}
+	}
+	for _, key := range removed {
+		if !d.isAbsent(fieldName.child(KeySegment(key)), node1[key]) {
+			return false
+		}
+	}
+	for _, key := range added {
+		if !d.isAbsent(fieldName.child(KeySegment(key)), node2[key]) {
+			return false
+		}
+	}
+	return true
+}
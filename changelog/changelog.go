@@ -0,0 +1,224 @@
+// Package changelog converts jsondiff deltas into human readable
+// sentences, such as "field user.email changed from "a" to "b"" or
+// "2 items added to orders", for audit logs and change notifications.
+// The sentences are generated using text/template templates that can
+// be replaced.
+package changelog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+// Kind is the kind of a change log entry
+type Kind string
+
+// Change log entry kinds
+const (
+	// FieldChanged is a field whose value changed
+	FieldChanged Kind = "changed"
+	// FieldAdded is a field added to an object
+	FieldAdded Kind = "added"
+	// FieldRemoved is a field removed from an object
+	FieldRemoved Kind = "removed"
+	// FieldRenamed is an object field renamed without changing its value
+	FieldRenamed Kind = "renamed"
+	// ItemsAdded are elements inserted into an array
+	ItemsAdded Kind = "itemsAdded"
+	// ItemsRemoved are elements deleted from an array
+	ItemsRemoved Kind = "itemsRemoved"
+	// ItemsMoved are array elements that changed their position
+	ItemsMoved Kind = "itemsMoved"
+)
+
+// Entry is a single change log entry. It is the data passed to the
+// templates.
+type Entry struct {
+	Kind Kind
+	// Field is the changed field. For array entries, it is the array
+	Field jsondiff.FieldName
+	// Path is the field as text, like orders[2].status
+	Path string
+	// OldPath is the old field of a renamed field as text
+	OldPath string
+	// Old and New are the old and new values of a field
+	Old interface{}
+	New interface{}
+	// Count is the number of array elements for array entries
+	Count int
+}
+
+// DefaultTemplates are the templates used by Lines. The templates can
+// use the value function, that writes a value as JSON, and the plural
+// function, that selects the singular or plural form of a word for a
+// count.
+var DefaultTemplates = map[Kind]string{
+	FieldChanged: `field {{.Path}} changed from {{value .Old}} to {{value .New}}`,
+	FieldAdded:   `field {{.Path}} added with value {{value .New}}`,
+	FieldRemoved: `field {{.Path}} removed`,
+	FieldRenamed: `field {{.OldPath}} renamed to {{.Path}}`,
+	ItemsAdded:   `{{.Count}} {{plural .Count "item" "items"}} added to {{.Path}}`,
+	ItemsRemoved: `{{.Count}} {{plural .Count "item" "items"}} removed from {{.Path}}`,
+	ItemsMoved:   `{{.Count}} {{plural .Count "item" "items"}} moved in {{.Path}}`,
+}
+
+var funcs = template.FuncMap{
+	"value": func(v interface{}) string {
+		data, err := jsondiff.CanonicalJSON(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	},
+	"plural": func(n int, singular, plural string) string {
+		if n == 1 {
+			return singular
+		}
+		return plural
+	},
+}
+
+// Generator generates change log lines using templates
+type Generator struct {
+	templates map[Kind]*template.Template
+}
+
+// New returns a generator using the templates. The kinds that are not
+// in templates use the DefaultTemplates.
+func New(templates map[Kind]string) (*Generator, error) {
+	g := &Generator{templates: make(map[Kind]*template.Template)}
+	for kind, text := range DefaultTemplates {
+		if t, ok := templates[kind]; ok {
+			text = t
+		}
+		tmpl, err := template.New(string(kind)).Funcs(funcs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", kind, err)
+		}
+		g.templates[kind] = tmpl
+	}
+	return g, nil
+}
+
+var defaultGenerator, _ = New(nil)
+
+// Lines returns the change log lines for the deltas using the default
+// templates
+func Lines(deltas []jsondiff.Delta) []string {
+	lines, _ := defaultGenerator.Lines(deltas)
+	return lines
+}
+
+// Lines returns the change log lines for the deltas, one line for
+// each entry returned by Entries
+func (g *Generator) Lines(deltas []jsondiff.Delta) ([]string, error) {
+	entries := Entries(deltas)
+	ret := make([]string, 0, len(entries))
+	var sb strings.Builder
+	for _, e := range entries {
+		sb.Reset()
+		if err := g.templates[e.Kind].Execute(&sb, e); err != nil {
+			return nil, err
+		}
+		ret = append(ret, sb.String())
+	}
+	return ret, nil
+}
+
+// Entries converts deltas into change log entries. Changes to object
+// fields give one entry each. A Modification with a nil old value is
+// reported as an added field, and with a nil new value as a removed
+// field. Insertions, deletions, and moves of the elements of an array
+// are counted, and reported as one entry of each kind for the array,
+// in the position of the first such change.
+func Entries(deltas []jsondiff.Delta) []Entry {
+	var ret []Entry
+	// Index of the array entries in ret, by kind and array path
+	arrays := make(map[string]int)
+	element := func(kind Kind, name jsondiff.FieldName) {
+		parent := name[:len(name)-1]
+		key := string(kind) + " " + parent.String()
+		if ix, ok := arrays[key]; ok {
+			ret[ix].Count++
+			return
+		}
+		arrays[key] = len(ret)
+		ret = append(ret, Entry{Kind: kind, Field: parent, Path: Path(parent), Count: 1})
+	}
+	isElement := func(name jsondiff.FieldName) bool {
+		return len(name) > 0 && name[len(name)-1].IsIndex
+	}
+	for _, d := range deltas {
+		name := d.GetField()
+		switch x := d.(type) {
+		case jsondiff.Insertion:
+			if isElement(name) {
+				element(ItemsAdded, name)
+			} else {
+				ret = append(ret, Entry{Kind: FieldAdded, Field: name, Path: Path(name), New: x.NewNode})
+			}
+		case jsondiff.Deletion:
+			if isElement(name) {
+				element(ItemsRemoved, name)
+			} else {
+				ret = append(ret, Entry{Kind: FieldRemoved, Field: name, Path: Path(name), Old: x.DeletedNode})
+			}
+		case jsondiff.Move:
+			element(ItemsMoved, name)
+		case jsondiff.Rename:
+			old := append(append(jsondiff.FieldName{}, x.Name...), jsondiff.KeySegment(x.OldKey))
+			ret = append(ret, Entry{Kind: FieldRenamed, Field: name, Path: Path(name), OldPath: Path(old), Old: x.Value, New: x.Value})
+		case jsondiff.Modification:
+			e := Entry{Kind: FieldChanged, Field: name, Path: Path(name), Old: x.Old, New: x.New}
+			switch {
+			case x.Old == nil && x.New != nil && !isElement(name):
+				e.Kind = FieldAdded
+			case x.New == nil && x.Old != nil && !isElement(name):
+				e.Kind = FieldRemoved
+			}
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+// Path returns the field name in the form orders[2].status. Keys that
+// are not identifiers are quoted, like a["b c"]. The root is written
+// as "(root)".
+func Path(name jsondiff.FieldName) string {
+	if len(name) == 0 {
+		return "(root)"
+	}
+	var sb strings.Builder
+	for i, s := range name {
+		switch {
+		case s.IsIndex:
+			sb.WriteString("[" + strconv.Itoa(s.Index) + "]")
+		case isIdentifier(s.Key):
+			if i > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(s.Key)
+		default:
+			sb.WriteString("[" + strconv.Quote(s.Key) + "]")
+		}
+	}
+	return sb.String()
+}
+
+// isIdentifier returns if s can be written without quotes in a path
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !(c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
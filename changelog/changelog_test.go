@@ -0,0 +1,68 @@
+package changelog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+func parse(s string) (interface{}, error) {
+	var doc interface{}
+	e := json.Unmarshal([]byte(s), &doc)
+	return doc, e
+}
+
+func TestLines(t *testing.T) {
+	doc1, _ := parse(`{"user":{"email":"a@x","phone":"1"},"orders":[1,2],"tags":["a","b"]}`)
+	doc2, _ := parse(`{"user":{"email":"b@x","name":"N"},"orders":[1,2,3,4],"tags":["b"]}`)
+	lines := Lines(jsondiff.Difference(doc1, doc2))
+	expected := []string{
+		`field user.email changed from "a@x" to "b@x"`,
+		`field user.name added with value "N"`,
+		`field user.phone removed`,
+		`2 items added to orders`,
+		`1 item removed from tags`,
+	}
+	got := map[string]bool{}
+	for _, l := range lines {
+		got[l] = true
+	}
+	if len(lines) != len(expected) {
+		t.Errorf("Wrong lines: %s", strings.Join(lines, "\n"))
+	}
+	for _, e := range expected {
+		if !got[e] {
+			t.Errorf("Missing line %s: %s", e, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+func TestRenameLines(t *testing.T) {
+	doc1, _ := parse(`{"user":{"firstName":"A"}}`)
+	doc2, _ := parse(`{"user":{"first_name":"A"}}`)
+	lines := Lines(jsondiff.NewDiffer(jsondiff.DetectRenames()).Difference(doc1, doc2))
+	if len(lines) != 1 || lines[0] != `field user.firstName renamed to user.first_name` {
+		t.Errorf("Wrong lines: %s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	g, err := New(map[Kind]string{FieldChanged: `{{.Path}}: {{.Old}} => {{.New}}`})
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	name := jsondiff.FieldName{jsondiff.KeySegment("a b"), jsondiff.IndexSegment(1), jsondiff.KeySegment("c")}
+	lines, err := g.Lines([]jsondiff.Delta{
+		jsondiff.Modification{Name: name, Old: 1.0, New: 2.0},
+		jsondiff.Move{From: jsondiff.FieldName{jsondiff.IndexSegment(0)}, To: jsondiff.FieldName{jsondiff.IndexSegment(1)}},
+	})
+	if err != nil || len(lines) != 2 || lines[0] != `["a b"][1].c: 1 => 2` || lines[1] != `1 item moved in (root)` {
+		t.Errorf("Wrong lines: %v %v", lines, err)
+	}
+	if _, err := New(map[Kind]string{FieldAdded: `{{.Path`}); err == nil {
+		t.Errorf("Expected error")
+	}
+}
@@ -0,0 +1,57 @@
+package jsondiff
+
+// CollectionDiff is the difference between two collections of
+// documents
+type CollectionDiff struct {
+	// Added are the documents that are only in the new collection, in
+	// the order of the new collection
+	Added []RecordDelta
+	// Removed are the documents that are only in the old collection,
+	// in the order of the old collection
+	Removed []RecordDelta
+	// Modified are the documents that are in both collections and
+	// are different, in the order of the new collection
+	Modified []RecordDelta
+	// Unchanged is the number of documents that are in both
+	// collections and are equal
+	Unchanged int
+}
+
+// DiffCollections pairs the documents of two collections using the
+// key returned by keyFn, and compares the paired documents. Documents
+// with the same key are paired in order. The options are used to
+// compare the paired documents. It is the in-memory counterpart of
+// StreamDifference.
+func DiffCollections(old, new []interface{}, keyFn func(interface{}) string, opts ...Option) CollectionDiff {
+	var ret CollectionDiff
+	d := NewDiffer(opts...)
+	// Indexes of the old documents by key, in order
+	byKey := make(map[string][]int)
+	for i, doc := range old {
+		k := keyFn(doc)
+		byKey[k] = append(byKey[k], i)
+	}
+	paired := make([]bool, len(old))
+	for _, doc := range new {
+		k := keyFn(doc)
+		ixs := byKey[k]
+		if len(ixs) == 0 {
+			ret.Added = append(ret.Added, RecordDelta{Key: k, Type: DiffIns, New: doc})
+			continue
+		}
+		byKey[k] = ixs[1:]
+		paired[ixs[0]] = true
+		deltas := d.Difference(old[ixs[0]], doc)
+		if len(deltas) == 0 {
+			ret.Unchanged++
+			continue
+		}
+		ret.Modified = append(ret.Modified, RecordDelta{Key: k, Type: DiffMod, Old: old[ixs[0]], New: doc, Deltas: deltas})
+	}
+	for i, doc := range old {
+		if !paired[i] {
+			ret.Removed = append(ret.Removed, RecordDelta{Key: keyFn(doc), Type: DiffDel, Old: doc})
+		}
+	}
+	return ret
+}
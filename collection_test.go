@@ -0,0 +1,27 @@
+package jsondiff
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiffCollections(t *testing.T) {
+	old, _ := parse(`[{"id":1,"v":"a"},{"id":2,"v":"b"},{"id":3,"v":"c"},{"id":3,"v":"d"}]`)
+	new, _ := parse(`[{"id":4,"v":"e"},{"id":3,"v":"c"},{"id":2,"v":"x"}]`)
+	keyFn := func(doc interface{}) string {
+		return fmt.Sprint(doc.(map[string]interface{})["id"])
+	}
+	diff := DiffCollections(old.([]interface{}), new.([]interface{}), keyFn)
+	if len(diff.Added) != 1 || diff.Added[0].Key != "4" || diff.Added[0].Type != DiffIns {
+		t.Errorf("Wrong added: %v", diff.Added)
+	}
+	if len(diff.Removed) != 2 || diff.Removed[0].Key != "1" || diff.Removed[1].Old.(map[string]interface{})["v"] != "d" {
+		t.Errorf("Wrong removed: %v", diff.Removed)
+	}
+	if len(diff.Modified) != 1 || diff.Modified[0].Key != "2" || len(diff.Modified[0].Deltas) != 1 {
+		t.Errorf("Wrong modified: %v", diff.Modified)
+	}
+	if diff.Unchanged != 1 {
+		t.Errorf("Wrong unchanged: %d", diff.Unchanged)
+	}
+}
@@ -0,0 +1,112 @@
+package jsondiff
+
+import (
+	"fmt"
+)
+
+// Compatible checks that the deltas fit the structure of doc without
+// applying them. Every object key in a delta path must refer to an
+// object, and every array index to an array element. Deleted, moved,
+// renamed, and modified nodes must exist, and have the same kind (object,
+// array, or scalar) as the old value recorded in the delta. Paths
+// under elements inserted by the deltas cannot be checked, and are
+// skipped. It returns an error describing the first mismatch.
+func Compatible(doc interface{}, deltas []Delta) error {
+	paths := newOldPaths(deltas)
+	for _, d := range deltas {
+		var err error
+		switch x := d.(type) {
+		case Insertion:
+			err = checkElement(doc, x.Name, paths, nil, false)
+		case Deletion:
+			err = checkElement(doc, x.Name, paths, x.DeletedNode, true)
+		case Move:
+			err = checkElement(doc, x.From, paths, x.Old, true)
+		case Rename:
+			err = checkElement(doc, x.Name.child(KeySegment(x.OldKey)), paths, x.Value, true)
+		case Modification:
+			err = checkElement(doc, x.Name, paths, x.Old, x.Old != nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkElement checks the path of a delta against doc. The parent of
+// the changed node is resolved using the indexes of the new document
+// for enclosing arrays, and the last segment is checked as is. If
+// mustExist is set, the node must exist and have the same kind as old
+func checkElement(doc interface{}, name FieldName, paths oldPaths, old interface{}, mustExist bool) error {
+	if len(name) == 0 {
+		if mustExist && nodeKind(doc) != nodeKind(old) {
+			return fmt.Errorf("(root): expected %s, found %s", nodeKind(old), nodeKind(doc))
+		}
+		return nil
+	}
+	node := doc
+	for k, seg := range name {
+		last := k == len(name)-1
+		switch n := node.(type) {
+		case map[string]interface{}:
+			if seg.IsIndex {
+				return fmt.Errorf("%s: expected an array, found an object", pathText(name[:k]))
+			}
+			v, ok := n[seg.Key]
+			if !ok {
+				if last && !mustExist {
+					return nil
+				}
+				return fmt.Errorf("%s: field does not exist", name[:k+1])
+			}
+			node = v
+		case []interface{}:
+			if !seg.IsIndex {
+				return fmt.Errorf("%s: expected an object, found an array", pathText(name[:k]))
+			}
+			ix := seg.Index
+			if !last {
+				var ok bool
+				if ix, ok = paths.oldArrayIndex(name[:k], ix); !ok {
+					// Under an inserted element
+					return nil
+				}
+			}
+			if ix >= len(n) {
+				if last && !mustExist {
+					return nil
+				}
+				return fmt.Errorf("%s: index out of range", name[:k+1])
+			}
+			node = n[ix]
+		default:
+			return fmt.Errorf("%s: expected a container, found %s", pathText(name[:k]), nodeKind(node))
+		}
+	}
+	if mustExist && nodeKind(node) != nodeKind(old) {
+		return fmt.Errorf("%s: expected %s, found %s", name, nodeKind(old), nodeKind(node))
+	}
+	return nil
+}
+
+// nodeKind returns the kind of a node for error messages
+func nodeKind(node interface{}) string {
+	switch node.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case nil:
+		return "null"
+	}
+	return "a scalar"
+}
+
+// pathText returns the field name for error messages
+func pathText(name FieldName) string {
+	if len(name) == 0 {
+		return "(root)"
+	}
+	return name.String()
+}
@@ -0,0 +1,36 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestCompatible(t *testing.T) {
+	doc1, _ := parse(`{"a":{"b":[1,2,{"c":1}]},"d":"x"}`)
+	doc2, _ := parse(`{"a":{"b":[0,{"c":2},1],"e":[]},"d":{"y":1}}`)
+	deltas := NewDiffer(ArrayKey("a/b", "c")).Difference(doc1, doc2)
+	if err := Compatible(doc1, deltas); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	other, _ := parse(`{"a":[1,2],"d":{"x":1}}`)
+	if err := Compatible(other, deltas); err == nil {
+		t.Errorf("Expected error")
+	}
+	tests := []struct {
+		doc   string
+		delta Delta
+	}{
+		{`{"a":1}`, Modification{Name: keys("a", "b"), Old: float64(1), New: float64(2)}},
+		{`{"a":{"b":1}}`, Modification{Name: keys("a", "b"), Old: map[string]interface{}{}, New: float64(2)}},
+		{`{"a":[1]}`, Deletion{Name: FieldName{KeySegment("a"), IndexSegment(1)}, DeletedNode: float64(1)}},
+		{`{"a":{"0":1}}`, Deletion{Name: FieldName{KeySegment("a"), IndexSegment(0)}, DeletedNode: float64(1)}},
+		{`{"a":[]}`, Modification{Name: keys("a", "x"), New: float64(1)}},
+		{`{}`, Modification{Name: keys("a", "x"), New: float64(1)}},
+	}
+	for _, test := range tests {
+		doc, _ := parse(test.doc)
+		if err := Compatible(doc, []Delta{test.delta}); err == nil {
+			t.Errorf("Expected error for %s %v", test.doc, test.delta)
+		}
+	}
+}
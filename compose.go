@@ -0,0 +1,400 @@
+package jsondiff
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Compose combines two sequential sets of deltas into a single set:
+// if a transforms doc1 to doc2, and b transforms doc2 to doc3, then
+// the result transforms doc1 to doc3. Redundant changes are
+// collapsed. For example, a modification followed by a deletion
+// becomes the deletion, an insertion followed by a deletion of the
+// same element disappears, and changes that restore the original
+// value are dropped. Renames are composed as a removed field and an
+// added field. The result is sorted by field name.
+//
+// Composing needs the values of some intermediate nodes, which are
+// computed by applying parts of the deltas with opts. An error is
+// returned if the deltas cannot be applied, which means that b does
+// not follow a. Pass SetNullFields to compose deltas computed with the
+// ObjectFieldDeltas option.
+func Compose(a, b []Delta, opts ...ApplyOption) ([]Delta, error) {
+	c := composer{opts: opts}
+	for _, opt := range opts {
+		opt(&c.applier)
+	}
+	ret, err := c.composeNode(0, FieldName{}, expandRenames(a), expandRenames(b))
+	if err != nil {
+		return nil, fmt.Errorf("cannot compose: %w", err)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if c := compareFieldNames(ret[i].GetField(), ret[j].GetField()); c != 0 {
+			return c < 0
+		}
+		return ret[i].GetType() < ret[j].GetType()
+	})
+	return ret, nil
+}
+
+// composer composes deltas. The options of Apply select how the
+// deltas are interpreted
+type composer struct {
+	applier
+	opts []ApplyOption
+}
+
+// composeNode composes the deltas under a node. The first depth
+// segments of the field names of a and b refer to the node, using the
+// array indexes of their own documents. out is the field name of the
+// node in the result
+func (c composer) composeNode(depth int, out FieldName, a, b []Delta) ([]Delta, error) {
+	if len(b) == 0 {
+		return rebaseDeltas(a, depth, out), nil
+	}
+	if len(a) == 0 {
+		return rebaseDeltas(b, depth, out), nil
+	}
+	var aOwn, aSub, bOwn, bSub []Delta
+	for _, d := range a {
+		if len(d.GetField()) == depth {
+			aOwn = append(aOwn, d)
+		} else {
+			aSub = append(aSub, d)
+		}
+	}
+	for _, d := range b {
+		if len(d.GetField()) == depth {
+			bOwn = append(bOwn, d)
+		} else {
+			bSub = append(bSub, d)
+		}
+	}
+	if len(bOwn) > 0 {
+		// b replaces the node, so the changes of a under the node are
+		// only needed to find the original value
+		after := c.nodeStateAfter(bOwn[0])
+		var before nodeState
+		if len(aOwn) > 0 {
+			before = c.nodeStateBefore(aOwn[0])
+		} else {
+			before = c.nodeStateBefore(bOwn[0])
+			v, err := c.revertDeltas(before.value, aSub, depth)
+			if err != nil {
+				return nil, err
+			}
+			before.value = v
+		}
+		return composedDelta(out, before, after, isInsDel(aOwn) || isInsDel(bOwn)), nil
+	}
+	if len(aOwn) > 0 {
+		// a replaces the node, so the changes of b under the node are
+		// applied to the new value
+		before := c.nodeStateBefore(aOwn[0])
+		after := c.nodeStateAfter(aOwn[0])
+		if len(bSub) > 0 {
+			v, err := Apply(after.value, rebaseDeltas(bSub, depth, FieldName{}), c.opts...)
+			if err != nil {
+				return nil, err
+			}
+			after.value = v
+		}
+		return composedDelta(out, before, after, isInsDel(aOwn)), nil
+	}
+	for _, d := range append(aSub, bSub...) {
+		if d.GetField()[depth].IsIndex {
+			return c.composeArray(depth, out, aSub, bSub)
+		}
+	}
+	return c.composeObject(depth, out, aSub, bSub)
+}
+
+// composeObject composes the changes under an object node
+func (c composer) composeObject(depth int, out FieldName, a, b []Delta) ([]Delta, error) {
+	var keys []Segment
+	aGroups := make(map[Segment][]Delta)
+	bGroups := make(map[Segment][]Delta)
+	for _, d := range a {
+		seg := d.GetField()[depth]
+		if _, ok := aGroups[seg]; !ok {
+			keys = append(keys, seg)
+		}
+		aGroups[seg] = append(aGroups[seg], d)
+	}
+	for _, d := range b {
+		seg := d.GetField()[depth]
+		if _, ok := aGroups[seg]; !ok {
+			if _, ok := bGroups[seg]; !ok {
+				keys = append(keys, seg)
+			}
+		}
+		bGroups[seg] = append(bGroups[seg], d)
+	}
+	var ret []Delta
+	for _, key := range keys {
+		d, err := c.composeNode(depth+1, out.child(key), aGroups[key], bGroups[key])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, d...)
+	}
+	return ret, nil
+}
+
+// arrayOps contains the element insertions, deletions, and moves of
+// an array, and the changes nested under the elements
+type arrayOps struct {
+	*arrayChanges
+	moved    map[int]Move
+	inserted map[int]interface{}
+	deleted  map[int]interface{}
+	nested   map[int][]Delta
+}
+
+func newArrayOps(depth int, deltas []Delta) arrayOps {
+	ops := arrayOps{arrayChanges: newArrayChanges(),
+		moved:    make(map[int]Move),
+		inserted: make(map[int]interface{}),
+		deleted:  make(map[int]interface{}),
+		nested:   make(map[int][]Delta),
+	}
+	for _, d := range deltas {
+		name := d.GetField()
+		ix := name[depth].Index
+		if len(name) > depth+1 {
+			ops.nested[ix] = append(ops.nested[ix], d)
+			continue
+		}
+		switch x := d.(type) {
+		case Insertion:
+			ops.add(ix)
+			ops.inserted[ix] = x.NewNode
+		case Deletion:
+			ops.remove(ix)
+			ops.deleted[ix] = x.DeletedNode
+		case Move:
+			from := x.From[depth].Index
+			ops.moved[from] = x
+			ops.move(from, ix)
+		default:
+			// Modification of the element
+			ops.nested[ix] = append(ops.nested[ix], d)
+		}
+	}
+	return ops
+}
+
+// composeArray composes the changes under an array node
+func (c composer) composeArray(depth int, out FieldName, a, b []Delta) ([]Delta, error) {
+	aOps := newArrayOps(depth, a)
+	bOps := newArrayOps(depth, b)
+	var ret []Delta
+	// Elements of the original array removed or moved by a
+	for i := range aOps.removed {
+		if m, ok := aOps.moved[i]; ok {
+			mid := m.To[len(m.To)-1].Index
+			k, ok := bOps.newIndex(mid)
+			if !ok {
+				ret = append(ret, Deletion{Name: out.child(IndexSegment(i)), DeletedNode: m.Old})
+				continue
+			}
+			newValue := m.New
+			if bm, ok := bOps.moved[mid]; ok {
+				newValue = bm.New
+			}
+			ret = append(ret, Move{From: out.child(IndexSegment(i)),
+				To:  out.child(IndexSegment(k)),
+				Old: m.Old,
+				New: newValue})
+			continue
+		}
+		ret = append(ret, Deletion{Name: out.child(IndexSegment(i)), DeletedNode: aOps.deleted[i]})
+	}
+	// Elements of the intermediate array removed or moved by b
+	for mid := range bOps.removed {
+		if _, ok := aOps.added[mid]; ok {
+			if _, ok := aOps.inserted[mid]; ok {
+				// Inserted by a
+				if bm, ok := bOps.moved[mid]; ok {
+					ret = append(ret, Insertion{Name: out.child(bm.To[len(bm.To)-1]), NewNode: bm.New})
+				}
+			}
+			// Moved by a, already processed
+			continue
+		}
+		i, _ := aOps.oldIndex(mid)
+		if bm, ok := bOps.moved[mid]; ok {
+			old, err := c.revertDeltas(bm.Old, aOps.nested[mid], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, Move{From: out.child(IndexSegment(i)),
+				To:  out.child(bm.To[len(bm.To)-1]),
+				Old: old,
+				New: bm.New})
+			continue
+		}
+		old, err := c.revertDeltas(bOps.deleted[mid], aOps.nested[mid], depth+1)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, Deletion{Name: out.child(IndexSegment(i)), DeletedNode: old})
+	}
+	// Elements inserted by a, and not removed by b
+	bApplied := make(map[int]struct{})
+	for mid, value := range aOps.inserted {
+		k, ok := bOps.newIndex(mid)
+		if !ok {
+			continue
+		}
+		if _, ok := bOps.moved[mid]; ok {
+			continue
+		}
+		if nested := bOps.nested[k]; len(nested) > 0 {
+			v, err := Apply(value, rebaseDeltas(nested, depth+1, FieldName{}), c.opts...)
+			if err != nil {
+				return nil, err
+			}
+			value = v
+			bApplied[k] = struct{}{}
+		}
+		ret = append(ret, Insertion{Name: out.child(IndexSegment(k)), NewNode: value})
+	}
+	// Elements inserted by b
+	for k, value := range bOps.inserted {
+		ret = append(ret, Insertion{Name: out.child(IndexSegment(k)), NewNode: value})
+	}
+	// Changes nested under the elements
+	for mid, nested := range aOps.nested {
+		k, ok := bOps.newIndex(mid)
+		if !ok {
+			continue
+		}
+		bApplied[k] = struct{}{}
+		d, err := c.composeNode(depth+1, out.child(IndexSegment(k)), nested, bOps.nested[k])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, d...)
+	}
+	for k, nested := range bOps.nested {
+		if _, ok := bApplied[k]; ok {
+			continue
+		}
+		ret = append(ret, rebaseDeltas(nested, depth+1, out.child(IndexSegment(k)))...)
+	}
+	return ret, nil
+}
+
+// nodeState is the value of a node, or its absence
+type nodeState struct {
+	exists bool
+	value  interface{}
+}
+
+// nodeStateBefore returns the state of a node before the delta is applied
+func (c composer) nodeStateBefore(d Delta) nodeState {
+	switch x := d.(type) {
+	case Insertion:
+		return nodeState{}
+	case Deletion:
+		return nodeState{exists: true, value: x.DeletedNode}
+	case Modification:
+		// A Modification with a nil old value adds an object field,
+		// unless nil values are nulls
+		return nodeState{exists: x.Old != nil || c.setNulls || !isObjectField(x.Name), value: x.Old}
+	case Move:
+		return nodeState{exists: true, value: x.Old}
+	}
+	return nodeState{}
+}
+
+// nodeStateAfter returns the state of a node after the delta is applied
+func (c composer) nodeStateAfter(d Delta) nodeState {
+	switch x := d.(type) {
+	case Insertion:
+		return nodeState{exists: true, value: x.NewNode}
+	case Deletion:
+		return nodeState{}
+	case Modification:
+		// A Modification with a nil new value removes an object field,
+		// as in Apply, unless nil values are nulls
+		return nodeState{exists: x.New != nil || c.setNulls || !isObjectField(x.Name), value: x.New}
+	case Move:
+		return nodeState{exists: true, value: x.New}
+	}
+	return nodeState{}
+}
+
+// isObjectField returns if name is the name of an object field, and
+// not an array element or the root
+func isObjectField(name FieldName) bool {
+	return len(name) > 0 && !name[len(name)-1].IsIndex
+}
+
+// isInsDel returns if the first delta is an insertion or deletion
+func isInsDel(deltas []Delta) bool {
+	if len(deltas) == 0 {
+		return false
+	}
+	t := deltas[0].GetType()
+	return t == DiffIns || t == DiffDel
+}
+
+// composedDelta returns the delta that changes the node from before
+// to after. If insDel is set, insertions and deletions are reported
+// as such, otherwise as modifications
+func composedDelta(name FieldName, before, after nodeState, insDel bool) []Delta {
+	if before.exists == after.exists && IsEqual(before.value, after.value) {
+		return nil
+	}
+	if insDel && !before.exists {
+		return []Delta{Insertion{Name: name, NewNode: after.value}}
+	}
+	if insDel && !after.exists {
+		return []Delta{Deletion{Name: name, DeletedNode: before.value}}
+	}
+	return []Delta{Modification{Name: name, Old: before.value, New: after.value,
+		TypeChanged: before.exists && after.exists && typeChanged(before.value, after.value)}}
+}
+
+// revertDeltas returns the value before the deltas under it are
+// applied. The first depth segments of the delta field names refer
+// to the value
+func (c composer) revertDeltas(value interface{}, deltas []Delta, depth int) (interface{}, error) {
+	if len(deltas) == 0 {
+		return value, nil
+	}
+	return Apply(value, Invert(rebaseDeltas(deltas, depth, FieldName{})), c.opts...)
+}
+
+// rebaseDeltas replaces the first depth segments of the field names
+// of the deltas with prefix
+func rebaseDeltas(deltas []Delta, depth int, prefix FieldName) []Delta {
+	rebase := func(name FieldName) FieldName {
+		ret := make(FieldName, 0, len(prefix)+len(name)-depth)
+		ret = append(ret, prefix...)
+		return append(ret, name[depth:]...)
+	}
+	ret := make([]Delta, 0, len(deltas))
+	for _, d := range deltas {
+		switch x := d.(type) {
+		case Insertion:
+			x.Name = rebase(x.Name)
+			ret = append(ret, x)
+		case Deletion:
+			x.Name = rebase(x.Name)
+			ret = append(ret, x)
+		case Move:
+			x.From = rebase(x.From)
+			x.To = rebase(x.To)
+			ret = append(ret, x)
+		case Modification:
+			x.Name = rebase(x.Name)
+			ret = append(ret, x)
+		default:
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,219 @@
+package jsondiff
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func testCompose(t *testing.T, differ *Differ, s1, s2, s3 string) []Delta {
+	doc1, err := parse(s1)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return nil
+	}
+	doc2, err := parse(s2)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return nil
+	}
+	doc3, err := parse(s3)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return nil
+	}
+	a := differ.Difference(doc1, doc2)
+	b := differ.Difference(doc2, doc3)
+	composed, err := Compose(a, b)
+	if err != nil {
+		t.Errorf("Cannot compose %v and %v: %s", a, b, err)
+		return nil
+	}
+	result, err := Apply(doc1, composed)
+	if err != nil {
+		t.Errorf("Cannot apply %v: %s", composed, err)
+		return nil
+	}
+	if !IsEqual(result, doc3) {
+		t.Errorf("Wrong result: %v, expected %v, a: %v, b: %v, composed: %v", result, doc3, a, b, composed)
+	}
+	return composed
+}
+
+func TestCompose(t *testing.T) {
+	differ := NewDiffer()
+	c := testCompose(t, differ, `{"a":1,"b":2}`, `{"a":2,"b":2}`, `{"a":1,"b":3}`)
+	if len(c) != 1 || c[0].GetField().String() != "b" {
+		t.Errorf("Wrong composition: %v", c)
+	}
+	c = testCompose(t, differ, `{"a":{"x":1},"b":2}`, `{"a":{"x":2},"b":2}`, `{"b":2}`)
+	if len(c) != 1 || c[0].(Modification).Old.(map[string]interface{})["x"].(float64) != 1 {
+		t.Errorf("Wrong composition: %v", c)
+	}
+	testCompose(t, differ, `{"a":1}`, `{"a":{"x":1}}`, `{"a":{"x":2,"y":1}}`)
+	testCompose(t, differ, `{"a":[1,2,3]}`, `{"a":[1,3,4]}`, `{"a":[4,1,5]}`)
+	testCompose(t, differ, `{"a":[1,2,3,4]}`, `{"a":[4,3,2,1]}`, `{"a":[2,4,1,3,5]}`)
+	c = testCompose(t, differ, `{"a":[1,2]}`, `{"a":[1,2,3]}`, `{"a":[1,2]}`)
+	if len(c) != 0 {
+		t.Errorf("Wrong composition: %v", c)
+	}
+	differ = NewDiffer(ArrayKey("u", "id"))
+	testCompose(t, differ, `{"u":[{"id":1,"x":1},{"id":2,"x":1},{"id":3}]}`,
+		`{"u":[{"id":3},{"id":1,"x":2},{"id":4}]}`,
+		`{"u":[{"id":4,"y":1},{"id":3,"z":1},{"id":1,"x":3}]}`)
+}
+
+func TestComposeRename(t *testing.T) {
+	differ := NewDiffer(DetectRenames())
+	c := testCompose(t, differ, `{"a":1,"b":{"x":1}}`, `{"a":1,"c":{"x":1}}`, `{"a":1}`)
+	if len(c) != 1 || c[0].GetField().String() != "b" {
+		t.Errorf("Wrong composition: %v", c)
+	}
+	c = testCompose(t, differ, `{"b":1}`, `{"c":1}`, `{"b":1}`)
+	if len(c) != 0 {
+		t.Errorf("Wrong composition: %v", c)
+	}
+	testCompose(t, differ, `{"b":1}`, `{"c":1}`, `{"c":2}`)
+}
+
+func TestComposeSorted(t *testing.T) {
+	differ := NewDiffer()
+	c := testCompose(t, differ, `{"c":1,"b":1,"a":[1,2],"d":{"x":1}}`,
+		`{"c":2,"b":1,"a":[1,3,2],"d":{"x":2,"y":1}}`,
+		`{"c":2,"b":2,"a":[0,1,3,2],"d":{"x":3,"z":1}}`)
+	for i := 1; i < len(c); i++ {
+		if compareFieldNames(c[i-1].GetField(), c[i].GetField()) > 0 {
+			t.Errorf("Not sorted: %v", c)
+		}
+	}
+}
+
+func TestComposeError(t *testing.T) {
+	a := []Delta{Modification{Name: FieldName{KeySegment("a")}, Old: 1.0, New: map[string]interface{}{"x": 1.0}}}
+	b := []Delta{Modification{Name: FieldName{KeySegment("a"), KeySegment("y"), KeySegment("z")}, Old: nil, New: 1.0}}
+	if c, err := Compose(a, b); err == nil {
+		t.Errorf("Expected error, got %v", c)
+	}
+}
+
+func TestComposeNullFields(t *testing.T) {
+	x := FieldName{KeySegment("x")}
+	c, err := Compose([]Delta{Insertion{Name: x, NewNode: 0.0}},
+		[]Delta{Modification{Name: x, Old: 0.0, New: nil}}, SetNullFields())
+	if err != nil || len(c) != 1 {
+		t.Errorf("Wrong composition: %v %v", c, err)
+	} else if ins, ok := c[0].(Insertion); !ok || ins.NewNode != nil {
+		t.Errorf("Wrong composition: %v", c)
+	}
+	y := FieldName{KeySegment("y")}
+	c, err = Compose([]Delta{Insertion{Name: y, NewNode: map[string]interface{}{"z": map[string]interface{}{"z": "a"}}}},
+		[]Delta{Modification{Name: y.child(KeySegment("z")), Old: map[string]interface{}{"z": "a"}, New: nil}}, SetNullFields())
+	expected := map[string]interface{}{"z": nil}
+	if err != nil || len(c) != 1 {
+		t.Errorf("Wrong composition: %v %v", c, err)
+	} else if ins, ok := c[0].(Insertion); !ok || !IsEqual(ins.NewNode, expected) {
+		t.Errorf("Wrong composition: %v", c)
+	}
+}
+
+// randomValue returns a random JSON value. null is only generated if
+// nulls is set
+func randomValue(r *rand.Rand, depth int, nulls bool) interface{} {
+	n := 6
+	if depth <= 0 {
+		n = 4
+	}
+	switch r.Intn(n) {
+	case 0:
+		return float64(r.Intn(3))
+	case 1:
+		return []string{"x", "y"}[r.Intn(2)]
+	case 2:
+		if nulls {
+			return nil
+		}
+		return true
+	case 3:
+		return float64(r.Intn(3))
+	case 4:
+		obj := map[string]interface{}{}
+		for _, k := range []string{"a", "b", "c"} {
+			if r.Intn(2) == 0 {
+				obj[k] = randomValue(r, depth-1, nulls)
+			}
+		}
+		return obj
+	}
+	arr := []interface{}{}
+	for i := r.Intn(5); i > 0; i-- {
+		arr = append(arr, randomValue(r, depth-1, nulls))
+	}
+	return arr
+}
+
+// mutate returns a random modification of v
+func mutate(r *rand.Rand, v interface{}, depth int, nulls bool) interface{} {
+	if r.Intn(5) == 0 {
+		return randomValue(r, depth, nulls)
+	}
+	switch x := v.(type) {
+	case map[string]interface{}:
+		ret := map[string]interface{}{}
+		for _, k := range []string{"a", "b", "c"} {
+			old, ok := x[k]
+			switch {
+			case ok && r.Intn(4) == 0:
+			case ok:
+				ret[k] = mutate(r, old, depth-1, nulls)
+			case r.Intn(3) == 0:
+				ret[k] = randomValue(r, depth-1, nulls)
+			}
+		}
+		return ret
+	case []interface{}:
+		ret := []interface{}{}
+		for _, e := range x {
+			switch r.Intn(4) {
+			case 0:
+			case 1:
+				ret = append(ret, randomValue(r, depth-1, nulls), e)
+			default:
+				ret = append(ret, mutate(r, e, depth-1, nulls))
+			}
+		}
+		r.Shuffle(len(ret), func(i, j int) {
+			if r.Intn(3) == 0 {
+				ret[i], ret[j] = ret[j], ret[i]
+			}
+		})
+		return ret
+	}
+	return v
+}
+
+func TestComposeRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, nulls := range []bool{false, true} {
+		differ := NewDiffer()
+		var opts []ApplyOption
+		if nulls {
+			differ = NewDiffer(ObjectFieldDeltas())
+			opts = []ApplyOption{SetNullFields()}
+		}
+		for i := 0; i < 2000; i++ {
+			doc1 := randomValue(r, 3, nulls)
+			doc2 := mutate(r, doc1, 3, nulls)
+			doc3 := mutate(r, doc2, 3, nulls)
+			a := differ.Difference(doc1, doc2)
+			b := differ.Difference(doc2, doc3)
+			composed, err := Compose(a, b, opts...)
+			if err != nil {
+				t.Errorf("Cannot compose %v and %v: %s", a, b, err)
+				continue
+			}
+			result, err := Apply(doc1, composed, opts...)
+			if err != nil || !IsEqual(result, doc3) {
+				t.Errorf("Wrong result %v %v for %v, %v, %v: a: %v, b: %v, composed: %v", result, err, doc1, doc2, doc3, a, b, composed)
+			}
+		}
+	}
+}
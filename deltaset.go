@@ -0,0 +1,99 @@
+package jsondiff
+
+// DeltaComparison is the result of comparing an observed set of deltas
+// with an expected set of deltas
+type DeltaComparison struct {
+	// Matched are the observed deltas that are expected
+	Matched []Delta
+	// Unexpected are the observed deltas that are not expected
+	Unexpected []Delta
+	// Missing are the expected deltas that are not observed
+	Missing []Delta
+}
+
+// Equal returns if the observed deltas are the same as the expected
+// deltas
+func (c DeltaComparison) Equal() bool {
+	return len(c.Unexpected) == 0 && len(c.Missing) == 0
+}
+
+// CompareDeltas compares an observed set of deltas with an expected
+// set of deltas, such as an approved change set. Two deltas are the
+// same if they have the same type and field, and describe the same
+// change. Each expected delta matches at most one observed delta. The
+// order of the deltas is not significant. The results are in the
+// order of observed and expected.
+func CompareDeltas(observed, expected []Delta) DeltaComparison {
+	var ret DeltaComparison
+	used := matchDeltas(observed, expected)
+	for i, d := range observed {
+		if used[i] >= 0 {
+			ret.Matched = append(ret.Matched, d)
+		} else {
+			ret.Unexpected = append(ret.Unexpected, d)
+		}
+	}
+	matched := make([]bool, len(expected))
+	for _, j := range used {
+		if j >= 0 {
+			matched[j] = true
+		}
+	}
+	for j, d := range expected {
+		if !matched[j] {
+			ret.Missing = append(ret.Missing, d)
+		}
+	}
+	return ret
+}
+
+// IntersectDeltas returns the deltas of a that are also in b, in the
+// order of a. Deltas are compared as in CompareDeltas
+func IntersectDeltas(a, b []Delta) []Delta {
+	return CompareDeltas(a, b).Matched
+}
+
+// SubtractDeltas returns the deltas of a that are not in b, in the
+// order of a. Deltas are compared as in CompareDeltas
+func SubtractDeltas(a, b []Delta) []Delta {
+	return CompareDeltas(a, b).Unexpected
+}
+
+// SubtractPaths returns the deltas of a whose field is not changed by
+// a delta of b, in the order of a. Unlike SubtractDeltas, the type and
+// the values of the deltas are not compared.
+func SubtractPaths(a, b []Delta) []Delta {
+	var ret []Delta
+	for _, d := range a {
+		found := false
+		for _, x := range b {
+			if sameField(d.GetField(), x.GetField()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+// matchDeltas pairs the equal deltas of a and b. It
+// returns the index of the delta of b paired with each delta of a, or
+// -1 if there is none
+func matchDeltas(a, b []Delta) []int {
+	ret := make([]int, len(a))
+	paired := make([]bool, len(b))
+	for i, d := range a {
+		ret[i] = -1
+		for j, x := range b {
+			if !paired[j] && isDeltaEqual(d, x) {
+				paired[j] = true
+				ret[i] = j
+				break
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,47 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestCompareDeltas(t *testing.T) {
+	base, _ := parse(`{"a":1,"b":"x","c":[1,2]}`)
+	approved, _ := parse(`{"a":2,"b":"x","c":[1,2,3]}`)
+	observed, _ := parse(`{"a":2,"b":"y","c":[1,2]}`)
+	expected := Difference(base, approved)
+	actual := Difference(base, observed)
+
+	c := CompareDeltas(actual, expected)
+	if c.Equal() {
+		t.Errorf("Expected differences")
+	}
+	if len(c.Matched) != 1 || c.Matched[0].GetField().String() != "a" {
+		t.Errorf("Wrong matched: %v", c.Matched)
+	}
+	if len(c.Unexpected) != 1 || c.Unexpected[0].GetField().String() != "b" {
+		t.Errorf("Wrong unexpected: %v", c.Unexpected)
+	}
+	if len(c.Missing) != 1 || c.Missing[0].GetField().String() != "c/2" {
+		t.Errorf("Wrong missing: %v", c.Missing)
+	}
+	if !CompareDeltas(expected, Difference(base, approved)).Equal() {
+		t.Errorf("Equal sets reported as different")
+	}
+
+	if d := IntersectDeltas(actual, expected); len(d) != 1 {
+		t.Errorf("Wrong intersection: %v", d)
+	}
+	if d := SubtractDeltas(actual, expected); len(d) != 1 || d[0].GetField().String() != "b" {
+		t.Errorf("Wrong subtraction: %v", d)
+	}
+
+	// a changed to a different value than approved is still a change
+	// of an approved path
+	other, _ := parse(`{"a":3,"b":"x","c":[1,2]}`)
+	if d := SubtractDeltas(Difference(base, other), expected); len(d) != 1 {
+		t.Errorf("Wrong subtraction: %v", d)
+	}
+	if d := SubtractPaths(Difference(base, other), expected); len(d) != 0 {
+		t.Errorf("Wrong path subtraction: %v", d)
+	}
+}
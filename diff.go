@@ -3,20 +3,11 @@ package jsondiff
 import (
 	"encoding/json"
 	"fmt"
-	"log"
-	"math/big"
-	"strconv"
-	"strings"
+	"io"
+	"reflect"
+	"sort"
 )
 
-func logDebugf(fmt string, args ...interface{}) {
-	log.Printf(fmt, args...)
-}
-
-func nopDebugf(fmt string, args ...interface{}) {}
-
-var debugf = nopDebugf
-
 // DiffType describes a difference type
 type DiffType string
 
@@ -26,15 +17,10 @@ const (
 	DiffDel  DiffType = "-"
 	DiffMove DiffType = "<->"
 	DiffMod  DiffType = "*"
+	// DiffRename is an object field renamed, see DetectRenames
+	DiffRename DiffType = "=>"
 )
 
-// FieldName contains field name parts
-type FieldName []string
-
-func (f FieldName) String() string {
-	return strings.Join(f, "/")
-}
-
 // Delta describes the difference between two corresponding nodes
 type Delta interface {
 	// GetType returns the type of delt
@@ -93,11 +79,62 @@ func (x Move) String() string {
 	return fmt.Sprintf("<-> %s -> %s", x.From, x.To)
 }
 
+// Rename describes an object field renamed from OldKey to NewKey
+// without changing its Value. Name is the field name of the object.
+// Renames are only reported with the DetectRenames option
+type Rename struct {
+	Name   FieldName
+	OldKey string
+	NewKey string
+	Value  interface{}
+}
+
+// GetField returns the new field name
+func (x Rename) GetField() FieldName { return x.Name.child(KeySegment(x.NewKey)) }
+
+// GetType returns the diff type
+func (x Rename) GetType() DiffType { return DiffRename }
+func (x Rename) String() string {
+	return fmt.Sprintf("=> %s -> %s", x.Name.child(KeySegment(x.OldKey)), x.GetField())
+}
+
+// expandRenames replaces each Rename with a Deletion of the old field
+// and an Insertion of the new field
+func expandRenames(deltas []Delta) []Delta {
+	var ret []Delta
+	for i, d := range deltas {
+		x, ok := d.(Rename)
+		if !ok {
+			if ret != nil {
+				ret = append(ret, d)
+			}
+			continue
+		}
+		if ret == nil {
+			ret = append(make([]Delta, 0, len(deltas)+1), deltas[:i]...)
+		}
+		ret = append(ret, Deletion{Name: x.Name.child(KeySegment(x.OldKey)), DeletedNode: x.Value},
+			Insertion{Name: x.GetField(), NewNode: x.Value})
+	}
+	if ret == nil {
+		return deltas
+	}
+	return ret
+}
+
 // Modification describes an edit where field is modified from Old to New
 type Modification struct {
 	Name FieldName
 	Old  interface{}
 	New  interface{}
+	// TextDiff is the difference between Old and New if they are
+	// both strings and the TextDiffs option is set
+	TextDiff []TextEdit
+	// TypeChanged is set if the field exists in both documents, and
+	// the JSON types of Old and New are different, for example an
+	// object replaced by an array, or null replaced by a string. Use
+	// KindOf to get the types.
+	TypeChanged bool
 }
 
 // GetField returns the name of the modified field
@@ -109,93 +146,303 @@ func (x Modification) String() string {
 	return fmt.Sprintf("* %s: (%v -> %v)", x.Name, x.Old, x.New)
 }
 
-//  Difference computes difference between two documents.
+// JSONDifference computes difference between two JSON documents. If
+// one of the documents cannot be parsed, the returned error is a
+// *ParseError.
 func JSONDifference(node1, node2 []byte) ([]Delta, error) {
 	var n1, n2 interface{}
 	err := json.Unmarshal(node1, &n1)
 	if err != nil {
-		return nil, nil
+		return nil, newParseError(1, -1, err)
 	}
 	err = json.Unmarshal(node2, &n2)
 	if err != nil {
-		return nil, nil
+		return nil, newParseError(2, -1, err)
 	}
 	return Difference(n1, n2), nil
 }
 
+// MustJSONDifference is like JSONDifference, but panics if one of the
+// documents cannot be parsed. It is intended for tests.
+func MustJSONDifference(node1, node2 []byte) []Delta {
+	d, err := JSONDifference(node1, node2)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// JSONDifferenceReaders computes difference between two documents
+// read from r1 and r2. The documents are decoded directly from the
+// readers, so the caller does not have to read them into memory
+// first. If one of the documents cannot be parsed, the returned error
+// is a *ParseError.
+func JSONDifferenceReaders(r1, r2 io.Reader, opts ...Option) ([]Delta, error) {
+	var n1, n2 interface{}
+	dec := json.NewDecoder(r1)
+	if err := dec.Decode(&n1); err != nil {
+		return nil, newParseError(1, dec.InputOffset(), err)
+	}
+	dec = json.NewDecoder(r2)
+	if err := dec.Decode(&n2); err != nil {
+		return nil, newParseError(2, dec.InputOffset(), err)
+	}
+	return NewDiffer(opts...).Difference(n1, n2), nil
+}
+
+// DifferenceOf computes difference between two arbitrary Go values,
+// such as structs, maps, and slices. The values are converted to the
+// JSON node model by marshaling them to JSON, so json field tags and
+// json.Marshaler implementations are honored.
+func DifferenceOf(v1, v2 interface{}, opts ...Option) ([]Delta, error) {
+	n1, err := toNode(v1)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert first value: %w", err)
+	}
+	n2, err := toNode(v2)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert second value: %w", err)
+	}
+	return NewDiffer(opts...).Difference(n1, n2), nil
+}
+
+// toNode converts a Go value to the JSON node model
+func toNode(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var node interface{}
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
 // Difference computes difference between two documents. node1 and
 // node2 are results of json.Unmarshal(&interface{})
 func Difference(node1, node2 interface{}) []Delta {
-	return nodeDifference(FieldName{}, node1, node2)
+	return NewDiffer().Difference(node1, node2)
 }
 
-func nodeDifference(fieldName FieldName, node1, node2 interface{}) []Delta {
+func (d *Differ) nodeDifference(fieldName FieldName, node1, node2 interface{}, emit func(Delta)) {
+	if d.stopped(fieldName) {
+		return
+	}
+	if fn := d.customEqual(fieldName, node1, node2); fn != nil {
+		if !fn(node1, node2) {
+			emit(Modification{Name: fieldName.clone(), Old: node1, New: node2,
+				TextDiff:    d.textDiff(node1, node2),
+				TypeChanged: typeChanged(node1, node2)})
+		}
+		return
+	}
 	if node1 == nil {
 		if node2 == nil {
-			return nil
+			return
 		}
-		return []Delta{Modification{Name: fieldName, Old: node1, New: node2}}
+		emit(Modification{Name: fieldName.clone(), Old: node1, New: node2, TypeChanged: true})
+		return
 	}
 	if node2 == nil {
-		return []Delta{Modification{Name: fieldName, Old: node1, New: node2}}
+		emit(Modification{Name: fieldName.clone(), Old: node1, New: node2, TypeChanged: true})
+		return
 	}
 	// Both are non-nil
 	switch n1 := node1.(type) {
 	case map[string]interface{}:
 		if n2, ok := node2.(map[string]interface{}); ok {
-			return objectNodeDifference(fieldName, n1, n2)
+			d.objectNodeDifference(fieldName, n1, n2, emit)
+			return
 		}
 	case []interface{}:
 		if n2, ok := node2.([]interface{}); ok {
-			return arrayNodeDifference(fieldName, n1, n2)
+			d.arrayNodeDifference(fieldName, n1, n2, emit)
+			return
 		}
 	default:
-		return valueNodeDifference(fieldName, n1, node2)
+		d.valueNodeDifference(fieldName, n1, node2, emit)
+		return
 	}
-	return []Delta{Modification{Name: fieldName, Old: node1, New: node2}}
+	emit(Modification{Name: fieldName.clone(), Old: node1, New: node2, TypeChanged: true})
 }
 
-func objectNodeDifference(fieldName FieldName, node1, node2 map[string]interface{}) []Delta {
-	var ret []Delta
+func (d *Differ) objectNodeDifference(fieldName FieldName, node1, node2 map[string]interface{}, emit func(Delta)) {
+	if d.keyOrder != nil {
+		d.keyMoves(fieldName, node1, node2, emit)
+	}
+	if d.renames || d.foldKeys {
+		d.pairedFieldDifference(fieldName, node1, node2, emit)
+		return
+	}
 	for key, v1 := range node1 {
 		if v2, ok := node2[key]; ok {
-			// Same field exists, compare
-			d := nodeDifference(append(fieldName, key), v1, v2)
-			if d != nil {
-				ret = append(ret, d...)
+			if name := fieldName.next(KeySegment(key)); !d.isAbsent(name, v1) || !d.isAbsent(name, v2) {
+				// Same field exists, compare
+				d.nodeDifference(name, v1, v2, emit)
 			}
-		} else {
+		} else if name := fieldName.next(KeySegment(key)); !d.isAbsent(name, v1) {
 			// Field does not exist on node2
-			ret = append(ret, Modification{Name: append(fieldName, key),
-				Old: v1,
-				New: nil})
+			emit(d.removedField(name.clone(), v1))
 		}
 	}
 	for key, v2 := range node2 {
 		_, ok := node1[key]
-		if !ok {
-			ret = append(ret, Modification{Name: append(fieldName, key),
-				Old: nil,
-				New: v2})
+		if name := fieldName.next(KeySegment(key)); !ok && !d.isAbsent(name, v2) {
+			emit(d.addedField(name.clone(), v2))
 		}
 	}
-	return ret
 }
 
-func valueNodeDifference(fieldName FieldName, node1, node2 interface{}) []Delta {
+// pairedFieldDifference computes the difference between two objects
+// like objectNodeDifference, pairing their fields with pairFields. If
+// renames are detected, a removed field and an added field with equal
+// values are reported as a Rename
+func (d *Differ) pairedFieldDifference(fieldName FieldName, node1, node2 map[string]interface{}, emit func(Delta)) {
+	pairs, removedKeys, addedKeys := d.pairFields(node1, node2)
+	for _, p := range pairs {
+		v1, v2 := node1[p.oldKey], node2[p.newKey]
+		if name := fieldName.next(KeySegment(p.newKey)); !d.isAbsent(name, v1) || !d.isAbsent(name, v2) {
+			d.nodeDifference(name, v1, v2, emit)
+		}
+	}
+	var removed, added []string
+	for _, key := range removedKeys {
+		if !d.isAbsent(fieldName.next(KeySegment(key)), node1[key]) {
+			removed = append(removed, key)
+		}
+	}
+	for _, key := range addedKeys {
+		if !d.isAbsent(fieldName.next(KeySegment(key)), node2[key]) {
+			added = append(added, key)
+		}
+	}
+	// Keys are paired in order, so the result does not depend on the
+	// map iteration order
+	sort.Strings(removed)
+	sort.Strings(added)
+	paired := make([]bool, len(added))
+	for _, oldKey := range removed {
+		v1 := node1[oldKey]
+		renamed := false
+		for i, newKey := range added {
+			if d.renames && !paired[i] && IsEqual(v1, node2[newKey]) {
+				paired[i] = true
+				renamed = true
+				emit(Rename{Name: fieldName.clone(), OldKey: oldKey, NewKey: newKey, Value: node2[newKey]})
+				break
+			}
+		}
+		if !renamed {
+			emit(d.removedField(fieldName.next(KeySegment(oldKey)).clone(), v1))
+		}
+	}
+	for i, newKey := range added {
+		if !paired[i] {
+			emit(d.addedField(fieldName.next(KeySegment(newKey)).clone(), node2[newKey]))
+		}
+	}
+}
+
+// removedField returns the delta for a field removed from an object
+func (d *Differ) removedField(name FieldName, value interface{}) Delta {
+	if d.fieldDeltas {
+		return Deletion{Name: name, DeletedNode: value}
+	}
+	return Modification{Name: name, Old: value, New: nil}
+}
+
+// addedField returns the delta for a field added to an object
+func (d *Differ) addedField(name FieldName, value interface{}) Delta {
+	if d.fieldDeltas {
+		return Insertion{Name: name, NewNode: value}
+	}
+	return Modification{Name: name, Old: nil, New: value}
+}
+
+func (d *Differ) valueNodeDifference(fieldName FieldName, node1, node2 interface{}, emit func(Delta)) {
+	d.checkValue(fieldName, node1)
+	d.checkValue(fieldName, node2)
+	if !isComparable(node1) || !isComparable(node2) {
+		if !reflect.DeepEqual(node1, node2) {
+			emit(Modification{Name: fieldName.clone(), Old: node1, New: node2, TypeChanged: typeChanged(node1, node2)})
+		}
+		return
+	}
 	if node1 != node2 {
-		return []Delta{Modification{Name: fieldName, Old: node1, New: node2}}
+		emit(Modification{Name: fieldName.clone(), Old: node1, New: node2,
+			TextDiff:    d.textDiff(node1, node2),
+			TypeChanged: typeChanged(node1, node2)})
+	}
+}
+
+func (d *Differ) arrayNodeDifference(fieldName FieldName, node1, node2 []interface{}, emit func(Delta)) {
+	computeEq := d.valueBasedEquivalence
+	if d.customEquality() {
+		computeEq = d.customEquivalence(fieldName)
+	}
+	if m, ok := d.arrayMatcher(fieldName); ok {
+		if d.logger != nil {
+			d.debug("array matcher", "path", fieldName.String(), "matcher", matcherName(m))
+		}
+		eq, recurse := d.matcherEquivalence(m, fieldName, computeEq)
+		d.arrayDifference(fieldName, node1, node2, eq, recurse, emit)
+		return
+	}
+	if d.arraysAsSets.matches(fieldName) {
+		if d.logger != nil {
+			d.debug("array matcher", "path", fieldName.String(), "matcher", "set")
+		}
+		d.setDifference(fieldName, node1, node2, computeEq, emit)
+		return
+	}
+	// Most arrays do not change. Elements matched by value are not
+	// compared recursively, so equal arrays have no differences
+	if !d.customEquality() && isArrayNodeEqual(node1, node2) {
+		return
+	}
+	if d.logger != nil {
+		d.debug("array matcher", "path", fieldName.String(), "matcher", "value")
 	}
-	return nil
+	d.arrayDifference(fieldName, node1, node2, computeEq, false, emit)
 }
 
-func arrayNodeDifference(fieldName FieldName, node1, node2 []interface{}) []Delta {
-	return arrayDifference(fieldName, node1, node2, valueBasedEquivalence, false)
+// setDifference computes difference between two array nodes
+// ignoring the order of elements. Elements of node1 that are not
+// matched to an element of node2 are deleted, and elements of node2
+// not matched to an element of node1 are inserted.
+func (d *Differ) setDifference(fieldName FieldName, node1, node2 []interface{},
+	computeEq func(node1, node2 []interface{}) dualMap, emit func(Delta)) {
+	equivalence := computeEq(node1, node2)
+	for i := range node1 {
+		if equivalence.getNewIndex(i) == -1 {
+			emit(Deletion{Name: fieldName.child(IndexSegment(i)),
+				DeletedNode: node1[i]})
+		}
+	}
+	for i := range node2 {
+		if equivalence.getOldIndex(i) == -1 {
+			emit(Insertion{Name: fieldName.child(IndexSegment(i)),
+				NewNode: node2[i]})
+		}
+	}
 }
 
+// dualMap pairs the elements of two arrays by index. Unpaired
+// indexes map to -1
 type dualMap struct {
-	old2new map[int]int
-	new2old map[int]int
+	old2new []int
+	new2old []int
+}
+
+// newDualMap returns a dualMap without pairs for arrays of n1 and n2
+// elements
+func newDualMap(n1, n2 int) dualMap {
+	buf := make([]int, n1+n2)
+	for i := range buf {
+		buf[i] = -1
+	}
+	return dualMap{old2new: buf[:n1:n1], new2old: buf[n1:]}
 }
 
 func (x dualMap) insert(oldix, newix int) {
@@ -204,49 +451,153 @@ func (x dualMap) insert(oldix, newix int) {
 }
 
 func (x dualMap) getNewIndex(oldix int) int {
-	if i, ok := x.old2new[oldix]; ok {
-		return i
-	}
-	return -1
+	return x.old2new[oldix]
 }
 
 func (x dualMap) getOldIndex(newix int) int {
-	if i, ok := x.new2old[newix]; ok {
-		return i
+	return x.new2old[newix]
+}
+
+// pairs returns the pairs as a map from old index to new index
+func (x dualMap) pairs() map[int]int {
+	ret := make(map[int]int)
+	for i, j := range x.old2new {
+		if j != -1 {
+			ret[i] = j
+		}
 	}
-	return -1
+	return ret
 }
 
-// valueBasedEquivalence compares nodes based on node values
-func valueBasedEquivalence(node1, node2 []interface{}) dualMap {
-	type nodeHashInfo struct {
-		hash int
-		eq   int
+// maxLCSCells is the largest table size for which the unmatched
+// middle parts of two arrays are paired using the longest common
+// subsequence
+const maxLCSCells = 1 << 20
+
+// valueBasedEquivalence compares nodes based on node values. Arrays
+// are treated as multisets: each element of node1 is paired with an
+// equal element of node2, so duplicate values are paired one to one.
+// The common prefix and suffix of the arrays are paired first. The
+// remaining elements are paired using the longest common subsequence
+// when the arrays are small enough, so that as many elements as
+// possible keep their relative order and are not reported as
+// moved. Any equal elements that are still unpaired are paired in
+// order.
+func (d *Differ) valueBasedEquivalence(node1, node2 []interface{}) dualMap {
+	equivalence := newDualMap(len(node1), len(node2))
+	ids1, ids2, numClasses := d.elementClasses(node1, node2)
+	// Pair the common prefix and suffix
+	start := 0
+	for start < len(ids1) && start < len(ids2) && ids1[start] == ids2[start] {
+		equivalence.insert(start, start)
+		start++
+	}
+	end1, end2 := len(ids1), len(ids2)
+	for end1 > start && end2 > start && ids1[end1-1] == ids2[end2-1] {
+		end1--
+		end2--
+		equivalence.insert(end1, end2)
+	}
+	// Pair the longest common subsequence of the middle parts
+	if (end1-start)*(end2-start) <= maxLCSCells {
+		d.lcsPairs(ids1, ids2, start, end1, end2, equivalence)
+	}
+	// Pair the remaining equal elements in order. first[id] is the
+	// first unpaired new index of the class id, and next links the
+	// unpaired new indexes of a class
+	first := make([]int, numClasses)
+	for i := range first {
+		first[i] = -1
+	}
+	next := make([]int, end2)
+	for j := end2 - 1; j >= start; j-- {
+		if equivalence.getOldIndex(j) == -1 {
+			next[j] = first[ids2[j]]
+			first[ids2[j]] = j
+		}
 	}
-	// Our goal is to compute an equivalence map.
-	equivalence := dualMap{old2new: make(map[int]int), new2old: make(map[int]int)}
-	// First step is to compute hashes on the nodes of node2.
-	node2Hashes := make([]nodeHashInfo, len(node2))
-	for i, n := range node2 {
-		node2Hashes[i].hash = NodeHash(n)
-		node2Hashes[i].eq = -1
+	for i := start; i < end1; i++ {
+		if equivalence.getNewIndex(i) != -1 {
+			continue
+		}
+		if j := first[ids1[i]]; j != -1 {
+			equivalence.insert(i, j)
+			first[ids1[i]] = next[j]
+		}
 	}
-	// Then iterate node1 nodes, only comparing nodes from node2 whose
-	// hashes match
+	return equivalence
+}
+
+// elementClasses assigns a class id to each element of the arrays,
+// so that equal elements have the same id, and returns the number of
+// classes. Hashes are used to find the candidates, and IsEqual to
+// confirm
+func (d *Differ) elementClasses(node1, node2 []interface{}) ([]int, []int, int) {
+	// classes maps a hash to the index of the first class with that
+	// hash in reps. Classes with the same hash are linked by next
+	classes := d.classMap()
+	defer d.putClassMap(classes)
+	var reps []classRep
+	classOf := func(n interface{}) int {
+		h := d.hashes.nodeHash(n)
+		ix, ok := classes[h]
+		if !ok {
+			ix = -1
+		}
+		for r := ix; r != -1; r = reps[r].next {
+			if IsEqual(reps[r].node, n) {
+				return r
+			}
+		}
+		reps = append(reps, classRep{node: n, next: ix})
+		classes[h] = len(reps) - 1
+		return len(reps) - 1
+	}
+	ids := make([]int, len(node1)+len(node2))
+	ids1, ids2 := ids[:len(node1):len(node1)], ids[len(node1):]
 	for i, n := range node1 {
-		node1Hash := NodeHash(n)
-		for j, h := range node2Hashes {
-			if h.eq == -1 && node1Hash == h.hash {
-				// these two nodes are possibly equal
-				if IsEqual(n, node2[j]) {
-					node2Hashes[j].eq = i
-					equivalence.insert(i, j)
-					break
-				}
+		ids1[i] = classOf(n)
+	}
+	for i, n := range node2 {
+		ids2[i] = classOf(n)
+	}
+	return ids1, ids2, len(reps)
+}
+
+// lcsPairs pairs the elements in the longest common subsequence of
+// ids1[start:end1] and ids2[start:end2]
+func (d *Differ) lcsPairs(ids1, ids2 []int, start, end1, end2 int, equivalence dualMap) {
+	m1, m2 := end1-start, end2-start
+	if m1 <= 0 || m2 <= 0 {
+		return
+	}
+	// lcs[i*(m2+1)+j] is the length of the LCS of the parts starting
+	// at i and j
+	lcs := d.lcsTable((m1 + 1) * (m2 + 1))
+	defer d.putLCSTable(lcs)
+	for i := m1 - 1; i >= 0; i-- {
+		for j := m2 - 1; j >= 0; j-- {
+			if ids1[start+i] == ids2[start+j] {
+				lcs[i*(m2+1)+j] = lcs[(i+1)*(m2+1)+j+1] + 1
+			} else if a, b := lcs[(i+1)*(m2+1)+j], lcs[i*(m2+1)+j+1]; a >= b {
+				lcs[i*(m2+1)+j] = a
+			} else {
+				lcs[i*(m2+1)+j] = b
 			}
 		}
 	}
-	return equivalence
+	for i, j := 0, 0; i < m1 && j < m2; {
+		switch {
+		case ids1[start+i] == ids2[start+j]:
+			equivalence.insert(start+i, start+j)
+			i++
+			j++
+		case lcs[(i+1)*(m2+1)+j] >= lcs[i*(m2+1)+j+1]:
+			i++
+		default:
+			j++
+		}
+	}
 }
 
 // arrayDifference computes difference between two array nodes based
@@ -255,198 +606,126 @@ func valueBasedEquivalence(node1, node2 []interface{}) dualMap {
 // unmodified between the two arays, and assumes any other element is
 // inserted/deleted. If the element indexes don't match, it assumes
 // elements are moved
-func arrayDifference(fieldName FieldName, node1, node2 []interface{},
-	computeEq func(node1, node2 []interface{}) dualMap, recurse bool) []Delta {
-	debugf("array diff n1: %v n2: %v", node1, node2)
+func (d *Differ) arrayDifference(fieldName FieldName, node1, node2 []interface{},
+	computeEq func(node1, node2 []interface{}) dualMap, recurse bool, emit func(Delta)) {
 	// Deal with trivial cases: if node1 is empty, then all node2 are additions
 	// If node2 is empty, all node1 are deletions
 	n1 := len(node1)
 	n2 := len(node2)
 	if n1 == 0 {
-		ret := make([]Delta, n2)
 		for i, x := range node2 {
-			ret[i] = Insertion{Name: append(fieldName, strconv.Itoa(i)), NewNode: x}
+			emit(Insertion{Name: fieldName.child(IndexSegment(i)), NewNode: x})
 		}
-		return ret
+		return
 	}
 	if n2 == 0 {
-		ret := make([]Delta, n1)
 		for i, x := range node1 {
-			ret[i] = Deletion{Name: append(fieldName, strconv.Itoa(i)), DeletedNode: x}
+			emit(Deletion{Name: fieldName.child(IndexSegment(i)), DeletedNode: x})
 		}
-		return ret
+		return
 	}
 	// Here, both arrays are nonempty
 
 	equivalence := computeEq(node1, node2)
-
-	debugf("Equivalences: %v", equivalence)
-	ret := make([]Delta, 0)
+	if d.noMoves {
+		equivalence = unmovedMatches(equivalence, n1, n2)
+	}
+	if d.logger != nil {
+		matched := 0
+		for _, j := range equivalence.old2new {
+			if j != -1 {
+				matched++
+			}
+		}
+		d.debug("array elements matched", "path", fieldName.String(), "old", n1, "new", n2, "matched", matched)
+	}
 	// If there is anything in node1 that's not contained in node2, thats a deletion
 	for i := 0; i < n1; i++ {
 		if equivalence.getNewIndex(i) == -1 {
-			ret = append(ret, Deletion{Name: append(fieldName, strconv.Itoa(i)),
+			emit(Deletion{Name: fieldName.child(IndexSegment(i)),
 				DeletedNode: node1[i]})
 		}
 	}
 	// If there is anything in node2 that's not in node1, that's an addition
 	for i := 0; i < n2; i++ {
 		if equivalence.getOldIndex(i) == -1 {
-			ret = append(ret, Insertion{Name: append(fieldName, strconv.Itoa(i)),
+			emit(Insertion{Name: fieldName.child(IndexSegment(i)),
 				NewNode: node2[i]})
 		}
 	}
 
-	pos1 := 0
-	pos2 := 0
-	// Keep recursively compared node2 indexes here to not duplicate comparisons
-	recursedIndex := map[int]struct{}{}
-	for {
-		debugf("pos1: %d/%d pos2: %d/%d:", pos1, n1, pos2, n2)
-		var oldix, newix int
-		if pos1 < n1 {
-			if pos2 < n2 {
-				// Does the new node exist in the old node?
-				oldix = equivalence.getOldIndex(pos2)
-				debugf("pos2 %d -> oldix %d", pos2, oldix)
-				if oldix == -1 {
-					// This is a new item
-					pos2++
-				} else {
-					if recurse {
-						if _, ok := recursedIndex[pos2]; !ok {
-							recursedIndex[pos2] = struct{}{}
-							debugf("Recursively evaluating %d -> %d", pos2, oldix)
-							rd := nodeDifference(append(fieldName, strconv.Itoa(pos2)), node1[oldix],
-								node2[pos2])
-							debugf("Result: %v", rd)
-							if rd != nil {
-								ret = append(ret, rd...)
-							}
-						}
-					}
-					// New node is in the old node. Make sure we take care of deletions
-					newix = equivalence.getNewIndex(pos1)
-					if newix == -1 {
-						pos1++
-					} else {
-						// pos1: exists in node2 at index newix
-						// pos2: exists in node1 at index oldix
-						if oldix == pos1 {
-							pos1++
-							pos2++
-						} else {
-							ret = append(ret, Move{To: append(fieldName, strconv.Itoa(pos2)),
-								From: append(fieldName, strconv.Itoa(oldix)),
-								Old:  node1[oldix],
-								New:  node2[pos2]})
-							pos2++
-						}
-					}
-				}
-			} else {
-				// These are all deleted items
-				pos1++
-			}
-		} else if pos2 < n2 {
-			// These are all insertions
-			pos2++
-		} else {
-			break
+	// Matched elements that keep their relative order are not
+	// moved. Among the matched elements, the longest sequence that is
+	// in the same order in both arrays stays in place, and the others
+	// are moved
+	stable := stableMatches(equivalence, n2)
+	for pos2 := 0; pos2 < n2; pos2++ {
+		oldix := equivalence.getOldIndex(pos2)
+		if oldix == -1 {
+			continue
+		}
+		if recurse {
+			d.nodeDifference(fieldName.next(IndexSegment(pos2)), node1[oldix], node2[pos2], emit)
+		}
+		if !stable[pos2] {
+			emit(Move{To: fieldName.child(IndexSegment(pos2)),
+				From: fieldName.child(IndexSegment(oldix)),
+				Old:  node1[oldix],
+				New:  node2[pos2]})
+		}
+	}
+}
+
+// unmovedMatches returns the matched elements that are not moved. The
+// elements that would be moved are not matched, so they are reported
+// as deleted and inserted
+func unmovedMatches(equivalence dualMap, n1, n2 int) dualMap {
+	stable := stableMatches(equivalence, n2)
+	ret := newDualMap(n1, n2)
+	for pos2, ok := range stable {
+		if ok {
+			ret.insert(equivalence.getOldIndex(pos2), pos2)
 		}
 	}
-	debugf("Result: %v", ret)
 	return ret
 }
 
-// valueHash returns a hash for the given value. It is a weak has,
-// but fast to compute. We are trying to find differences, not
-// equivalences, so this is sufficient for our purposes
-func valueHash(value interface{}) int {
-	if value == nil {
-		return 0
-	}
-	switch k := value.(type) {
-	case bool:
-		if k {
-			return 1
-		}
-		return 0
-	case int:
-		return k
-	case int8:
-		return int(k)
-	case int16:
-		return int(k)
-	case int32:
-		return int(k)
-	case int64:
-		return int(k)
-	case uint:
-		return int(k)
-	case uint8:
-		return int(k)
-	case uint16:
-		return int(k)
-	case uint32:
-		return int(k)
-	case uint64:
-		return int(k)
-	case float32:
-		return int(k)
-	case float64:
-		return int(k)
-	case big.Int:
-		x := k.Int64()
-		return int(x)
-	case big.Float:
-		x, _ := k.Int64()
-		return int(x)
-	case string:
-		return stringHash(k)
-	}
-	return 0
-}
-
-// stringHash returns the sum of bytes in a string
-func stringHash(s string) int {
-	i := 0
-	for _, c := range s {
-		i += int(c)
-	}
-	return i
-}
-
-// objectNodeHash returns a hash value for an object node
-func objectNodeHash(node map[string]interface{}) int {
-	hash := 0
-	for k, v := range node {
-		hash += stringHash(k) + NodeHash(v)
-	}
-	return hash
-}
-
-// arrayNodeHash returns a hash value for an array node
-func arrayNodeHash(node []interface{}) int {
-	hash := 0
-	for i, v := range node {
-		hash += i * NodeHash(v)
-	}
-	return hash
-}
-
-// NodeHash calculates the hash of a node recursively
-func NodeHash(node interface{}) int {
-	if node == nil {
-		return 0
-	}
-	switch k := node.(type) {
-	case map[string]interface{}:
-		return objectNodeHash(k)
-	case []interface{}:
-		return arrayNodeHash(k)
+// stableMatches returns the new indexes of the longest sequence of
+// matched elements that are in the same order in both arrays: the
+// returned slice is true at those indexes
+func stableMatches(equivalence dualMap, n2 int) []bool {
+	// Patience sorting: tails[k] is the new index of the last element
+	// of the best increasing sequence of length k+1 found so far, and
+	// prev links each element to the previous element in its sequence
+	var tails []int
+	prev := make([]int, n2)
+	for pos2 := 0; pos2 < n2; pos2++ {
+		oldix := equivalence.getOldIndex(pos2)
+		if oldix == -1 {
+			continue
+		}
+		k := sort.Search(len(tails), func(i int) bool {
+			return equivalence.getOldIndex(tails[i]) >= oldix
+		})
+		if k > 0 {
+			prev[pos2] = tails[k-1]
+		} else {
+			prev[pos2] = -1
+		}
+		if k == len(tails) {
+			tails = append(tails, pos2)
+		} else {
+			tails[k] = pos2
+		}
 	}
-	return valueHash(node)
+	stable := make([]bool, n2)
+	if len(tails) == 0 {
+		return stable
+	}
+	for pos2 := tails[len(tails)-1]; pos2 != -1; pos2 = prev[pos2] {
+		stable[pos2] = true
+	}
+	return stable
 }
 
 // IsEqual checks if two nodes are the same
@@ -474,6 +753,69 @@ func IsEqual(node1, node2 interface{}) bool {
 	return false
 }
 
+// Explain checks if two nodes are the same like IsEqual. If they are
+// not, it also returns the field name of the first difference. Object
+// fields are checked in key order. If a field exists in only one of
+// the objects, the name of that field is returned. If one array is a
+// prefix of the other, the index of the first extra element is
+// returned.
+func Explain(node1, node2 interface{}) (bool, FieldName) {
+	return explain(FieldName{}, node1, node2)
+}
+
+func explain(fieldName FieldName, node1, node2 interface{}) (bool, FieldName) {
+	switch k1 := node1.(type) {
+	case map[string]interface{}:
+		k2, ok := node2.(map[string]interface{})
+		if !ok {
+			return false, fieldName
+		}
+		keys := make([]string, 0, len(k1)+len(k2))
+		for k := range k1 {
+			keys = append(keys, k)
+		}
+		for k := range k2 {
+			if _, ok := k1[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v1, ok1 := k1[k]
+			v2, ok2 := k2[k]
+			if !ok1 || !ok2 {
+				return false, fieldName.child(KeySegment(k))
+			}
+			if eq, name := explain(fieldName.child(KeySegment(k)), v1, v2); !eq {
+				return false, name
+			}
+		}
+		return true, nil
+	case []interface{}:
+		k2, ok := node2.([]interface{})
+		if !ok {
+			return false, fieldName
+		}
+		for i := 0; i < len(k1) && i < len(k2); i++ {
+			if eq, name := explain(fieldName.child(IndexSegment(i)), k1[i], k2[i]); !eq {
+				return false, name
+			}
+		}
+		if len(k1) != len(k2) {
+			n := len(k1)
+			if len(k2) < n {
+				n = len(k2)
+			}
+			return false, fieldName.child(IndexSegment(n))
+		}
+		return true, nil
+	}
+	if !IsEqual(node1, node2) {
+		return false, fieldName
+	}
+	return true, nil
+}
+
 func isObjectNodeEqual(node1, node2 map[string]interface{}) bool {
 	if len(node1) != len(node2) {
 		return false
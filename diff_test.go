@@ -1,7 +1,12 @@
 package jsondiff
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -360,3 +365,372 @@ func TestObjArrayIDDiff1(t *testing.T) {
 		t.Errorf("Insert expected: %v", delta[3])
 	}
 }
+
+func TestReadersDiff(t *testing.T) {
+	delta, err := JSONDifferenceReaders(strings.NewReader(`{"f1":"value1","f2":[1,2]}`),
+		strings.NewReader(`{"f1":"value2","f2":[1,2]}`))
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	if len(delta) != 1 {
+		t.Errorf("Unexpected diff: %v", delta)
+		return
+	}
+	if delta[0].GetField().String() != "f1" {
+		t.Errorf("Wrong delta: %v", delta[0])
+	}
+	_, err = JSONDifferenceReaders(strings.NewReader(`{"f1":`), strings.NewReader(`{}`))
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestArraysAsSets(t *testing.T) {
+	doc1, err := parse(`{"f1":[1,2,3,2],"f2":{"f3":["a","b"]}}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"f1":[2,3,2,1],"f2":{"f3":["b","c","a"]}}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	delta := NewDiffer(ArraysAsSets()).Difference(doc1, doc2)
+	if len(delta) != 1 {
+		t.Errorf("Unexpected diff: %v", delta)
+		return
+	}
+	if a, ok := delta[0].(Insertion); ok {
+		if a.Name.String() != "f2/f3/1" ||
+			a.NewNode.(string) != "c" {
+			t.Errorf("Bad diff: %v", a)
+		}
+	} else {
+		t.Errorf("Wrong delta: %v", delta)
+	}
+
+	delta = NewDiffer(ArraysAsSets("**/f3")).Difference(doc1, doc2)
+	for _, d := range delta {
+		if d.GetField()[0].Key != "f1" {
+			if d.GetField().String() != "f2/f3/1" {
+				t.Errorf("Unexpected diff: %v", d)
+			}
+		}
+	}
+	if len(delta) < 2 {
+		t.Errorf("Expected array diffs for f1: %v", delta)
+	}
+}
+
+func TestDifferenceOf(t *testing.T) {
+	type item struct {
+		Name  string `json:"name"`
+		Count int    `json:"count,omitempty"`
+	}
+	type doc struct {
+		ID    string `json:"id"`
+		Items []item `json:"items"`
+	}
+	delta, err := DifferenceOf(doc{ID: "1", Items: []item{{Name: "a", Count: 1}}},
+		doc{ID: "2", Items: []item{{Name: "a", Count: 1}}})
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	if len(delta) != 1 {
+		t.Errorf("Unexpected diff: %v", delta)
+		return
+	}
+	if m, ok := delta[0].(Modification); ok {
+		if m.Name.String() != "id" ||
+			m.Old.(string) != "1" ||
+			m.New.(string) != "2" {
+			t.Errorf("Wrong data: %v", m)
+		}
+	} else {
+		t.Errorf("Wrong delta: %v", delta[0])
+	}
+	_, err = DifferenceOf(make(chan int), 1)
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestArrayDuplicates(t *testing.T) {
+	tests := []struct {
+		doc1, doc2         string
+		ins, del, numMoves int
+	}{
+		{`[1,1,2]`, `[1,2,1]`, 0, 0, 1},
+		{`[1,2,3,4]`, `[2,3,4,1]`, 0, 0, 1},
+		{`[1,"x",1]`, `["x",1]`, 0, 1, 0},
+		{`[1,1,1]`, `[1,1,1,1]`, 1, 0, 0},
+		{`[1,2,1,2]`, `[2,1,2,1]`, 0, 0, 1},
+		{`[1,2,3]`, `[3,2,1]`, 0, 0, 2},
+	}
+	for _, test := range tests {
+		doc1, _ := parse(test.doc1)
+		doc2, _ := parse(test.doc2)
+		deltas := Difference(doc1, doc2)
+		counts := map[DiffType]int{}
+		for _, d := range deltas {
+			counts[d.GetType()]++
+		}
+		if counts[DiffIns] != test.ins || counts[DiffDel] != test.del || counts[DiffMove] != test.numMoves {
+			t.Errorf("Wrong deltas for %s -> %s: %v", test.doc1, test.doc2, deltas)
+		}
+		result, err := Apply(doc1, deltas)
+		if err != nil || !IsEqual(result, doc2) {
+			t.Errorf("Wrong result for %s -> %s: %v %v", test.doc1, test.doc2, result, err)
+		}
+	}
+}
+
+func TestDifferenceCtx(t *testing.T) {
+	doc1, _ := parse(`{"a":1,"b":2,"c":3,"d":{"e":{"f":1}}}`)
+	doc2, _ := parse(`{"a":2,"b":3,"c":4,"d":{"e":{"f":2}}}`)
+	deltas, err := DifferenceCtx(context.Background(), doc1, doc2)
+	if err != nil || len(deltas) != 4 {
+		t.Errorf("Unexpected result: %v %v", deltas, err)
+	}
+	deltas, err = DifferenceCtx(context.Background(), doc1, doc2, MaxDeltas(2))
+	if !errors.Is(err, ErrTooManyDeltas) || len(deltas) != 2 {
+		t.Errorf("Unexpected result: %v %v", deltas, err)
+	}
+	deltas, err = DifferenceCtx(context.Background(), doc1, doc2, MaxDepth(2))
+	if !errors.Is(err, ErrTooDeep) || len(deltas) > 3 {
+		t.Errorf("Unexpected result: %v %v", deltas, err)
+	}
+
+	var warnings []Warning
+	deltas = NewDiffer(MaxDeltas(1), OnWarning(func(w Warning) { warnings = append(warnings, w) })).Difference(doc1, doc2)
+	if len(deltas) != 1 || len(warnings) != 1 || warnings[0].Kind != WarnTruncated {
+		t.Errorf("Unexpected result: %v %v", deltas, warnings)
+	}
+
+	// A large document, so the context is checked
+	arr1 := make([]interface{}, 0, 1000)
+	arr2 := make([]interface{}, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		arr1 = append(arr1, map[string]interface{}{"id": float64(i), "x": float64(i)})
+		arr2 = append(arr2, map[string]interface{}{"id": float64(i), "x": float64(i + 1)})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	deltas, err = DifferenceCtx(ctx, arr1, arr2, ArrayKey("", "id"))
+	if !errors.Is(err, context.Canceled) || len(deltas) == 1000 {
+		t.Errorf("Unexpected result: %d %v", len(deltas), err)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		doc1, doc2 string
+		path       string
+	}{
+		{`{"a":1,"b":{"c":[1,2]}}`, `{"a":1,"b":{"c":[1,2]}}`, ""},
+		{`{"a":1,"b":{"c":[1,2]}}`, `{"a":1,"b":{"c":[1,3]}}`, "b/c/1"},
+		{`{"a":1,"b":2}`, `{"a":2,"b":3}`, "a"},
+		{`{"a":1}`, `{"a":1,"b":2}`, "b"},
+		{`{"a":[1,2]}`, `{"a":[1,2,3]}`, "a/2"},
+		{`{"a":[1,2]}`, `{"a":{}}`, "a"},
+		{`1`, `"1"`, ""},
+	}
+	for _, test := range tests {
+		doc1, _ := parse(test.doc1)
+		doc2, _ := parse(test.doc2)
+		eq, name := Explain(doc1, doc2)
+		if eq != IsEqual(doc1, doc2) {
+			t.Errorf("Explain and IsEqual disagree for %s %s", test.doc1, test.doc2)
+		}
+		if name.String() != test.path {
+			t.Errorf("Wrong path for %s %s: %s", test.doc1, test.doc2, name)
+		}
+	}
+}
+
+func TestObjectFieldDeltas(t *testing.T) {
+	doc1, _ := parse(`{"a":1,"b":2,"c":{"d":3}}`)
+	doc2, _ := parse(`{"a":null,"c":{"d":3,"e":4}}`)
+	deltas := NewDiffer(ObjectFieldDeltas()).Difference(doc1, doc2)
+	if len(deltas) != 3 {
+		t.Errorf("Unexpected diff: %v", deltas)
+		return
+	}
+	for _, d := range deltas {
+		var expected DiffType
+		switch d.GetField().String() {
+		case "a":
+			expected = DiffMod
+		case "b":
+			expected = DiffDel
+		case "c/e":
+			expected = DiffIns
+		}
+		if d.GetType() != expected {
+			t.Errorf("Wrong delta: %v", d)
+		}
+	}
+	result, err := Apply(doc1, deltas, SetNullFields())
+	if err != nil || !IsEqual(result, doc2) {
+		t.Errorf("Wrong result: %v %v", result, err)
+	}
+	result, err = Apply(doc2, Invert(deltas), SetNullFields())
+	if err != nil || !IsEqual(result, doc1) {
+		t.Errorf("Wrong result: %v %v", result, err)
+	}
+	if err := Compatible(doc1, deltas); err != nil {
+		t.Errorf("Error: %s", err)
+	}
+}
+
+func TestDifferenceAt(t *testing.T) {
+	doc1, _ := parse(`{"a":{"b":[{"id":1,"x":1},{"id":2,"x":2}]},"c":1}`)
+	doc2, _ := parse(`{"a":{"b":[{"id":2,"x":3},{"id":1,"x":1}],"n":1},"c":2}`)
+	path := FieldName{KeySegment("a"), KeySegment("b")}
+	deltas := NewDiffer(ArrayKey("a/b", "id")).DifferenceAt(path, doc1, doc2)
+	if len(deltas) != 2 {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+	for _, d := range deltas {
+		if !hasPrefix(d.GetField(), path) {
+			t.Errorf("Delta outside the subtree: %v", d)
+		}
+	}
+	if deltas := DifferenceAt(FieldName{KeySegment("a"), KeySegment("n")}, doc1, doc2); len(deltas) != 1 || deltas[0].(Modification).New != 1.0 || deltas[0].GetField().String() != "a/n" {
+		t.Errorf("Wrong deltas for added node: %v", deltas)
+	}
+	if deltas := DifferenceAt(FieldName{KeySegment("a"), KeySegment("b"), IndexSegment(0)}, doc1, doc2); len(deltas) != 2 || !hasPrefix(deltas[0].GetField(), FieldName{KeySegment("a"), KeySegment("b"), IndexSegment(0)}) {
+		t.Errorf("Wrong deltas for array element: %v", deltas)
+	}
+	if deltas := DifferenceAt(FieldName{KeySegment("x")}, doc1, doc2); len(deltas) != 0 {
+		t.Errorf("Wrong deltas for missing node: %v", deltas)
+	}
+}
+
+func TestDetectRenames(t *testing.T) {
+	doc1, _ := parse(`{"a":{"firstName":"x","n":{"p":1},"z":1},"b":1,"c":1}`)
+	doc2, _ := parse(`{"a":{"first_name":"x","m":{"p":1},"z":2},"b":1,"d":1}`)
+	deltas := NewDiffer(DetectRenames()).Difference(doc1, doc2)
+	renames := 0
+	for _, d := range deltas {
+		r, ok := d.(Rename)
+		if !ok {
+			continue
+		}
+		renames++
+		switch d.GetField().String() {
+		case "a/first_name":
+			if r.OldKey != "firstName" || r.Value != "x" {
+				t.Errorf("Wrong rename: %v", r)
+			}
+		case "a/m":
+			if r.OldKey != "n" {
+				t.Errorf("Wrong rename: %v", r)
+			}
+		case "d":
+			if r.OldKey != "c" {
+				t.Errorf("Wrong rename: %v", r)
+			}
+		default:
+			t.Errorf("Unexpected rename: %v", r)
+		}
+	}
+	if renames != 3 || len(deltas) != 4 {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+	if d := Difference(doc1, doc2); len(d) != 7 {
+		t.Errorf("Renames detected without the option: %v", d)
+	}
+
+	result, err := Apply(doc1, deltas)
+	if err != nil || !IsEqual(result, doc2) {
+		t.Errorf("Wrong result: %v %v", result, err)
+	}
+	result, err = Apply(doc2, Invert(deltas))
+	if err != nil || !IsEqual(result, doc1) {
+		t.Errorf("Wrong inverted result: %v %v", result, err)
+	}
+	if err := Compatible(doc1, deltas); err != nil {
+		t.Errorf("Error: %s", err)
+	}
+	if err := Compatible(doc2, deltas); err == nil {
+		t.Errorf("Expected error")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSONLines(&buf, deltas); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadJSONLines(&buf)
+	if err != nil || !reflect.DeepEqual(read, deltas) {
+		t.Errorf("Wrong decoded deltas: %v %v", read, err)
+	}
+
+	patch, err := ToJSONPatch([]Delta{Rename{Name: FieldName{KeySegment("a")}, OldKey: "x/y", NewKey: "z", Value: 1.0}}, SequentialIndexes)
+	if err != nil || string(patch) != `[{"op":"move","path":"/a/z","from":"/a/x~1y"}]` {
+		t.Errorf("Wrong patch: %s %v", patch, err)
+	}
+}
+
+func TestCaseInsensitiveKeys(t *testing.T) {
+	doc1, _ := parse(`{"UserName":"x","Profile":{"Age":1},"list":[{"ID":1},{"ID":2}],"gone":1}`)
+	doc2, _ := parse(`{"username":"x","profile":{"age":2},"list":[{"id":2},{"id":1}],"GONE":1}`)
+	deltas := NewDiffer(CaseInsensitiveKeys()).Difference(doc1, doc2)
+	if len(deltas) != 2 {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+	for _, d := range deltas {
+		switch x := d.(type) {
+		case Modification:
+			if x.GetField().String() != "profile/age" || x.Old != 1.0 || x.New != 2.0 {
+				t.Errorf("Wrong modification: %v", x)
+			}
+		case Move:
+			if x.GetField().String() != "list/0" && x.GetField().String() != "list/1" {
+				t.Errorf("Wrong move: %v", x)
+			}
+		default:
+			t.Errorf("Unexpected delta: %v", d)
+		}
+	}
+	if d := Difference(doc1, doc2); len(d) <= 2 {
+		t.Errorf("Keys folded without the option: %v", d)
+	}
+
+	// Exact matches are paired before keys that differ in case
+	doc1, _ = parse(`{"a":1,"A":2}`)
+	doc2, _ = parse(`{"A":2,"a":3}`)
+	deltas = NewDiffer(CaseInsensitiveKeys()).Difference(doc1, doc2)
+	if len(deltas) != 1 || deltas[0].GetField().String() != "a" {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+
+	d, err := DifferenceRaw([]byte(`{"Name":"a","X":1}`), []byte(`{"name":"a","x":2}`), CaseInsensitiveKeys())
+	if err != nil || len(d) != 1 || d[0].GetField().String() != "x" {
+		t.Errorf("Wrong raw deltas: %v %v", d, err)
+	}
+}
+
+func TestNoMoves(t *testing.T) {
+	doc1, _ := parse(`{"a":[1,2,3,4],"b":[{"id":1,"v":1},{"id":2,"v":2}]}`)
+	doc2, _ := parse(`{"a":[4,1,2,3],"b":[{"id":2,"v":3},{"id":1,"v":1}]}`)
+	deltas := NewDiffer(NoMoves(), ArrayKey("b", "id")).Difference(doc1, doc2)
+	for _, d := range deltas {
+		if d.GetType() == DiffMove {
+			t.Errorf("Unexpected move: %v", d)
+		}
+	}
+	if s := Summarize(deltas); s.Counts[DiffDel] != 2 || s.Counts[DiffIns] != 2 || len(deltas) != 4 {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+	result, err := Apply(doc1, deltas)
+	if err != nil || !IsEqual(result, doc2) {
+		t.Errorf("Wrong result: %v %v", result, err)
+	}
+	if s := Summarize(Difference(doc1, doc2)); s.Counts[DiffMove] == 0 {
+		t.Errorf("Moves not reported without the option")
+	}
+}
@@ -0,0 +1,348 @@
+package jsondiff
+
+import (
+	"context"
+	"fmt"
+)
+
+// Differ computes the differences between documents. A Differ is
+// configured using Options, and is not changed by the difference
+// computations, so one Differ can be shared by any number of
+// goroutines. Each computation takes its own buffers from a pool kept
+// by the Differ. The functions passed to options, such as EqualFunc,
+// OnWarning, and WithLogger, are then called concurrently, and must be
+// safe for concurrent use.
+type Differ struct {
+	arraysAsSets  pathSelector
+	equalFuncs    []pathEqualFunc
+	arrayMatchers []pathArrayMatcher
+	warn          func(Warning)
+	maxDeltas     int
+	maxDepth      int
+	textDiffs     bool
+	textMinLength int
+	textUnit      TextUnit
+	schema        interface{}
+	absent        absentRules
+	fieldDeltas   bool
+	truncDepth    int
+	truncBytes    int
+	autoTune      bool
+	logger        Logger
+	renames       bool
+	foldKeys      bool
+	strategic     bool
+	ordered       bool
+	noMoves       bool
+	redact        []string
+
+	// pool is shared by the copies of the Differ made for each
+	// difference computation
+	pool *scratchPool
+
+	// The following are set for the duration of a difference
+	// computation
+	scratch *scratch
+
+	hashes   hashCache
+	keyOrder map[uintptr][]string
+	ctx      context.Context
+	err      error
+	nDeltas  int
+	nNodes   int
+}
+
+// Option configures a Differ
+type Option func(*Differ)
+
+// ArraysAsSets compares arrays as multisets: the order of array
+// elements is ignored, and only the elements that are added or
+// removed are reported. If paths are given, only the arrays whose
+// paths match one of the patterns are compared as sets. A pattern is
+// a list of field names separated by "/", where "*" matches a single
+// field name, "#" matches a single array index, and "**" matches any
+// number of field names. An object key named "#" is written as "~2".
+// See MatchPath.
+func ArraysAsSets(paths ...string) Option {
+	return func(d *Differ) {
+		d.arraysAsSets = newPathSelector(paths)
+	}
+}
+
+// EqualFunc registers a custom equality function for the fields
+// matching the path pattern. Patterns use the same syntax as
+// ArraysAsSets. The values of matching fields are compared using fn
+// instead of the default comparison, both when computing deltas and
+// when matching array elements. If more than one pattern matches a
+// field, the first registered function is used.
+func EqualFunc(path string, fn func(a, b interface{}) bool) Option {
+	return func(d *Differ) {
+		d.equalFuncs = append(d.equalFuncs, pathEqualFunc{pattern: path, fn: fn})
+	}
+}
+
+// NumericStringIDs compares numbers and strings containing the same
+// number as equal for the fields matching one of the path patterns,
+// so "42" and 42 are the same value. Patterns use the same syntax as
+// ArraysAsSets. Other fields, and objects and arrays matching the
+// patterns, are compared as usual.
+func NumericStringIDs(paths ...string) Option {
+	return func(d *Differ) {
+		for _, p := range paths {
+			d.equalFuncs = append(d.equalFuncs, pathEqualFunc{pattern: p, fn: numericStringEqual, scalars: true})
+		}
+	}
+}
+
+// StringNormalization is a set of rules applied to strings before
+// they are compared
+type StringNormalization int
+
+// String normalization rules
+const (
+	// TrimSpace removes leading and trailing white space
+	TrimSpace StringNormalization = 1 << iota
+	// CollapseSpace replaces each run of white space with a single space
+	CollapseSpace
+	// CaseFold compares strings case insensitively
+	CaseFold
+)
+
+// NormalizeStrings compares the string values of fields matching the
+// path pattern after applying the normalization rules, for example
+// NormalizeStrings("**/email", TrimSpace|CaseFold). Patterns use the
+// same syntax as ArraysAsSets. Objects and arrays matching the
+// pattern are compared as usual.
+func NormalizeStrings(path string, rules StringNormalization) Option {
+	return func(d *Differ) {
+		d.equalFuncs = append(d.equalFuncs, pathEqualFunc{pattern: path, fn: rules.equal, scalars: true})
+	}
+}
+
+// ArrayKey matches the elements of the arrays at paths matching the
+// pattern using the value of the key field of the elements, instead
+// of the element values. Patterns use the same syntax as
+// ArraysAsSets. Matched elements are compared recursively, so the
+// changes inside an element are reported as changes to its fields.
+// Elements that are not objects, or that do not have the key field
+// are matched by value. ArrayKey(path, key) is the same as
+// MatchArrays(path, KeyMatcher(key)).
+func ArrayKey(path, key string) Option {
+	return func(d *Differ) {
+		d.arrayMatchers = append(d.arrayMatchers, pathArrayMatcher{pattern: path, matcher: KeyMatcher(key)})
+	}
+}
+
+// ObjectFieldDeltas reports the fields added to objects as
+// Insertions, and the fields removed from objects as Deletions.
+// Without this option, they are reported as Modifications with a nil
+// Old or New value, so removing a field cannot be distinguished from
+// setting it to null. With this option, a Modification with a nil New
+// value always sets the field to null, so use the SetNullFields option
+// of Apply to apply the deltas.
+func ObjectFieldDeltas() Option {
+	return func(d *Differ) {
+		d.fieldDeltas = true
+	}
+}
+
+// DetectRenames reports a field removed from an object and a field
+// added to the same object with an equal value as a Rename, instead of
+// a removed and an added field. If more than one field qualifies, the
+// keys are paired in sorted order.
+func DetectRenames() Option {
+	return func(d *Differ) {
+		d.renames = true
+	}
+}
+
+// NoMoves reports array elements that changed their position as
+// deleted from their old index and inserted at their new index,
+// instead of as Moves, for patch formats that cannot represent moves,
+// such as merge patches and MongoDB updates. A moved element that is
+// also modified is inserted with its new value. Use ArraysAsSets to
+// ignore the position changes instead.
+func NoMoves() Option {
+	return func(d *Differ) {
+		d.noMoves = true
+	}
+}
+
+// MaxDeltas stops the difference computation after n deltas are
+// found. DifferenceCtx returns the first n deltas and
+// ErrTooManyDeltas if there are more. Difference returns the first n
+// deltas, and reports a WarnTruncated warning.
+func MaxDeltas(n int) Option {
+	return func(d *Differ) {
+		d.maxDeltas = n
+	}
+}
+
+// MaxDepth stops the difference computation when a field nested
+// deeper than depth levels has to be compared. DifferenceCtx returns
+// the deltas found until then and ErrTooDeep. Difference returns the
+// deltas found until then, and reports a WarnTruncated warning.
+func MaxDepth(depth int) Option {
+	return func(d *Differ) {
+		d.maxDepth = depth
+	}
+}
+
+// NewDiffer returns a new Differ configured with the given options. A
+// Differ can be used for any number of difference computations,
+// including concurrent ones. Each computation takes its buffers from
+// the pool of the Differ, and returns them when it is done. Reset
+// drops the pooled buffers.
+func NewDiffer(opts ...Option) *Differ {
+	d := &Differ{pool: &scratchPool{}}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Difference computes difference between two documents. node1 and
+// node2 are results of json.Unmarshal(&interface{})
+func (d *Differ) Difference(node1, node2 interface{}) []Delta {
+	var ret []Delta
+	d.DifferenceFunc(node1, node2, func(x Delta) {
+		ret = append(ret, x)
+	})
+	return ret
+}
+
+// DifferenceFunc computes difference between two documents, and
+// calls fn for each delta as soon as it is found. This avoids
+// collecting all the deltas in memory.
+func (d *Differ) DifferenceFunc(node1, node2 interface{}, fn func(Delta)) {
+	run, emit := d.start(nil, fn)
+	defer d.release(run)
+	node1, node2 = run.unorder(node1, node2)
+	run.tune(node1, node2)
+	run.nodeDifference(make(FieldName, 0, pathCapacity), node1, node2, emit)
+}
+
+// DifferenceCtx computes difference between two documents like
+// Difference, but stops early if ctx is done, or if a limit set by
+// MaxDeltas or MaxDepth is reached. In that case, the deltas found
+// until then are returned with the context error, ErrTooManyDeltas,
+// or ErrTooDeep.
+func DifferenceCtx(ctx context.Context, node1, node2 interface{}, opts ...Option) ([]Delta, error) {
+	return NewDiffer(opts...).DifferenceCtx(ctx, node1, node2)
+}
+
+// DifferenceCtx computes difference between two documents like
+// Difference, but stops early if ctx is done, or if a limit set by
+// MaxDeltas or MaxDepth is reached. In that case, the deltas found
+// until then are returned with the context error, ErrTooManyDeltas,
+// or ErrTooDeep.
+func (d *Differ) DifferenceCtx(ctx context.Context, node1, node2 interface{}) ([]Delta, error) {
+	var ret []Delta
+	run, emit := d.start(ctx, func(x Delta) {
+		ret = append(ret, x)
+	})
+	defer d.release(run)
+	node1, node2 = run.unorder(node1, node2)
+	run.tune(node1, node2)
+	run.nodeDifference(make(FieldName, 0, pathCapacity), node1, node2, emit)
+	return ret, run.err
+}
+
+// DifferenceAt computes the difference between the nodes at path in
+// doc1 and doc2 using the default options. See Differ.DifferenceAt.
+func DifferenceAt(path FieldName, doc1, doc2 interface{}) []Delta {
+	return NewDiffer().DifferenceAt(path, doc1, doc2)
+}
+
+// DifferenceAt computes the difference between the nodes at path in
+// doc1 and doc2, without comparing the rest of the documents. The
+// deltas have full paths, starting at the document root, and path
+// patterns of the options are matched against the full paths. If the
+// node at path exists in only one of the documents, it is reported as
+// added or removed.
+func (d *Differ) DifferenceAt(path FieldName, doc1, doc2 interface{}) []Delta {
+	var ret []Delta
+	run, emit := d.start(nil, func(x Delta) {
+		ret = append(ret, x)
+	})
+	defer d.release(run)
+	doc1, doc2 = run.unorder(doc1, doc2)
+	run.tune(doc1, doc2)
+	node1, ok1 := lookup(doc1, path)
+	node2, ok2 := lookup(doc2, path)
+	name := make(FieldName, len(path), len(path)+pathCapacity)
+	copy(name, path)
+	isIndex := len(path) > 0 && path[len(path)-1].IsIndex
+	switch {
+	case ok1 && ok2:
+		run.nodeDifference(name, node1, node2, emit)
+	case ok1 && isIndex:
+		emit(Deletion{Name: name, DeletedNode: node1})
+	case ok1:
+		emit(run.removedField(name, node1))
+	case ok2 && isIndex:
+		emit(Insertion{Name: name, NewNode: node2})
+	case ok2:
+		emit(run.addedField(name, node2))
+	}
+	return ret
+}
+
+// pathCapacity is the initial capacity of the field name buffer
+// shared by the nodes compared in a difference computation. Deeper
+// nodes allocate a larger buffer
+const pathCapacity = 16
+
+// start returns a copy of the Differ to run a difference computation,
+// and the function to emit deltas that enforces the MaxDeltas
+// limit. The run must be passed to release when it is done
+func (d *Differ) start(ctx context.Context, fn func(Delta)) (*Differ, func(Delta)) {
+	run := *d
+	run.scratch = d.acquire()
+	run.hashes = run.scratch.hashes
+	run.ctx = ctx
+	emit := func(x Delta) {
+		if run.err != nil {
+			return
+		}
+		if run.maxDeltas > 0 && run.nDeltas >= run.maxDeltas {
+			run.stop(x.GetField(), fmt.Errorf("%w: more than %d", ErrTooManyDeltas, run.maxDeltas))
+			return
+		}
+		run.nDeltas++
+		fn(run.truncateDelta(run.redactDelta(x)))
+	}
+	return &run, emit
+}
+
+// stop stops the difference computation with err
+func (d *Differ) stop(name FieldName, err error) {
+	d.err = err
+	d.warnf(WarnTruncated, name, "%s", err)
+}
+
+// ctxCheckInterval is the number of nodes compared between context
+// checks
+const ctxCheckInterval = 256
+
+// stopped returns if the difference computation should stop before
+// comparing the nodes at fieldName
+func (d *Differ) stopped(fieldName FieldName) bool {
+	if d.err != nil {
+		return true
+	}
+	if d.maxDepth > 0 && len(fieldName) > d.maxDepth {
+		d.stop(fieldName, fmt.Errorf("%w: more than %d levels", ErrTooDeep, d.maxDepth))
+		return true
+	}
+	if d.ctx != nil {
+		d.nNodes++
+		if d.nNodes%ctxCheckInterval == 0 {
+			if err := d.ctx.Err(); err != nil {
+				d.stop(fieldName, err)
+				return true
+			}
+		}
+	}
+	return false
+}
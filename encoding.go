@@ -0,0 +1,236 @@
+package jsondiff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// FormatVersion is the version of the serialized delta format written
+// by this package. Every serialized delta contains the version in the
+// "v" field. Deltas without a version field were written before
+// versioning was introduced, and are read as version 0.
+//
+// Version 0 paths are arrays of strings, where array indexes are
+// written as decimal strings. Version 1 paths write array indexes as
+// numbers. Version 2 adds the rename op "=>", and the "text" and
+// "typeChanged" fields of modifications.
+const FormatVersion = 2
+
+// jsonDelta is the serialized form of a delta
+type jsonDelta struct {
+	Version     int         `json:"v"`
+	Op          DiffType    `json:"op"`
+	Path        FieldName   `json:"path"`
+	From        FieldName   `json:"from,omitempty"`
+	Value       interface{} `json:"value,omitempty"`
+	Old         interface{} `json:"old,omitempty"`
+	New         interface{} `json:"new,omitempty"`
+	Text        []TextEdit  `json:"text,omitempty"`
+	TypeChanged bool        `json:"typeChanged,omitempty"`
+}
+
+// MarshalJSON encodes the insertion as {"v":2,"op":"+","path":[...],"value":...}
+func (x Insertion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDelta{Version: FormatVersion, Op: DiffIns, Path: x.Name, Value: canonicalValue(x.NewNode)})
+}
+
+// MarshalJSON encodes the deletion as {"v":2,"op":"-","path":[...],"value":...}
+func (x Deletion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDelta{Version: FormatVersion, Op: DiffDel, Path: x.Name, Value: canonicalValue(x.DeletedNode)})
+}
+
+// MarshalJSON encodes the move as {"v":2,"op":"<->","from":[...],"path":[...],"old":...,"new":...}
+func (x Move) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDelta{Version: FormatVersion, Op: DiffMove, Path: x.To, From: x.From, Old: canonicalValue(x.Old), New: canonicalValue(x.New)})
+}
+
+// MarshalJSON encodes the rename as {"v":2,"op":"=>","from":[...],"path":[...],"value":...}
+func (x Rename) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDelta{Version: FormatVersion, Op: DiffRename, Path: x.GetField(), From: x.Name.child(KeySegment(x.OldKey)), Value: canonicalValue(x.Value)})
+}
+
+// MarshalJSON encodes the modification as {"v":2,"op":"*","path":[...],"old":...,"new":...}.
+// If the modification has a text diff, it is written to the "text"
+// field, and if the type of the node changed, "typeChanged" is true.
+func (x Modification) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDelta{Version: FormatVersion, Op: DiffMod, Path: x.Name, Old: canonicalValue(x.Old), New: canonicalValue(x.New), Text: x.TextDiff, TypeChanged: x.TypeChanged})
+}
+
+// JSONLinesWriter writes deltas to an io.Writer in JSON Lines
+// format, one JSON object per delta per line. It can be used with
+// Differ.DifferenceFunc to write deltas as they are found:
+//
+//	w := NewJSONLinesWriter(out)
+//	differ.DifferenceFunc(doc1, doc2, w.WriteDelta)
+//	if err := w.Err(); err != nil {
+//	...
+//
+// After the first write error, all subsequent writes are ignored, and
+// the error is returned by Err.
+type JSONLinesWriter struct {
+	enc *json.Encoder
+	err error
+}
+
+// NewJSONLinesWriter returns a new JSONLinesWriter writing to w
+func NewJSONLinesWriter(w io.Writer) *JSONLinesWriter {
+	return &JSONLinesWriter{enc: json.NewEncoder(w)}
+}
+
+// WriteDelta writes d as a single line
+func (w *JSONLinesWriter) WriteDelta(d Delta) {
+	if w.err != nil {
+		return
+	}
+	w.err = w.enc.Encode(d)
+}
+
+// Err returns the first error encountered while writing
+func (w *JSONLinesWriter) Err() error {
+	return w.err
+}
+
+// WriteJSONLines writes deltas to w in JSON Lines format
+func WriteJSONLines(w io.Writer, deltas []Delta) error {
+	jw := NewJSONLinesWriter(w)
+	for _, d := range deltas {
+		jw.WriteDelta(d)
+	}
+	return jw.Err()
+}
+
+// UnmarshalDelta decodes a delta written by one of the MarshalJSON
+// methods of the delta types. All format versions up to
+// FormatVersion are accepted.
+func UnmarshalDelta(data []byte) (Delta, error) {
+	var hdr struct {
+		Version *int `json:"v"`
+	}
+	if err := json.Unmarshal(data, &hdr); err != nil {
+		return nil, err
+	}
+	version := 0
+	if hdr.Version != nil {
+		version = *hdr.Version
+	}
+	var x jsonDelta
+	switch version {
+	case 0:
+		var v0 struct {
+			Op    DiffType    `json:"op"`
+			Path  []string    `json:"path"`
+			From  []string    `json:"from"`
+			Value interface{} `json:"value"`
+			Old   interface{} `json:"old"`
+			New   interface{} `json:"new"`
+		}
+		if err := json.Unmarshal(data, &v0); err != nil {
+			return nil, err
+		}
+		x = jsonDelta{Op: v0.Op,
+			Path:  fieldNameV0(v0.Path),
+			From:  fieldNameV0(v0.From),
+			Value: v0.Value,
+			Old:   v0.Old,
+			New:   v0.New}
+	case 1, 2:
+		if err := json.Unmarshal(data, &x); err != nil {
+			return nil, err
+		}
+		if version == 1 && (x.Op == DiffRename || x.Text != nil || x.TypeChanged) {
+			return nil, fmt.Errorf("invalid delta for format version 1: %s", data)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported delta format version: %d", version)
+	}
+	switch x.Op {
+	case DiffIns:
+		return Insertion{Name: x.Path, NewNode: x.Value}, nil
+	case DiffDel:
+		return Deletion{Name: x.Path, DeletedNode: x.Value}, nil
+	case DiffMove:
+		return Move{From: x.From, To: x.Path, Old: x.Old, New: x.New}, nil
+	case DiffMod:
+		return Modification{Name: x.Path, Old: x.Old, New: x.New, TextDiff: x.Text, TypeChanged: x.TypeChanged}, nil
+	case DiffRename:
+		n := len(x.Path)
+		if n == 0 || len(x.From) != n || x.Path[n-1].IsIndex || x.From[n-1].IsIndex || !sameField(x.Path[:n-1], x.From[:n-1]) {
+			return nil, fmt.Errorf("invalid rename from %s to %s", x.From, x.Path)
+		}
+		return Rename{Name: x.Path[:n-1], OldKey: x.From[n-1].Key, NewKey: x.Path[n-1].Key, Value: x.Value}, nil
+	}
+	return nil, fmt.Errorf("unknown delta op: %q", x.Op)
+}
+
+// fieldNameV0 converts a version 0 path to a field name. Version 0
+// did not distinguish array indexes from object keys, so all
+// nonnegative decimal strings are read as array indexes.
+func fieldNameV0(path []string) FieldName {
+	if path == nil {
+		return nil
+	}
+	name := make(FieldName, len(path))
+	for i, p := range path {
+		if ix, err := strconv.Atoi(p); err == nil && ix >= 0 && strconv.Itoa(ix) == p {
+			name[i] = IndexSegment(ix)
+		} else {
+			name[i] = KeySegment(p)
+		}
+	}
+	return name
+}
+
+// JSONLinesReader reads deltas written in JSON Lines format by a
+// JSONLinesWriter. Empty lines are skipped.
+type JSONLinesReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewJSONLinesReader returns a new JSONLinesReader reading from r
+func NewJSONLinesReader(r io.Reader) *JSONLinesReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	return &JSONLinesReader{scanner: scanner}
+}
+
+// ReadDelta reads the next delta. It returns io.EOF when there are no
+// more deltas.
+func (r *JSONLinesReader) ReadDelta() (Delta, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		d, err := UnmarshalDelta(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return d, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// ReadJSONLines reads all deltas from r in JSON Lines format
+func ReadJSONLines(r io.Reader) ([]Delta, error) {
+	jr := NewJSONLinesReader(r)
+	var ret []Delta
+	for {
+		d, err := jr.ReadDelta()
+		if err == io.EOF {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, d)
+	}
+}
@@ -0,0 +1,107 @@
+package jsondiff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestJSONLines(t *testing.T) {
+	doc1, err := parse(`{"f1":"value1","f2":[1,2,3]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"f1":"value2","f2":[1,3,4]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	var buf bytes.Buffer
+	w := NewJSONLinesWriter(&buf)
+	NewDiffer().DifferenceFunc(doc1, doc2, w.WriteDelta)
+	if w.Err() != nil {
+		t.Errorf("Write error: %s", w.Err())
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Errorf("Wrong output: %s", buf.String())
+		return
+	}
+	expected := map[string]bool{
+		`{"v":2,"op":"*","path":["f1"],"old":"value1","new":"value2"}`: true,
+		`{"v":2,"op":"-","path":["f2",1],"value":2}`:                   true,
+		`{"v":2,"op":"+","path":["f2",2],"value":4}`:                   true,
+	}
+	for _, l := range lines {
+		if !expected[l] {
+			t.Errorf("Unexpected line: %s", l)
+		}
+	}
+}
+
+func TestReadJSONLines(t *testing.T) {
+	doc1, err := parse(`{"f1":"value1","f2":[1,2,3],"f3":{"x":[1,2]}}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"f1":"value2","f2":[3,1,4],"f3":{"x":[2]}}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	deltas := Difference(doc1, doc2)
+	var buf bytes.Buffer
+	if err := WriteJSONLines(&buf, deltas); err != nil {
+		t.Errorf("Write error: %s", err)
+		return
+	}
+	decoded, err := ReadJSONLines(&buf)
+	if err != nil {
+		t.Errorf("Read error: %s", err)
+		return
+	}
+	result, err := Apply(doc1, decoded)
+	if err != nil {
+		t.Errorf("Cannot apply: %s", err)
+		return
+	}
+	if !IsEqual(result, doc2) {
+		t.Errorf("Wrong result: %v", result)
+	}
+}
+
+func TestUnmarshalDeltaVersions(t *testing.T) {
+	d, err := UnmarshalDelta([]byte(`{"op":"-","path":["f2","1"],"value":2}`))
+	if err != nil {
+		t.Errorf("Cannot decode version 0: %s", err)
+		return
+	}
+	if del, ok := d.(Deletion); !ok || !sameField(del.Name, FieldName{KeySegment("f2"), IndexSegment(1)}) {
+		t.Errorf("Wrong version 0 delta: %v", d)
+	}
+	d, err = UnmarshalDelta([]byte(`{"v":1,"op":"<->","from":["a",0],"path":["a",2]}`))
+	if err != nil {
+		t.Errorf("Cannot decode version 1: %s", err)
+		return
+	}
+	if m, ok := d.(Move); !ok || m.From.String() != "a/0" || m.To.String() != "a/2" {
+		t.Errorf("Wrong version 1 delta: %v", d)
+	}
+	for _, s := range []string{`{"v":1,"op":"=>","from":["a","x"],"path":["a","y"],"value":1}`,
+		`{"v":1,"op":"*","path":["a"],"old":1,"new":"1","typeChanged":true}`,
+		`{"v":1,"op":"*","path":["a"],"old":"x","new":"y","text":[{"op":"-","text":"x"},{"op":"+","text":"y"}]}`} {
+		if d, err := UnmarshalDelta([]byte(s)); err == nil {
+			t.Errorf("Expected error for version 1 delta %s, got %v", s, d)
+		}
+	}
+	d, err = UnmarshalDelta([]byte(`{"v":2,"op":"=>","from":["a","x"],"path":["a","y"],"value":1}`))
+	if r, ok := d.(Rename); err != nil || !ok || r.OldKey != "x" || r.NewKey != "y" {
+		t.Errorf("Wrong version 2 delta: %v %v", d, err)
+	}
+	if _, err := UnmarshalDelta([]byte(`{"v":99,"op":"+","path":[]}`)); err == nil {
+		t.Errorf("Expected error for unsupported version")
+	}
+}
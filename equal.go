@@ -0,0 +1,190 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// pathEqualFunc is a custom equality function for the fields matching a pattern
+type pathEqualFunc struct {
+	pattern string
+	fn      func(a, b interface{}) bool
+	// scalars is set if the function is only used when neither value
+	// is an object or an array
+	scalars bool
+	// hashed is set if the function does not change how array
+	// elements are matched: elements are still matched by their hashes
+	hashed bool
+}
+
+// customEqual returns the custom equality function for the field
+// with the values node1 and node2, or nil if there is none
+func (d *Differ) customEqual(name FieldName, node1, node2 interface{}) func(a, b interface{}) bool {
+	for _, f := range d.equalFuncs {
+		if f.scalars && (isContainer(node1) || isContainer(node2)) {
+			continue
+		}
+		if MatchPath(f.pattern, name) {
+			return f.fn
+		}
+	}
+	return nil
+}
+
+// isContainer returns if node is an object or an array
+func isContainer(node interface{}) bool {
+	switch node.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// customEquality returns if nodes are compared using the custom
+// equality functions, the absent field rules, or case insensitive keys,
+// instead of their values and hashes
+func (d *Differ) customEquality() bool {
+	for _, f := range d.equalFuncs {
+		if !f.hashed {
+			return true
+		}
+	}
+	return d.hasAbsentRules() || d.foldKeys
+}
+
+// IsEqual checks if two nodes are the same, using the custom equality
+// functions of the Differ
+func (d *Differ) IsEqual(node1, node2 interface{}) bool {
+	return d.isEqual(FieldName{}, node1, node2)
+}
+
+func (d *Differ) isEqual(fieldName FieldName, node1, node2 interface{}) bool {
+	if len(d.equalFuncs) == 0 && !d.customEquality() {
+		return IsEqual(node1, node2)
+	}
+	if fn := d.customEqual(fieldName, node1, node2); fn != nil {
+		return fn(node1, node2)
+	}
+	switch k1 := node1.(type) {
+	case map[string]interface{}:
+		k2, ok := node2.(map[string]interface{})
+		if !ok || (!d.hasAbsentRules() && len(k1) != len(k2)) {
+			return false
+		}
+		if d.foldKeys {
+			return d.foldedObjectEqual(fieldName, k1, k2)
+		}
+		for k, v1 := range k1 {
+			name := fieldName.child(KeySegment(k))
+			v2, ok := k2[k]
+			if !ok {
+				if !d.isAbsent(name, v1) {
+					return false
+				}
+				continue
+			}
+			if !d.isEqual(name, v1, v2) && (!d.isAbsent(name, v1) || !d.isAbsent(name, v2)) {
+				return false
+			}
+		}
+		for k, v2 := range k2 {
+			if _, ok := k1[k]; !ok && !d.isAbsent(fieldName.child(KeySegment(k)), v2) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		k2, ok := node2.([]interface{})
+		if !ok || len(k1) != len(k2) {
+			return false
+		}
+		for i := range k1 {
+			if !d.isEqual(fieldName.child(IndexSegment(i)), k1[i], k2[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return IsEqual(node1, node2)
+}
+
+// customEquivalence returns a function that matches the elements of
+// the arrays at fieldName using the custom equality functions and
+// schema defaults. Node
+// hashes cannot be used to filter the candidates, because nodes that
+// are equal under a custom equality function may have different
+// hashes.
+func (d *Differ) customEquivalence(fieldName FieldName) func(node1, node2 []interface{}) dualMap {
+	return func(node1, node2 []interface{}) dualMap {
+		equivalence := newDualMap(len(node1), len(node2))
+		for i, n1 := range node1 {
+			for j, n2 := range node2 {
+				if equivalence.getOldIndex(j) != -1 {
+					continue
+				}
+				if d.isEqual(fieldName.child(IndexSegment(j)), n1, n2) {
+					equivalence.insert(i, j)
+					break
+				}
+			}
+		}
+		return equivalence
+	}
+}
+
+// numericStringEqual compares two values, treating a string
+// containing a JSON number as equal to that number
+func numericStringEqual(a, b interface{}) bool {
+	if IsEqual(a, b) {
+		return true
+	}
+	x, ok := numericValue(a)
+	if !ok {
+		return false
+	}
+	y, ok := numericValue(b)
+	return ok && x == y
+}
+
+// numericValue returns the numeric value of a number, or of a string
+// containing a JSON number
+func numericValue(v interface{}) (float64, bool) {
+	if s, ok := v.(string); ok {
+		if s == "" || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) || !json.Valid([]byte(s)) {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil
+	}
+	n, ok := canonicalNumber(v)
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(n, 64)
+	return f, err == nil
+}
+
+// normalize applies the normalization rules to s
+func (n StringNormalization) normalize(s string) string {
+	if n&CollapseSpace != 0 {
+		s = strings.Join(strings.Fields(s), " ")
+	} else if n&TrimSpace != 0 {
+		s = strings.TrimSpace(s)
+	}
+	return s
+}
+
+// equal compares two values. Strings are compared after normalization
+func (n StringNormalization) equal(a, b interface{}) bool {
+	s1, ok1 := a.(string)
+	s2, ok2 := b.(string)
+	if !ok1 || !ok2 {
+		return IsEqual(a, b)
+	}
+	s1, s2 = n.normalize(s1), n.normalize(s2)
+	if n&CaseFold != 0 {
+		return strings.EqualFold(s1, s2)
+	}
+	return s1 == s2
+}
@@ -0,0 +1,124 @@
+package jsondiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func caseInsensitive(a, b interface{}) bool {
+	s1, ok1 := a.(string)
+	s2, ok2 := b.(string)
+	if ok1 && ok2 {
+		return strings.EqualFold(s1, s2)
+	}
+	return IsEqual(a, b)
+}
+
+func TestEqualFunc(t *testing.T) {
+	doc1, err := parse(`{"email":"A@B.com","token":"abc","users":[{"email":"X@Y.com"},{"email":"z@w.com"}]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"email":"a@b.com","token":"ABC","users":[{"email":"Z@W.com"},{"email":"x@y.com"}]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	differ := NewDiffer(EqualFunc("email", caseInsensitive), EqualFunc("users/*/email", caseInsensitive))
+	delta := differ.Difference(doc1, doc2)
+	// token is different, users are moved
+	if len(delta) != 2 {
+		t.Errorf("Unexpected diff: %v", delta)
+		return
+	}
+	for _, d := range delta {
+		switch x := d.(type) {
+		case Modification:
+			if x.Name.String() != "token" {
+				t.Errorf("Wrong delta: %v", x)
+			}
+		case Move:
+			if x.From.String() != "users/1" || x.To.String() != "users/0" {
+				t.Errorf("Wrong delta: %v", x)
+			}
+		default:
+			t.Errorf("Wrong delta: %v", d)
+		}
+	}
+	if differ.IsEqual(doc1, doc2) {
+		t.Errorf("Documents are not equal")
+	}
+	doc2, _ = parse(`{"email":"a@b.com","token":"abc","users":[{"email":"x@y.com"},{"email":"Z@W.com"}]}`)
+	if !differ.IsEqual(doc1, doc2) {
+		t.Errorf("Documents are equal")
+	}
+}
+
+func TestNumericStringIDs(t *testing.T) {
+	doc1, err := parse(`{"id":"42","ref":{"id":7},"count":"3","items":[{"id":1},{"id":"2"}]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"id":42,"ref":{"id":"7"},"count":3,"items":[{"id":"2"},{"id":"1"}]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	delta := NewDiffer(NumericStringIDs("id", "**/id")).Difference(doc1, doc2)
+	// count is not an ID, items are moved
+	if len(delta) != 2 {
+		t.Errorf("Unexpected diff: %v", delta)
+		return
+	}
+	for _, d := range delta {
+		switch x := d.(type) {
+		case Modification:
+			if x.Name.String() != "count" {
+				t.Errorf("Wrong delta: %v", x)
+			}
+		case Move:
+		default:
+			t.Errorf("Wrong delta: %v", d)
+		}
+	}
+
+	// Objects matching the pattern are compared recursively
+	doc1, _ = parse(`{"ref":{"id":"7","name":"a"}}`)
+	doc2, _ = parse(`{"ref":{"id":7,"name":"b"}}`)
+	delta = NewDiffer(NumericStringIDs("ref/*", "ref")).Difference(doc1, doc2)
+	if len(delta) != 1 || delta[0].GetField().String() != "ref/name" {
+		t.Errorf("Unexpected diff: %v", delta)
+	}
+	if numericStringEqual("abc", 1.0) || numericStringEqual("1x", 1.0) || numericStringEqual("Inf", 1.0) {
+		t.Errorf("Wrong comparison")
+	}
+}
+
+func TestNormalizeStrings(t *testing.T) {
+	doc1, err := parse(`{"email":" A@B.com ","country":"us","name":"a  b","token":"Abc"}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"email":"a@b.com","country":"US","name":" a b","token":"abc"}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	delta := NewDiffer(NormalizeStrings("email", TrimSpace|CaseFold),
+		NormalizeStrings("country", CaseFold),
+		NormalizeStrings("name", CollapseSpace)).Difference(doc1, doc2)
+	if len(delta) != 1 || delta[0].GetField().String() != "token" {
+		t.Errorf("Unexpected diff: %v", delta)
+	}
+
+	// Containers matching the pattern are compared recursively
+	doc1, _ = parse(`{"user":{"email":"A@B.com","tags":["X","y"],"age":1}}`)
+	doc2, _ = parse(`{"user":{"email":"a@b.com","tags":["x","Y"],"age":2}}`)
+	delta = NewDiffer(NormalizeStrings("**", CaseFold)).Difference(doc1, doc2)
+	if len(delta) != 1 || delta[0].GetField().String() != "user/age" {
+		t.Errorf("Unexpected diff: %v", delta)
+	}
+}
@@ -0,0 +1,54 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Errors returned when a difference computation stops early because
+// a limit is reached. The deltas found until then are returned along
+// with the error.
+var (
+	// ErrTooManyDeltas is returned when there are more deltas than
+	// the MaxDeltas limit
+	ErrTooManyDeltas = errors.New("too many deltas")
+	// ErrTooDeep is returned when the documents are nested deeper
+	// than the MaxDepth limit
+	ErrTooDeep = errors.New("documents are too deep")
+)
+
+// ParseError is returned when one of the input documents cannot be
+// parsed
+type ParseError struct {
+	// Doc is the document that failed to parse, 1 for the first
+	// document, and 2 for the second
+	Doc int
+	// Offset is the byte offset in the document where the error
+	// occurred
+	Offset int64
+	// Err is the underlying error
+	Err error
+}
+
+// newParseError returns a ParseError for the document. If the error
+// carries an offset, that offset is used instead of offset.
+func newParseError(doc int, offset int64, err error) *ParseError {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	}
+	return &ParseError{Doc: doc, Offset: offset, Err: err}
+}
+
+func (e *ParseError) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("document %d: %s", e.Doc, e.Err)
+	}
+	return fmt.Sprintf("document %d, offset %d: %s", e.Doc, e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error { return e.Err }
@@ -0,0 +1,40 @@
+package jsondiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	_, err := JSONDifference([]byte(`{"a":1}`), []byte(`{"a":}`))
+	if err == nil {
+		t.Errorf("Expected error")
+		return
+	}
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Errorf("Wrong error: %v", err)
+		return
+	}
+	if perr.Doc != 2 || perr.Offset != 6 {
+		t.Errorf("Wrong error: %v", perr)
+	}
+
+	_, err = JSONDifferenceReaders(strings.NewReader(`[1,2`), strings.NewReader(`{}`))
+	if perr, ok := err.(*ParseError); !ok || perr.Doc != 1 {
+		t.Errorf("Wrong error: %v", err)
+	}
+}
+
+func TestMustJSONDifference(t *testing.T) {
+	delta := MustJSONDifference([]byte(`{"a":1}`), []byte(`{"a":2}`))
+	if len(delta) != 1 {
+		t.Errorf("Unexpected diff: %v", delta)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic")
+		}
+	}()
+	MustJSONDifference([]byte(`{"a":1}`), []byte(`x`))
+}
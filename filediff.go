@@ -0,0 +1,87 @@
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// largeFileSize is the size above which FileDifference memory-maps the
+// files and compares them using DifferenceRaw
+var largeFileSize int64 = 1 << 20
+
+// FileDifference computes the difference between the JSON documents
+// in the files at path1 and path2 using the options. Small files are
+// read and decoded. Files larger than 1MB are memory-mapped where the
+// platform supports it, and compared as in DifferenceRaw, so the
+// subtrees that are byte-for-byte equal in the two files are not
+// decoded. Identical files have no differences. If one of the
+// documents cannot be parsed, the returned error wraps a *ParseError,
+// and gives the file name.
+func FileDifference(path1, path2 string, opts ...Option) ([]Delta, error) {
+	data1, size1, release1, err := readDocFile(path1)
+	if err != nil {
+		return nil, err
+	}
+	defer release1()
+	data2, size2, release2, err := readDocFile(path2)
+	if err != nil {
+		return nil, err
+	}
+	defer release2()
+	if bytes.Equal(data1, data2) && json.Valid(data1) {
+		return nil, nil
+	}
+	if size1 > largeFileSize || size2 > largeFileSize {
+		deltas, err := DifferenceRaw(data1, data2, opts...)
+		return deltas, fileParseError(path1, path2, err)
+	}
+	var n1, n2 interface{}
+	if err := json.Unmarshal(data1, &n1); err != nil {
+		return nil, fileParseError(path1, path2, newParseError(1, -1, err))
+	}
+	if err := json.Unmarshal(data2, &n2); err != nil {
+		return nil, fileParseError(path1, path2, newParseError(2, -1, err))
+	}
+	return NewDiffer(opts...).Difference(n1, n2), nil
+}
+
+// readDocFile returns the contents of the file at path, its size, and
+// a function that releases the contents. Large files are
+// memory-mapped if possible
+func readDocFile(path string) ([]byte, int64, func(), error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	size := info.Size()
+	if size > largeFileSize {
+		if data, release, err := mmapFile(f, size); err == nil {
+			return data, size, release, nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return data, int64(len(data)), func() {}, nil
+}
+
+// fileParseError adds the name of the file that cannot be parsed to
+// a *ParseError. Other errors are returned unchanged
+func fileParseError(path1, path2 string, err error) error {
+	if e, ok := err.(*ParseError); ok {
+		path := path1
+		if e.Doc == 2 {
+			path = path2
+		}
+		return fmt.Errorf("%s: %w", path, e)
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package jsondiff
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileDifference(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	f1 := write("a.json", `{"a":1,"b":{"c":[1,2]}}`)
+	f2 := write("b.json", `{"a":2,"b":{"c":[1,2]}}`)
+	bad := write("bad.json", `{"a":`)
+
+	for _, size := range []int64{1 << 20, 0} {
+		largeFileSize = size
+		deltas, err := FileDifference(f1, f2)
+		if err != nil || len(deltas) != 1 || deltas[0].GetField().String() != "a" {
+			t.Errorf("Wrong deltas with size %d: %v %v", size, deltas, err)
+		}
+		if deltas, err := FileDifference(f1, f1); err != nil || len(deltas) != 0 {
+			t.Errorf("Wrong deltas for the same file: %v %v", deltas, err)
+		}
+		_, err = FileDifference(f1, bad)
+		var perr *ParseError
+		if !errors.As(err, &perr) || perr.Doc != 2 {
+			t.Errorf("Expected parse error, got %v", err)
+		}
+		if _, err := FileDifference(bad, bad); err == nil {
+			t.Errorf("Expected parse error for the same invalid file")
+		}
+	}
+	largeFileSize = 1 << 20
+	if _, err := FileDifference(f1, filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("Expected error for a missing file")
+	}
+}
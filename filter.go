@@ -0,0 +1,499 @@
+package jsondiff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter returns the deltas for which pred returns true
+func Filter(deltas []Delta, pred func(Delta) bool) []Delta {
+	var ret []Delta
+	for _, d := range deltas {
+		if pred(d) {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+// CompileFilter compiles a filter expression into a delta predicate,
+// for example:
+//
+//	type == "mod" && path ~ "spec/**" && old != null
+//
+// An expression is a comparison, a boolean field, or a combination of
+// expressions using &&, ||, ! and parentheses. A comparison compares two
+// operands using ==, !=, <, <=, >, or >=. The path operators ~ and !~
+// match a path against a pattern that uses the same syntax as
+// ArraysAsSets. Operands are string, number, true, false, and null
+// literals, and the following fields of the delta:
+//
+//	type          "ins", "del", "move", "mod", or "rename"
+//	path          the field name of the delta, as returned by GetField
+//	from          the source field name of a move or a rename, null for others
+//	old           the old value of a modification, a move, or a rename, or the deleted value
+//	new           the new value of a modification, a move, or a rename, or the inserted value
+//	depth         the number of segments of path
+//	typeChanged   true if a modification changes the JSON type of the node
+//
+// The nodes under old and new values can be selected using JSONPath
+// steps: .key, ["key"], [index], and the wildcards .* and [*], for
+// example new.status or old.items[*].name. A comparison is true if it
+// is true for any of the selected nodes, so comparisons of missing
+// nodes are false.
+//
+// Paths are compared as strings of the form "a/b/0". Values are
+// compared using IsEqual, and only numbers and strings can be
+// ordered.
+func CompileFilter(expr string) (func(Delta) bool, error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := filterParser{tokens: tokens}
+	pred, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != filterEOF {
+		return nil, p.errorf(t, "unexpected %s", t.text)
+	}
+	return pred, nil
+}
+
+type filterTokenKind int
+
+const (
+	filterEOF filterTokenKind = iota
+	filterIdent
+	filterString
+	filterNumber
+	filterOp
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+// filterOps are the operators, longest first
+var filterOps = []string{"&&", "||", "==", "!=", "<=", ">=", "!~", "!", "~", "<", ">", "(", ")", "[", "]", ".", "*"}
+
+// filterComparisons are the comparison operators
+var filterComparisons = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "~": true, "!~": true}
+
+// tokenizeFilter splits a filter expression into tokens
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	i := 0
+	for i < len(expr) {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(expr) && expr[j] != '"' {
+				if expr[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(expr) {
+				return nil, fmt.Errorf("filter: unterminated string at %d", i)
+			}
+			s, err := strconv.Unquote(expr[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("filter: invalid string at %d: %w", i, err)
+			}
+			tokens = append(tokens, filterToken{kind: filterString, text: s, pos: i})
+			i = j + 1
+		case c == '-' || unicode.IsDigit(c):
+			j := i + 1
+			for j < len(expr) && strings.ContainsRune("0123456789.eE+-", rune(expr[j])) {
+				j++
+			}
+			tokens = append(tokens, filterToken{kind: filterNumber, text: expr[i:j], pos: i})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i + 1
+			for j < len(expr) && (unicode.IsLetter(rune(expr[j])) || unicode.IsDigit(rune(expr[j])) || expr[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, filterToken{kind: filterIdent, text: expr[i:j], pos: i})
+			i = j
+		default:
+			found := false
+			for _, op := range filterOps {
+				if strings.HasPrefix(expr[i:], op) {
+					tokens = append(tokens, filterToken{kind: filterOp, text: op, pos: i})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("filter: unexpected character %q at %d", c, i)
+			}
+		}
+	}
+	return append(tokens, filterToken{kind: filterEOF, text: "end of expression", pos: len(expr)}), nil
+}
+
+// filterParser is a recursive descent parser for filter expressions
+type filterParser struct {
+	tokens []filterToken
+	next   int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.next]
+}
+
+func (p *filterParser) take() filterToken {
+	t := p.tokens[p.next]
+	if t.kind != filterEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *filterParser) errorf(t filterToken, format string, args ...interface{}) error {
+	return fmt.Errorf("filter: %s at %d", fmt.Sprintf(format, args...), t.pos)
+}
+
+func (p *filterParser) isOp(op string) bool {
+	t := p.peek()
+	return t.kind == filterOp && t.text == op
+}
+
+func (p *filterParser) or() (func(Delta) bool, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.take()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(d Delta) bool { return l(d) || right(d) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) and() (func(Delta) bool, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.take()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(d Delta) bool { return l(d) && right(d) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) unary() (func(Delta) bool, error) {
+	if p.isOp("!") {
+		p.take()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(d Delta) bool { return !x(d) }, nil
+	}
+	if p.isOp("(") {
+		p.take()
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isOp(")") {
+			return nil, p.errorf(p.peek(), "expected )")
+		}
+		p.take()
+		return x, nil
+	}
+	return p.comparison()
+}
+
+func (p *filterParser) comparison() (func(Delta) bool, error) {
+	leftToken := p.peek()
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	opToken := p.peek()
+	if opToken.kind != filterOp || !filterComparisons[opToken.text] {
+		// An operand without a comparison is true if its value is true
+		if leftToken.kind != filterIdent {
+			return nil, p.errorf(opToken, "expected comparison operator")
+		}
+		return func(d Delta) bool {
+			for _, v := range left(d) {
+				if v == true {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	p.take()
+	if opToken.text == "~" || opToken.text == "!~" {
+		if leftToken.kind != filterIdent || (leftToken.text != "path" && leftToken.text != "from") {
+			return nil, p.errorf(leftToken, "%s requires path or from", opToken.text)
+		}
+		patternToken := p.take()
+		if patternToken.kind != filterString {
+			return nil, p.errorf(patternToken, "expected pattern string")
+		}
+		pattern := patternToken.text
+		from := leftToken.text == "from"
+		negate := opToken.text == "!~"
+		return func(d Delta) bool {
+			name := d.GetField()
+			if from {
+				var ok bool
+				if name, ok = deltaSource(d); !ok {
+					return false
+				}
+			}
+			return MatchPath(pattern, name) != negate
+		}, nil
+	}
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	var cmp func(a, b interface{}) bool
+	switch opToken.text {
+	case "==":
+		cmp = IsEqual
+	case "!=":
+		cmp = func(a, b interface{}) bool { return !IsEqual(a, b) }
+	default:
+		op := opToken.text
+		cmp = func(a, b interface{}) bool {
+			c, ok := compareFilterValues(a, b)
+			if !ok {
+				return false
+			}
+			switch op {
+			case "<":
+				return c < 0
+			case "<=":
+				return c <= 0
+			case ">":
+				return c > 0
+			}
+			return c >= 0
+		}
+	}
+	return func(d Delta) bool {
+		for _, a := range left(d) {
+			for _, b := range right(d) {
+				if cmp(a, b) {
+					return true
+				}
+			}
+		}
+		return false
+	}, nil
+}
+
+// filterOperand returns the values of an operand for a delta. Fields
+// and literals have a single value, and JSONPath selections have one
+// value for each selected node
+type filterOperand func(Delta) []interface{}
+
+// operand parses a literal, a delta field, or a JSONPath selection
+// under old or new
+func (p *filterParser) operand() (filterOperand, error) {
+	t := p.take()
+	switch t.kind {
+	case filterString:
+		return func(Delta) []interface{} { return []interface{}{t.text} }, nil
+	case filterNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf(t, "invalid number %s", t.text)
+		}
+		return func(Delta) []interface{} { return []interface{}{f} }, nil
+	case filterIdent:
+		fn, ok := filterFields[t.text]
+		if !ok {
+			return nil, p.errorf(t, "unknown field %s", t.text)
+		}
+		if !p.isOp(".") && !p.isOp("[") {
+			return func(d Delta) []interface{} { return []interface{}{fn(d)} }, nil
+		}
+		if t.text != "old" && t.text != "new" {
+			return nil, p.errorf(p.peek(), "only old and new have fields")
+		}
+		steps, err := p.jsonPath()
+		if err != nil {
+			return nil, err
+		}
+		return func(d Delta) []interface{} {
+			return selectJSONPath([]interface{}{fn(d)}, steps)
+		}, nil
+	}
+	return nil, p.errorf(t, "unexpected %s", t.text)
+}
+
+// jsonPathStep is a step of a JSONPath selection: an object key, an
+// array index, or a wildcard selecting all children
+type jsonPathStep struct {
+	seg      Segment
+	wildcard bool
+}
+
+// jsonPath parses the steps of a JSONPath selection: .key, .*,
+// [index], ["key"], and [*]
+func (p *filterParser) jsonPath() ([]jsonPathStep, error) {
+	var steps []jsonPathStep
+	for {
+		switch {
+		case p.isOp("."):
+			p.take()
+			t := p.take()
+			switch {
+			case t.kind == filterIdent:
+				steps = append(steps, jsonPathStep{seg: KeySegment(t.text)})
+			case t.kind == filterOp && t.text == "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			default:
+				return nil, p.errorf(t, "expected field name")
+			}
+		case p.isOp("["):
+			p.take()
+			t := p.take()
+			switch {
+			case t.kind == filterString:
+				steps = append(steps, jsonPathStep{seg: KeySegment(t.text)})
+			case t.kind == filterNumber:
+				ix, err := strconv.Atoi(t.text)
+				if err != nil || ix < 0 {
+					return nil, p.errorf(t, "invalid array index %s", t.text)
+				}
+				steps = append(steps, jsonPathStep{seg: IndexSegment(ix)})
+			case t.kind == filterOp && t.text == "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			default:
+				return nil, p.errorf(t, "expected index")
+			}
+			if !p.isOp("]") {
+				return nil, p.errorf(p.peek(), "expected ]")
+			}
+			p.take()
+		default:
+			return steps, nil
+		}
+	}
+}
+
+// selectJSONPath returns the nodes selected by the steps under the
+// nodes. Missing fields and indexes select nothing
+func selectJSONPath(nodes []interface{}, steps []jsonPathStep) []interface{} {
+	for _, step := range steps {
+		var next []interface{}
+		for _, node := range nodes {
+			if step.wildcard {
+				switch n := node.(type) {
+				case map[string]interface{}:
+					for _, v := range n {
+						next = append(next, v)
+					}
+				case []interface{}:
+					next = append(next, n...)
+				}
+				continue
+			}
+			if v, ok := lookup(node, FieldName{step.seg}); ok {
+				next = append(next, v)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// filterFields are the literals and delta fields that can be used in
+// filter expressions
+var filterFields = map[string]func(Delta) interface{}{
+	"true":  func(Delta) interface{} { return true },
+	"false": func(Delta) interface{} { return false },
+	"null":  func(Delta) interface{} { return nil },
+	"type": func(d Delta) interface{} {
+		switch d.GetType() {
+		case DiffIns:
+			return "ins"
+		case DiffDel:
+			return "del"
+		case DiffMove:
+			return "move"
+		case DiffMod:
+			return "mod"
+		case DiffRename:
+			return "rename"
+		}
+		return string(d.GetType())
+	},
+	"path": func(d Delta) interface{} { return d.GetField().String() },
+	"from": func(d Delta) interface{} {
+		if name, ok := deltaSource(d); ok {
+			return name.String()
+		}
+		return nil
+	},
+	"old":   oldValue,
+	"new":   newValue,
+	"depth": func(d Delta) interface{} { return float64(len(d.GetField())) },
+	"typeChanged": func(d Delta) interface{} {
+		m, ok := d.(Modification)
+		return ok && m.TypeChanged
+	},
+}
+
+// deltaSource returns the old field of a Move or a Rename
+func deltaSource(d Delta) (FieldName, bool) {
+	switch x := d.(type) {
+	case Move:
+		return x.From, true
+	case Rename:
+		return x.Name.child(KeySegment(x.OldKey)), true
+	}
+	return nil, false
+}
+
+// compareFilterValues compares two numbers or two strings
+func compareFilterValues(a, b interface{}) (int, bool) {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+	}
+	return 0, false
+}
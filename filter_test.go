@@ -0,0 +1,115 @@
+package jsondiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompileFilter(t *testing.T) {
+	doc1, _ := parse(`{"spec":{"replicas":1,"name":"a","ports":[80,443]},"status":{"ready":false},"x":null}`)
+	doc2, _ := parse(`{"spec":{"replicas":3,"name":"b","ports":[443,80]},"status":{"ready":true},"x":"set"}`)
+	deltas := Difference(doc1, doc2)
+	tests := []struct {
+		expr  string
+		paths []string
+	}{
+		{`type == "mod" && path ~ "spec/**" && old != null`, []string{"spec/name", "spec/replicas"}},
+		{`type == "move"`, []string{"spec/ports/0"}},
+		{`type == "mod" && new > 2`, []string{"spec/replicas"}},
+		{`!(path ~ "spec/**") && depth >= 2`, []string{"status/ready"}},
+		{`typeChanged || new == true`, []string{"status/ready", "x"}},
+		{`path !~ "**/ports/*" && (old == "a" || old == null)`, []string{"spec/name", "x"}},
+		{`from ~ "spec/ports/*"`, []string{"spec/ports/0"}},
+	}
+	for _, test := range tests {
+		pred, err := CompileFilter(test.expr)
+		if err != nil {
+			t.Errorf("Cannot compile %s: %s", test.expr, err)
+			continue
+		}
+		selected := map[string]bool{}
+		for _, d := range Filter(deltas, pred) {
+			selected[d.GetField().String()] = true
+		}
+		if len(selected) != len(test.paths) {
+			t.Errorf("Wrong result for %s: %v", test.expr, selected)
+			continue
+		}
+		for _, p := range test.paths {
+			if !selected[p] {
+				t.Errorf("Wrong result for %s: %v", test.expr, selected)
+			}
+		}
+	}
+
+	for _, expr := range []string{``, `type ==`, `type == "mod" &&`, `(type == "mod"`, `foo == 1`,
+		`old ~ "a"`, `path ~ 1`, `type "mod"`, `"mod"`, `"unterminated`, `type == "mod" )`, `path # 1`} {
+		if _, err := CompileFilter(expr); err == nil {
+			t.Errorf("Expected error for %s", expr)
+		}
+	}
+}
+
+func TestFilterRename(t *testing.T) {
+	doc1, _ := parse(`{"a":{"b":1},"c":2}`)
+	doc2, _ := parse(`{"a":{"d":1},"c":3}`)
+	deltas := NewDiffer(DetectRenames()).Difference(doc1, doc2)
+	for _, expr := range []string{`from == "a/b"`, `from ~ "a/*"`, `type == "rename" && from !~ "c/**"`} {
+		pred, err := CompileFilter(expr)
+		if err != nil {
+			t.Errorf("Cannot compile %s: %s", expr, err)
+			continue
+		}
+		if d := Filter(deltas, pred); len(d) != 1 || d[0].GetField().String() != "a/d" {
+			t.Errorf("Wrong result for %s: %v", expr, d)
+		}
+	}
+}
+
+func TestFilterJSONPath(t *testing.T) {
+	deltas := []Delta{
+		Modification{Name: FieldName{KeySegment("jobs"), KeySegment("a")},
+			Old: map[string]interface{}{"status": "running", "tags": []interface{}{"x"}},
+			New: map[string]interface{}{"status": "failed", "tags": []interface{}{"x", "y"}}},
+		Modification{Name: FieldName{KeySegment("jobs"), KeySegment("b")},
+			Old: map[string]interface{}{"status": "running"},
+			New: map[string]interface{}{"status": "done", "retries": 3.0}},
+		Insertion{Name: FieldName{KeySegment("jobs"), KeySegment("c")},
+			NewNode: map[string]interface{}{"status": "failed", "owner": map[string]interface{}{"name": "ops"}}},
+		Modification{Name: FieldName{KeySegment("count")}, Old: 1.0, New: 2.0},
+	}
+	tests := []struct {
+		expr  string
+		paths []string
+	}{
+		{`new.status == "failed"`, []string{"jobs/a", "jobs/c"}},
+		{`type == "mod" && new.status == "failed"`, []string{"jobs/a"}},
+		{`old.status == "running" && new["status"] != "failed"`, []string{"jobs/b"}},
+		{`new.tags[1] == "y"`, []string{"jobs/a"}},
+		{`new.tags[*] == "x"`, []string{"jobs/a"}},
+		{`new.retries > 2`, []string{"jobs/b"}},
+		{`new.owner.name == "ops"`, []string{"jobs/c"}},
+		{`new.* == "done"`, []string{"jobs/b"}},
+		{`new.missing == null`, []string{}},
+		{`new > 1.5`, []string{"count"}},
+	}
+	for _, test := range tests {
+		pred, err := CompileFilter(test.expr)
+		if err != nil {
+			t.Errorf("Cannot compile %s: %s", test.expr, err)
+			continue
+		}
+		var paths []string
+		for _, d := range Filter(deltas, pred) {
+			paths = append(paths, d.GetField().String())
+		}
+		if strings.Join(paths, ",") != strings.Join(test.paths, ",") {
+			t.Errorf("Wrong result for %s: %v", test.expr, paths)
+		}
+	}
+	for _, expr := range []string{`path.x == 1`, `new. == 1`, `new[-1] == 1`, `new[0 == 1`, `new[true] == 1`} {
+		if _, err := CompileFilter(expr); err == nil {
+			t.Errorf("Expected error for %s", expr)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Format is the name of a document format that can be decoded into
+// the node model used by the package
+type Format string
+
+// JSON is the JSON document format. Other formats are registered by
+// the packages implementing them, for example the yamldiff package
+// registers YAML.
+const JSON Format = "json"
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[Format]func([]byte) (interface{}, error){
+		JSON: decodeJSON,
+	}
+)
+
+// RegisterFormat makes a document format available to
+// DifferenceAny. decode must return the document in the node model
+// returned by json.Unmarshal into an interface{}. If a format is
+// registered twice, the last registration is used.
+func RegisterFormat(format Format, decode func([]byte) (interface{}, error)) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[format] = decode
+}
+
+// Decode decodes doc in the given format into the node model
+func Decode(doc []byte, format Format) (interface{}, error) {
+	formatsMu.RLock()
+	decode, ok := formats[format]
+	formatsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown format: %q", format)
+	}
+	return decode(doc)
+}
+
+// DifferenceAny computes difference between two documents that may be
+// in different formats, for example a YAML values file and the JSON
+// document rendered from it. Both documents are decoded into the same
+// node model before they are compared. If one of the documents cannot
+// be decoded, the returned error is a *ParseError.
+func DifferenceAny(a, b []byte, formatA, formatB Format, opts ...Option) ([]Delta, error) {
+	n1, err := Decode(a, formatA)
+	if err != nil {
+		return nil, newParseError(1, -1, err)
+	}
+	n2, err := Decode(b, formatB)
+	if err != nil {
+		return nil, newParseError(2, -1, err)
+	}
+	return NewDiffer(opts...).Difference(n1, n2), nil
+}
+
+func decodeJSON(doc []byte) (interface{}, error) {
+	var node interface{}
+	if err := json.Unmarshal(doc, &node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
@@ -0,0 +1,32 @@
+package jsondiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDifferenceAny(t *testing.T) {
+	RegisterFormat("lines", func(doc []byte) (interface{}, error) {
+		var ret []interface{}
+		for _, l := range strings.Split(strings.TrimSpace(string(doc)), "\n") {
+			ret = append(ret, l)
+		}
+		return ret, nil
+	})
+	deltas, err := DifferenceAny([]byte(`["a","b"]`), []byte("a\nc\n"), JSON, "lines")
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	if len(deltas) != 2 {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+	if _, err := DifferenceAny([]byte(`{}`), []byte(`{}`), JSON, "unknown"); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+	if _, err := DifferenceAny([]byte(`{`), []byte(`{}`), JSON, JSON); err == nil {
+		t.Errorf("Expected parse error")
+	} else if perr, ok := err.(*ParseError); !ok || perr.Doc != 1 {
+		t.Errorf("Wrong error: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var fuzzSeeds = [][2]string{
+	{`{}`, `{}`},
+	{`{"a":1,"b":[1,2,3]}`, `{"a":2,"b":[3,1,4],"c":null}`},
+	{`[1,2,3,4,5]`, `[5,4,3,2,1]`},
+	{`[1,1,2,2]`, `[2,1,2,1,1]`},
+	{`[{"a":1},{"a":1},[1],[1,2]]`, `[[1,2],{"a":1},[1]]`},
+	{`{"a":{"b":[{"c":1}]}}`, `{"a":{"b":[{"c":2},{"c":1}]}}`},
+	{`"x"`, `[null,true,"x"]`},
+}
+
+// FuzzDifferenceApply checks that applying the difference of two
+// documents to the first document gives the second document. Removed
+// fields are reported as Deletions, so that null values survive the
+// round trip
+func FuzzDifferenceApply(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s[0], s[1])
+	}
+	f.Fuzz(func(t *testing.T, s1, s2 string) {
+		var doc1, doc2 interface{}
+		if json.Unmarshal([]byte(s1), &doc1) != nil || json.Unmarshal([]byte(s2), &doc2) != nil {
+			return
+		}
+		for _, d := range []*Differ{NewDiffer(ObjectFieldDeltas()),
+			NewDiffer(ObjectFieldDeltas(), ArrayKey("**", "a")),
+			NewDiffer(ObjectFieldDeltas(), MatchArrays("**", LCSMatcher()))} {
+			deltas := d.Difference(doc1, doc2)
+			result, err := Apply(doc1, deltas, SetNullFields())
+			if err != nil {
+				t.Fatalf("Cannot apply %v to %s: %s", deltas, s1, err)
+			}
+			if !IsEqual(result, doc2) {
+				t.Fatalf("Applying %v to %s gives %v, expected %s", deltas, s1, result, s2)
+			}
+			if IsEqual(doc1, doc2) != (len(deltas) == 0) {
+				t.Fatalf("Wrong deltas for %s %s: %v", s1, s2, deltas)
+			}
+		}
+	})
+}
+
+// FuzzIsEqual checks IsEqual against reflect.DeepEqual
+func FuzzIsEqual(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s[0], s[1])
+		f.Add(s[0], s[0])
+	}
+	f.Fuzz(func(t *testing.T, s1, s2 string) {
+		var doc1, doc2 interface{}
+		if json.Unmarshal([]byte(s1), &doc1) != nil || json.Unmarshal([]byte(s2), &doc2) != nil {
+			return
+		}
+		if IsEqual(doc1, doc2) != reflect.DeepEqual(doc1, doc2) {
+			t.Fatalf("IsEqual(%s, %s) is %v", s1, s2, IsEqual(doc1, doc2))
+		}
+		if IsEqual(doc1, doc2) && NodeHash(doc1) != NodeHash(doc2) {
+			t.Fatalf("Equal documents %s %s have different hashes", s1, s2)
+		}
+	})
+}
@@ -0,0 +1,5 @@
+module github.com/bserdar/jsondiff/v2
+
+go 1.23
+
+require gopkg.in/yaml.v2 v2.4.0
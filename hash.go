@@ -0,0 +1,203 @@
+package jsondiff
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"hash/maphash"
+	"math"
+	"math/big"
+	"reflect"
+)
+
+// FNV-1a parameters
+const (
+	fnvOffset uint64 = 14695981039346656037
+	fnvPrime  uint64 = 1099511628211
+)
+
+// fnvBytes adds data to the FNV-1a hash h
+func fnvBytes(h uint64, data []byte) uint64 {
+	for _, c := range data {
+		h ^= uint64(c)
+		h *= fnvPrime
+	}
+	return h
+}
+
+// fnvString adds s to the FNV-1a hash h
+func fnvString(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime
+	}
+	return h
+}
+
+// fnvUint64 adds x to the FNV-1a hash h
+func fnvUint64(h uint64, x uint64) uint64 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], x)
+	return fnvBytes(h, buf[:])
+}
+
+// hashKey identifies a container node by the address of its
+// contents. Two slices with the same address and length have the
+// same contents
+type hashKey struct {
+	ptr uintptr
+	n   int
+}
+
+// hashCache memoizes the hashes of container nodes during a
+// difference computation, so the elements of nested arrays are not
+// hashed again at every level. A nil hashCache does not memoize.
+type hashCache map[hashKey]uint64
+
+// maxUncachedLen is the largest number of elements of a container
+// of scalars whose hash is not memoized. Hashing such a container
+// again costs less than memoizing its hash
+const maxUncachedLen = 16
+
+// isScalar returns if node is not a container
+func isScalar(node interface{}) bool {
+	switch node.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
+
+// nodeHash calculates the hash of a node recursively. Each value is
+// prefixed with a type tag, so values of different types do not
+// collide trivially. Object hashes do not depend on the map iteration
+// order.
+func (c hashCache) nodeHash(node interface{}) uint64 {
+	switch k := node.(type) {
+	case map[string]interface{}:
+		if len(k) == 0 {
+			return fnvString(fnvOffset, "o")
+		}
+		key := hashKey{ptr: reflect.ValueOf(k).Pointer()}
+		if h, ok := c[key]; ok {
+			return h
+		}
+		// Combine the field hashes using addition, which does not
+		// depend on the order
+		var sum uint64
+		leaf := len(k) <= maxUncachedLen
+		for name, v := range k {
+			sum += fnvUint64(c.stringHash(fnvOffset, name), c.nodeHash(v))
+			leaf = leaf && isScalar(v)
+		}
+		h := fnvUint64(fnvUint64(fnvString(fnvOffset, "o"), uint64(len(k))), sum)
+		if c != nil && !leaf {
+			c[key] = h
+		}
+		return h
+	case []interface{}:
+		if len(k) == 0 {
+			return fnvString(fnvOffset, "a")
+		}
+		// The address of the first element identifies the slice
+		// without boxing it
+		key := hashKey{ptr: reflect.ValueOf(&k[0]).Pointer(), n: len(k)}
+		if h, ok := c[key]; ok {
+			return h
+		}
+		h := fnvString(fnvOffset, "a")
+		leaf := len(k) <= maxUncachedLen
+		for _, v := range k {
+			h = fnvUint64(h, c.nodeHash(v))
+			leaf = leaf && isScalar(v)
+		}
+		if c != nil && !leaf {
+			c[key] = h
+		}
+		return h
+	}
+	if str, ok := node.(string); ok {
+		return c.stringHash(fnvString(fnvOffset, "s"), str)
+	}
+	return valueHash(node)
+}
+
+// minMaphashLen is the length of the shortest string hashed using
+// hash/maphash
+const minMaphashLen = 16
+
+// stringSeed seeds the string hashes of hash caches
+var stringSeed = maphash.MakeSeed()
+
+// stringHash adds s to the hash h. Hash caches are used during a
+// single difference computation, so they hash strings using
+// hash/maphash, which uses the hardware accelerated hash of the
+// runtime where available and a portable implementation elsewhere.
+// Its hashes are different in every process, so a nil hashCache, as
+// used by NodeHash, hashes strings using FNV-1a. Short strings are
+// also hashed using FNV-1a, which is faster for them.
+func (c hashCache) stringHash(h uint64, s string) uint64 {
+	if c == nil || len(s) < minMaphashLen {
+		return fnvString(h, s)
+	}
+	return fnvUint64(h, maphash.String(stringSeed, s))
+}
+
+// valueHash returns the FNV-1a hash of a value node
+func valueHash(value interface{}) uint64 {
+	h := fnvOffset
+	switch k := value.(type) {
+	case nil:
+		return fnvString(h, "n")
+	case bool:
+		if k {
+			return fnvString(h, "t")
+		}
+		return fnvString(h, "f")
+	case string:
+		return fnvString(fnvString(h, "s"), k)
+	case json.Number:
+		return fnvString(fnvString(h, "N"), string(k))
+	case float64:
+		if k == 0 {
+			// -0 == 0
+			k = 0
+		}
+		return fnvUint64(fnvString(h, "d"), math.Float64bits(k))
+	case float32:
+		return fnvUint64(fnvString(h, "F"), uint64(math.Float32bits(k)))
+	case int:
+		return fnvUint64(fnvString(h, "i"), uint64(k))
+	case int8:
+		return fnvUint64(fnvString(h, "i8"), uint64(k))
+	case int16:
+		return fnvUint64(fnvString(h, "i16"), uint64(k))
+	case int32:
+		return fnvUint64(fnvString(h, "i32"), uint64(k))
+	case int64:
+		return fnvUint64(fnvString(h, "i64"), uint64(k))
+	case uint:
+		return fnvUint64(fnvString(h, "u"), uint64(k))
+	case uint8:
+		return fnvUint64(fnvString(h, "u8"), uint64(k))
+	case uint16:
+		return fnvUint64(fnvString(h, "u16"), uint64(k))
+	case uint32:
+		return fnvUint64(fnvString(h, "u32"), uint64(k))
+	case uint64:
+		return fnvUint64(fnvString(h, "u64"), k)
+	case big.Int:
+		return fnvString(fnvString(h, "I"), k.String())
+	case big.Float:
+		return fnvString(fnvString(h, "B"), k.String())
+	}
+	return h
+}
+
+// NodeHash calculates the hash of a node recursively. Equal nodes
+// have equal hashes, and the hash does not depend on the map
+// iteration order. The hash is fast to compute, but it may change
+// between package versions. Use StableHash for hashes that are
+// stored.
+func NodeHash(node interface{}) int {
+	return int(hashCache(nil).nodeHash(node))
+}
@@ -0,0 +1,46 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestNodeHash(t *testing.T) {
+	different := [][2]string{
+		{`"ab"`, `"ba"`},
+		{`{"a":"b"}`, `{"b":"a"}`},
+		{`[1,2]`, `[2,1]`},
+		{`[[1],[2]]`, `[[2],[1]]`},
+		{`{"a":1,"b":2}`, `{"a":2,"b":1}`},
+		{`"1"`, `1`},
+		{`[]`, `{}`},
+		{`null`, `false`},
+	}
+	for _, x := range different {
+		n1, _ := parse(x[0])
+		n2, _ := parse(x[1])
+		if NodeHash(n1) == NodeHash(n2) {
+			t.Errorf("Hash collision: %s %s", x[0], x[1])
+		}
+	}
+	n1, _ := parse(`{"a":[1,{"b":2,"c":3}],"d":-0}`)
+	n2, _ := parse(`{"d":0,"a":[1,{"c":3,"b":2}]}`)
+	if NodeHash(n1) != NodeHash(n2) {
+		t.Errorf("Equal nodes have different hashes")
+	}
+	cache := make(hashCache)
+	for i := 0; i < 2; i++ {
+		if int(cache.nodeHash(n1)) != NodeHash(n1) {
+			t.Errorf("Memoized hash is different")
+		}
+	}
+	// Long strings are hashed differently by hash caches
+	n1, _ = parse(`{"a":"a long string value that is hashed with maphash","b":[1]}`)
+	n2, _ = parse(`{"b":[1],"a":"a long string value that is hashed with maphash"}`)
+	n3, _ := parse(`{"b":[1],"a":"a long string value that is hashed with maphasH"}`)
+	if cache.nodeHash(n1) != cache.nodeHash(n2) || cache.nodeHash(n1) != make(hashCache).nodeHash(n2) {
+		t.Errorf("Equal nodes have different hashes")
+	}
+	if cache.nodeHash(n1) == cache.nodeHash(n3) {
+		t.Errorf("Hash collision")
+	}
+}
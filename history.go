@@ -0,0 +1,133 @@
+package jsondiff
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ValueChange describes the value of a field in a version of a
+// document
+type ValueChange struct {
+	// Version is the index of the document version
+	Version int
+	// Name is the field name in this version. Array indexes in the
+	// field name change as array elements are moved
+	Name FieldName
+	// Old is the value of the field in the previous version
+	Old interface{}
+	// New is the value of the field in this version
+	New interface{}
+	// Exists is false if the field is removed in this version
+	Exists bool
+}
+
+// History returns how the value of a field evolved in a sequence of
+// document versions. The first element of the result gives the
+// initial value of the field, and the following elements give the
+// versions where the value is changed, added, or removed. path is a
+// list of object keys and array indexes separated by "/", resolved in
+// the first version containing the field. The versions are compared
+// using the options, and array elements are followed as they move or
+// change between versions, so the field name may be different in each
+// version. Use ArrayKey to follow elements that are modified, because
+// elements matched by value are removed and inserted when they
+// change. If an array element containing the field is removed, the
+// field is removed, and the history ends.
+func History(path string, versions []interface{}, opts ...Option) []ValueChange {
+	var tokens []string
+	if path != "" {
+		tokens = strings.Split(path, "/")
+		for i, t := range tokens {
+			tokens[i] = pointerUnescaper.Replace(t)
+		}
+	}
+	d := NewDiffer(opts...)
+	var ret []ValueChange
+	// current is the field name in the previous version. It is nil
+	// until the field is found
+	var current FieldName
+	var value interface{}
+	exists := false
+	for i, version := range versions {
+		if current == nil {
+			current, exists = resolvePath(version, tokens)
+			if exists {
+				value, _ = lookup(version, current)
+				ret = append(ret, ValueChange{Version: i, Name: current, New: value, Exists: true})
+			}
+			continue
+		}
+		next, ok := followPath(current, d.Difference(versions[i-1], version))
+		if !ok {
+			// An array element containing the field is removed
+			if exists {
+				ret = append(ret, ValueChange{Version: i, Name: current, Old: value})
+			}
+			break
+		}
+		newValue, found := lookup(version, next)
+		switch {
+		case exists && !found:
+			ret = append(ret, ValueChange{Version: i, Name: current, Old: value})
+		case !exists && found:
+			ret = append(ret, ValueChange{Version: i, Name: next, New: newValue, Exists: true})
+		case exists && found && !IsEqual(value, newValue):
+			ret = append(ret, ValueChange{Version: i, Name: next, Old: value, New: newValue, Exists: true})
+		}
+		current = next
+		value = newValue
+		exists = found
+	}
+	return ret
+}
+
+// resolvePath converts tokens to a field name using the containers
+// in doc to decide whether a token is an object key or an array index
+func resolvePath(doc interface{}, tokens []string) (FieldName, bool) {
+	name := make(FieldName, 0, len(tokens))
+	for _, t := range tokens {
+		switch n := doc.(type) {
+		case map[string]interface{}:
+			v, ok := n[t]
+			if !ok {
+				return nil, false
+			}
+			name = append(name, KeySegment(t))
+			doc = v
+		case []interface{}:
+			ix, err := strconv.Atoi(t)
+			if err != nil || ix < 0 || ix >= len(n) {
+				return nil, false
+			}
+			name = append(name, IndexSegment(ix))
+			doc = n[ix]
+		default:
+			return nil, false
+		}
+	}
+	return name, true
+}
+
+// followPath returns the field name of the element at name after the
+// deltas are applied. It returns false if an array element containing
+// the field is deleted
+func followPath(name FieldName, deltas []Delta) (FieldName, bool) {
+	arrays := collectArrayChanges(deltas)
+	ret := make(FieldName, len(name))
+	copy(ret, name)
+	for k, s := range ret {
+		if !s.IsIndex {
+			continue
+		}
+		a, ok := arrays[fieldKey(ret[:k])]
+		if !ok {
+			continue
+		}
+		ix, ok := a.newIndex(s.Index)
+		if !ok {
+			return nil, false
+		}
+		ret[k] = IndexSegment(ix)
+	}
+	return ret, true
+}
@@ -0,0 +1,92 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	var versions []interface{}
+	for _, s := range []string{
+		`{"items":[{"name":"a"},{"name":"b"},{"name":"c"}]}`,
+		`{"items":[{"name":"a"},{"name":"b"},{"name":"c"}],"x":1}`,
+		`{"items":[{"name":"b"},{"name":"c"}]}`,
+		`{"items":[{"name":"c"},{"name":"x"},{"name":"b"}]}`,
+		`{"items":[{"name":"c"},{"name":"x"},{"name":"d"}]}`,
+		`{"items":[{"name":"c"},{"name":"x"},{"name":"b"}]}`,
+	} {
+		doc, err := parse(s)
+		if err != nil {
+			t.Errorf("Cannot parse: %s", err)
+			return
+		}
+		versions = append(versions, doc)
+	}
+	h := History("items/1/name", versions)
+	if len(h) != 2 {
+		t.Errorf("Wrong history: %v", h)
+		return
+	}
+	if h[0].Version != 0 || h[0].New.(string) != "b" || h[0].Name.String() != "items/1/name" {
+		t.Errorf("Wrong initial value: %v", h[0])
+	}
+	// Element is deleted, and the element inserted later at the
+	// original index is a different element
+	if h[1].Version != 4 || h[1].Exists || h[1].Name.String() != "items/2/name" {
+		t.Errorf("Wrong removal: %v", h[1])
+	}
+}
+
+func TestHistoryKeyed(t *testing.T) {
+	var versions []interface{}
+	for _, s := range []string{
+		`{"items":[{"id":1,"name":"a"},{"id":2,"name":"b"}]}`,
+		`{"items":[{"id":2,"name":"c"},{"id":1,"name":"a"}]}`,
+		`{"items":[{"id":3,"name":"x"},{"id":2},{"id":1,"name":"a"}]}`,
+		`{"items":[{"id":2,"name":"d"},{"id":1,"name":"a"}]}`,
+	} {
+		doc, _ := parse(s)
+		versions = append(versions, doc)
+	}
+	h := History("items/1/name", versions, ArrayKey("items", "id"))
+	if len(h) != 4 {
+		t.Errorf("Wrong history: %v", h)
+		return
+	}
+	if h[1].Version != 1 || h[1].Old.(string) != "b" || h[1].New.(string) != "c" || h[1].Name.String() != "items/0/name" {
+		t.Errorf("Wrong change: %v", h[1])
+	}
+	if h[2].Version != 2 || h[2].Exists || h[2].Name.String() != "items/0/name" {
+		t.Errorf("Wrong removal: %v", h[2])
+	}
+	if h[3].Version != 3 || h[3].New.(string) != "d" || h[3].Name.String() != "items/0/name" {
+		t.Errorf("Wrong addition: %v", h[3])
+	}
+
+	// Without the key, the modified element is removed
+	h = History("items/1/name", versions)
+	if len(h) != 2 || h[1].Version != 1 || h[1].Exists {
+		t.Errorf("Wrong history: %v", h)
+	}
+}
+
+func TestHistoryValue(t *testing.T) {
+	var versions []interface{}
+	for _, s := range []string{`{"a":1}`, `{"a":1}`, `{"a":2}`, `{}`, `{"a":3}`} {
+		doc, _ := parse(s)
+		versions = append(versions, doc)
+	}
+	h := History("a", versions)
+	if len(h) != 4 {
+		t.Errorf("Wrong history: %v", h)
+		return
+	}
+	if h[1].Version != 2 || h[1].Old.(float64) != 1 || h[1].New.(float64) != 2 {
+		t.Errorf("Wrong change: %v", h[1])
+	}
+	if h[2].Version != 3 || h[2].Exists {
+		t.Errorf("Wrong removal: %v", h[2])
+	}
+	if h[3].Version != 4 || h[3].New.(float64) != 3 {
+		t.Errorf("Wrong addition: %v", h[3])
+	}
+}
@@ -0,0 +1,92 @@
+package jsondiff
+
+import (
+	"fmt"
+)
+
+// IsIdempotent returns if applying the deltas twice gives the same
+// result as applying them once. Setting or removing object fields is
+// idempotent, but array insertions, deletions, and moves are not,
+// because they shift the array elements each time they are applied,
+// and neither are renames, because the old field no longer exists.
+func IsIdempotent(deltas []Delta) bool {
+	return len(arrayScopes(deltas)) == 0
+}
+
+// MakeIdempotent returns an idempotent form of the deltas that gives
+// the same result as deltas when applied to doc. All the changes
+// under an array that has insertions, deletions, or moves, or under
+// an object that has renamed fields are replaced by a single
+// Modification setting the array or object to its new value.
+func MakeIdempotent(doc interface{}, deltas []Delta) ([]Delta, error) {
+	scopes := arrayScopes(deltas)
+	if len(scopes) == 0 {
+		return deltas, nil
+	}
+	result, err := Apply(doc, deltas)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]Delta, 0, len(deltas))
+	for _, d := range deltas {
+		inScope := false
+		for _, scope := range scopes {
+			if hasPrefix(d.GetField(), scope) {
+				inScope = true
+				break
+			}
+		}
+		if !inScope {
+			ret = append(ret, d)
+		}
+	}
+	for _, scope := range scopes {
+		oldValue, ok := lookup(doc, scope)
+		if !ok {
+			return nil, fmt.Errorf("field does not exist: %s", scope)
+		}
+		newValue, _ := lookup(result, scope)
+		ret = append(ret, Modification{Name: scope, Old: oldValue, New: newValue})
+	}
+	return ret, nil
+}
+
+// arrayScopes returns the outermost arrays that have insertions,
+// deletions, or moves, and objects that have renamed fields
+func arrayScopes(deltas []Delta) []FieldName {
+	var scopes []FieldName
+	for _, d := range deltas {
+		var scope FieldName
+		switch x := d.(type) {
+		case Insertion, Deletion, Move:
+			name := d.GetField()
+			if len(name) == 0 || !name[len(name)-1].IsIndex {
+				continue
+			}
+			scope = name[:len(name)-1]
+		case Rename:
+			scope = x.Name
+		default:
+			continue
+		}
+		covered := false
+		for _, s := range scopes {
+			if hasPrefix(scope, s) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		// Remove the scopes under the new scope
+		kept := scopes[:0]
+		for _, s := range scopes {
+			if !hasPrefix(s, scope) {
+				kept = append(kept, s)
+			}
+		}
+		scopes = append(kept, scope)
+	}
+	return scopes
+}
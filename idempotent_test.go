@@ -0,0 +1,67 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestIdempotent(t *testing.T) {
+	doc1, err := parse(`{"a":1,"b":{"c":[1,2,3]},"d":[{"e":1}]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"a":2,"b":{"c":[1,3,4]},"d":[{"e":1}]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	delta := Difference(doc1, doc2)
+	if IsIdempotent(delta) {
+		t.Errorf("Array changes are not idempotent: %v", delta)
+	}
+	idem, err := MakeIdempotent(doc1, delta)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	if !IsIdempotent(idem) || len(idem) != 2 {
+		t.Errorf("Wrong idempotent deltas: %v", idem)
+	}
+	once, err := Apply(doc1, idem)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	twice, err := Apply(once, idem)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	if !IsEqual(once, doc2) || !IsEqual(twice, doc2) {
+		t.Errorf("Wrong result: %v %v", once, twice)
+	}
+
+	doc2, _ = parse(`{"a":2,"b":{"c":[1,2,3],"x":1},"d":[{"e":1}]}`)
+	delta = Difference(doc1, doc2)
+	if !IsIdempotent(delta) {
+		t.Errorf("Expected idempotent: %v", delta)
+	}
+}
+
+func TestIdempotentNestedScopes(t *testing.T) {
+	doc1, _ := parse(`{"a":[{"id":3},{"id":1,"x":[1]},{"id":2,"y":[1]}]}`)
+	doc2, _ := parse(`{"a":[{"id":1,"x":[2,1]},{"id":2,"y":[2,1]},{"id":3}]}`)
+	delta := NewDiffer(ArrayKey("a", "id")).Difference(doc1, doc2)
+	idem, err := MakeIdempotent(doc1, delta)
+	if err != nil {
+		t.Errorf("Error: %s, deltas: %v", err, delta)
+		return
+	}
+	if len(idem) != 1 || idem[0].GetField().String() != "a" {
+		t.Errorf("Wrong idempotent deltas: %v", idem)
+	}
+	result, err := Apply(doc1, idem)
+	if err != nil || !IsEqual(result, doc2) {
+		t.Errorf("Wrong result: %v %v", result, err)
+	}
+}
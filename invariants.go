@@ -0,0 +1,76 @@
+package jsondiff
+
+import (
+	"fmt"
+)
+
+// CheckInvariants checks the invariants of the difference engine for
+// the two documents, and returns an error describing the first
+// violation. It is intended for fuzzing and property based tests, so
+// the engine can be tested against any corpus of documents. The
+// checked invariants are:
+//
+//   - IsEqual is reflexive and symmetric
+//   - Equal documents have equal NodeHash values
+//   - Difference is empty if and only if the documents are equal
+//   - Applying Difference(doc1, doc2) to doc1 gives doc2, and
+//     applying Difference(doc2, doc1) to doc2 gives doc1
+//   - Applying Invert(Difference(doc1, doc2)) to doc2 gives doc1
+//
+// The differences are computed by a Differ with opts, and the
+// documents are compared using its IsEqual method. By default,
+// Difference reports a removed field and a field set to null the same
+// way, so documents with null valued fields that are missing in the
+// other document fail the apply invariant. Pass the ObjectFieldDeltas
+// option to check such documents. The deltas are then applied with
+// SetNullFields.
+func CheckInvariants(doc1, doc2 interface{}, opts ...Option) error {
+	d := NewDiffer(opts...)
+	var applyOpts []ApplyOption
+	if d.fieldDeltas {
+		applyOpts = append(applyOpts, SetNullFields())
+	}
+	if !d.IsEqual(doc1, doc1) {
+		return fmt.Errorf("IsEqual is not reflexive for %v", doc1)
+	}
+	if !d.IsEqual(doc2, doc2) {
+		return fmt.Errorf("IsEqual is not reflexive for %v", doc2)
+	}
+	equal := d.IsEqual(doc1, doc2)
+	if equal != d.IsEqual(doc2, doc1) {
+		return fmt.Errorf("IsEqual is not symmetric for %v and %v", doc1, doc2)
+	}
+	// Documents that are equal by custom rules may hash differently
+	if equal && !d.customEquality() && NodeHash(doc1) != NodeHash(doc2) {
+		return fmt.Errorf("equal documents have different hashes: %v and %v", doc1, doc2)
+	}
+	if err := checkApply(d, doc1, doc2, equal, applyOpts); err != nil {
+		return err
+	}
+	return checkApply(d, doc2, doc1, equal, applyOpts)
+}
+
+// checkApply checks that applying the difference of doc1 and doc2 to
+// doc1 gives doc2
+func checkApply(d *Differ, doc1, doc2 interface{}, equal bool, opts []ApplyOption) error {
+	deltas := d.Difference(doc1, doc2)
+	if equal != (len(deltas) == 0) {
+		return fmt.Errorf("difference of %v and %v is %v, but IsEqual is %v", doc1, doc2, deltas, equal)
+	}
+	result, err := Apply(doc1, deltas, opts...)
+	if err != nil {
+		return fmt.Errorf("cannot apply %v to %v: %s", deltas, doc1, err)
+	}
+	if !d.IsEqual(result, doc2) {
+		return fmt.Errorf("applying %v to %v gives %v, expected %v", deltas, doc1, result, doc2)
+	}
+	inverse := Invert(deltas)
+	result, err = Apply(doc2, inverse, opts...)
+	if err != nil {
+		return fmt.Errorf("cannot apply inverse %v to %v: %s", inverse, doc2, err)
+	}
+	if !d.IsEqual(result, doc1) {
+		return fmt.Errorf("applying inverse %v to %v gives %v, expected %v", inverse, doc2, result, doc1)
+	}
+	return nil
+}
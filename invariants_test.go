@@ -0,0 +1,45 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestCheckInvariants(t *testing.T) {
+	docs := []string{
+		`{"f1":"value1","f2":2,"f3":"x","f4":true}`,
+		`{"f1":"value2","f2":2,"f4":false}`,
+		`{"f1":[1,2,3,4,5,6]}`,
+		`{"f1":[1,3,8,4,6]}`,
+		`{"f1":[{"a":"b","c":1,"d":[1,2,3]},{"a":"e","c":2,"d":[4,5]}]}`,
+		`{"f1":[{"a":"1"},{"a":"2"},{"a":"e","c":2,"d":[4,5]},{"a":"4"}]}`,
+		`[1,1,2]`,
+		`[2,1,1]`,
+		`"x"`,
+	}
+	for _, s1 := range docs {
+		for _, s2 := range docs {
+			doc1, _ := parse(s1)
+			doc2, _ := parse(s2)
+			if err := CheckInvariants(doc1, doc2); err != nil {
+				t.Errorf("%s", err)
+			}
+		}
+	}
+}
+
+func TestCheckInvariantsNullFields(t *testing.T) {
+	docs := []string{`{"a":null}`, `{}`, `{"a":1,"b":[null,{"c":null}]}`, `{"b":[{"c":null},null]}`}
+	for _, s1 := range docs {
+		for _, s2 := range docs {
+			doc1, _ := parse(s1)
+			doc2, _ := parse(s2)
+			if err := CheckInvariants(doc1, doc2, ObjectFieldDeltas()); err != nil {
+				t.Errorf("%s", err)
+			}
+		}
+	}
+	doc1, _ := parse(`{"a":null}`)
+	if err := CheckInvariants(doc1, map[string]interface{}{}); err == nil {
+		t.Errorf("Expected failure without ObjectFieldDeltas")
+	}
+}
@@ -0,0 +1,80 @@
+package jsondiff
+
+// Invert returns the reverse of the deltas: applying the result to
+// the new document gives the old document. Insertions become
+// deletions, deletions become insertions, and the old and new values
+// of modifications and moves, and the keys of renames are swapped.
+// Array indexes are converted so the result uses the same conventions
+// as Difference.
+func Invert(deltas []Delta) []Delta {
+	paths := newOldPaths(deltas)
+	ret := make([]Delta, 0, len(deltas))
+	for _, d := range deltas {
+		switch x := d.(type) {
+		case Insertion:
+			ret = append(ret, Deletion{Name: paths.oldElementPath(x.Name), DeletedNode: x.NewNode})
+		case Deletion:
+			ret = append(ret, Insertion{Name: paths.oldElementPath(x.Name), NewNode: x.DeletedNode})
+		case Move:
+			ret = append(ret, Move{From: paths.oldElementPath(x.To),
+				To:  paths.oldElementPath(x.From),
+				Old: x.New,
+				New: x.Old})
+		case Rename:
+			ret = append(ret, Rename{Name: paths.oldPath(x.Name), OldKey: x.NewKey, NewKey: x.OldKey, Value: x.Value})
+		case Modification:
+			ret = append(ret, Modification{Name: paths.oldPath(x.Name), Old: x.New, New: x.Old, TextDiff: invertTextDiff(x.TextDiff), TypeChanged: x.TypeChanged})
+		default:
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+// oldPaths converts the field names in the new document to the field
+// names in the old document. The element changes of the arrays are
+// collected once
+type oldPaths struct {
+	arrays map[string]*arrayChanges
+}
+
+// newOldPaths collects the element changes of the arrays in the deltas
+func newOldPaths(deltas []Delta) oldPaths {
+	return oldPaths{arrays: collectArrayChanges(deltas)}
+}
+
+// oldElementPath converts the field name of an array element in a
+// delta so that the array field name refers to the old document. The
+// element index is not changed
+func (p oldPaths) oldElementPath(name FieldName) FieldName {
+	if len(name) == 0 {
+		return name
+	}
+	return append(p.oldPath(name[:len(name)-1]), name[len(name)-1])
+}
+
+// oldPath converts a field name in the new document to the field name
+// in the old document
+func (p oldPaths) oldPath(name FieldName) FieldName {
+	ret := make(FieldName, len(name), len(name)+1)
+	copy(ret, name)
+	for k, s := range name {
+		if !s.IsIndex {
+			continue
+		}
+		if ix, ok := p.oldArrayIndex(name[:k], s.Index); ok {
+			ret[k] = IndexSegment(ix)
+		}
+	}
+	return ret
+}
+
+// oldArrayIndex returns the index of the element at index ix of the
+// array at arrayName before the deltas are applied
+func (p oldPaths) oldArrayIndex(arrayName FieldName, ix int) (int, bool) {
+	a, ok := p.arrays[fieldKey(arrayName)]
+	if !ok {
+		return ix, true
+	}
+	return a.oldIndex(ix)
+}
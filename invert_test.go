@@ -0,0 +1,40 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func testInvert(t *testing.T, differ *Differ, s1, s2 string) {
+	doc1, err := parse(s1)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(s2)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	delta := differ.Difference(doc1, doc2)
+	inverse := Invert(delta)
+	result, err := Apply(doc2, inverse)
+	if err != nil {
+		t.Errorf("Cannot apply %v: %s", inverse, err)
+		return
+	}
+	if !IsEqual(result, doc1) {
+		t.Errorf("Wrong result: %v, expected %v, delta: %v, inverse: %v", result, doc1, delta, inverse)
+	}
+}
+
+func TestInvert(t *testing.T) {
+	differ := NewDiffer()
+	testInvert(t, differ, `{"f1":"value1","f2":2,"f4":true}`, `{"f1":"value2","f2":2,"f4":false,"f5":1}`)
+	testInvert(t, differ, `{"f1":[1,2,3,4,5,6]}`, `{"f1":[1,3,8,4,6]}`)
+	testInvert(t, differ, `{"f1":[1,2,3]}`, `{"f1":[3,2,1]}`)
+	testInvert(t, differ, `{"f1":[1,2,3]}`, `{"f1":[2,1,3,4]}`)
+	testInvert(t, differ, `{"a":1}`, `[1,2]`)
+	differ = NewDiffer(ArrayKey("users", "id"))
+	testInvert(t, differ, `{"users":[{"id":1,"tags":[1,2]},{"id":2},{"id":3,"x":1}]}`,
+		`{"users":[{"id":4},{"id":3,"x":2},{"id":1,"tags":[2,1,3],"y":1}]}`)
+}
@@ -0,0 +1,111 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ToPostgresJSONB converts deltas to a PostgreSQL expression that
+// computes the new value of the jsonb column from its current value
+// using jsonb_set, jsonb_insert and the #- operator. The paths and the
+// values are passed as parameters: the returned args are to be bound
+// to the placeholders $firstParam, $firstParam+1, ..., so the
+// expression can be embedded in a larger statement:
+//
+//	expr, args, err := ToPostgresJSONB("doc", deltas, 2)
+//	db.Exec("UPDATE docs SET doc = "+expr+" WHERE id = $1", append([]interface{}{id}, args...)...)
+//
+// The deltas are expected to be in the form returned by
+// Difference. Moved array elements and renamed fields are removed and
+// inserted again.
+func ToPostgresJSONB(column string, deltas []Delta, firstParam int) (string, []interface{}, error) {
+	var ops []jsonPatchOp
+	emit := func(op jsonPatchOp) {
+		ops = append(ops, op)
+	}
+	// Array elements are inserted with jsonb_insert, object fields are
+	// set with jsonb_set
+	arrays := make(map[string]bool)
+	converted := make([]Delta, 0, len(deltas))
+	for _, d := range expandRenames(deltas) {
+		name := d.GetField()
+		if len(name) > 0 && name[len(name)-1].IsIndex {
+			arrays[name[:len(name)-1].Pointer()] = true
+		}
+		if m, ok := d.(Move); ok {
+			converted = append(converted, Deletion{Name: m.From, DeletedNode: m.Old}, Insertion{Name: m.To, NewNode: m.Old})
+			continue
+		}
+		converted = append(converted, d)
+	}
+	if err := patchNode(FieldName{}, converted, patchConfig{mode: SequentialIndexes}, emit); err != nil {
+		return "", nil, err
+	}
+	expr := quoteIdentifier(column)
+	var args []interface{}
+	param := func(v string) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(firstParam+len(args)-1)
+	}
+	for _, op := range ops {
+		tokens, err := parsePointer(op.Path)
+		if err != nil {
+			return "", nil, err
+		}
+		var value string
+		if op.Value != nil {
+			data, err := json.Marshal(op.Value)
+			if err != nil {
+				return "", nil, fmt.Errorf("cannot encode value at %s: %w", op.Path, err)
+			}
+			value = string(data)
+		}
+		if len(tokens) == 0 {
+			if op.Op != "replace" {
+				return "", nil, fmt.Errorf("cannot %s the document", op.Op)
+			}
+			expr = param(value) + "::jsonb"
+			continue
+		}
+		parent := op.Path[:strings.LastIndex(op.Path, "/")]
+		switch {
+		case op.Op == "remove":
+			expr = fmt.Sprintf("(%s #- %s::text[])", expr, param(textArray(tokens)))
+		case op.Op == "add" && arrays[parent]:
+			expr = fmt.Sprintf("jsonb_insert(%s, %s::text[], %s::jsonb)", expr, param(textArray(tokens)), param(value))
+		case op.Op == "add":
+			expr = fmt.Sprintf("jsonb_set(%s, %s::text[], %s::jsonb, true)", expr, param(textArray(tokens)), param(value))
+		case op.Op == "replace":
+			expr = fmt.Sprintf("jsonb_set(%s, %s::text[], %s::jsonb, false)", expr, param(textArray(tokens)), param(value))
+		default:
+			return "", nil, fmt.Errorf("unsupported operation %s at %s", op.Op, op.Path)
+		}
+	}
+	return expr, args, nil
+}
+
+// quoteIdentifier quotes a PostgreSQL identifier
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+var textArrayEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// textArray returns the PostgreSQL text array literal for the path
+// elements
+func textArray(tokens []string) string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, t := range tokens {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('"')
+		sb.WriteString(textArrayEscaper.Replace(t))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
@@ -0,0 +1,29 @@
+package jsondiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToPostgresJSONB(t *testing.T) {
+	doc1, _ := parse(`{"a":1,"b":"x","c":[1,2,3],"d":null}`)
+	doc2, _ := parse(`{"a":2,"c":[3,1,4],"d":{"e":1},"f\"":[]}`)
+	expr, args, err := ToPostgresJSONB("doc", Difference(doc1, doc2), 2)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected := `jsonb_set(jsonb_set(jsonb_insert(jsonb_insert((((jsonb_set("doc", $2::text[], $3::jsonb, false) #- $4::text[]) #- $5::text[]) #- $6::text[]), $7::text[], $8::jsonb), $9::text[], $10::jsonb), $11::text[], $12::jsonb, true), $13::text[], $14::jsonb, true)`
+	if expr != expected {
+		t.Errorf("Wrong expression: %s", expr)
+	}
+	expectedArgs := []interface{}{`{"a"}`, `2`, `{"b"}`, `{"c","2"}`, `{"c","1"}`, `{"c","0"}`, `3`, `{"c","2"}`, `4`, `{"d"}`, `{"e":1}`, `{"f\""}`, `[]`}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Wrong args: %v", args)
+	}
+
+	expr, args, _ = ToPostgresJSONB("doc", Difference(1.0, "x"), 1)
+	if expr != `$1::jsonb` || !reflect.DeepEqual(args, []interface{}{`"x"`}) {
+		t.Errorf("Wrong expression: %s %v", expr, args)
+	}
+}
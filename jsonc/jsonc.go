@@ -0,0 +1,136 @@
+// Package jsonc computes differences between JSON documents with
+// comments and trailing commas, such as tsconfig.json and VS Code
+// settings files, using the jsondiff engine. Line comments (//) and
+// block comments (/* */) outside strings, and commas before a closing
+// bracket or brace are removed before the documents are parsed.
+//
+// Importing this package registers the JSONC format, so these
+// documents can be compared with other documents using
+// jsondiff.DifferenceAny.
+package jsonc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+// JSONC is the JSON with comments document format
+const JSONC jsondiff.Format = "jsonc"
+
+func init() {
+	jsondiff.RegisterFormat(JSONC, Unmarshal)
+}
+
+// JSONCDifference computes difference between two JSON documents that
+// may contain comments and trailing commas. If one of the documents
+// cannot be parsed, the returned error is a *jsondiff.ParseError. The
+// offsets of the parse errors are the offsets in the original
+// documents.
+func JSONCDifference(doc1, doc2 []byte, opts ...jsondiff.Option) ([]jsondiff.Delta, error) {
+	n1, err := Unmarshal(doc1)
+	if err != nil {
+		return nil, parseError(1, err)
+	}
+	n2, err := Unmarshal(doc2)
+	if err != nil {
+		return nil, parseError(2, err)
+	}
+	return jsondiff.NewDiffer(opts...).Difference(n1, n2), nil
+}
+
+// Unmarshal parses a JSON document that may contain comments and
+// trailing commas
+func Unmarshal(doc []byte) (interface{}, error) {
+	data, err := Strip(doc)
+	if err != nil {
+		return nil, err
+	}
+	var node interface{}
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// Strip returns a copy of doc in which comments and trailing commas are
+// replaced by spaces. Line breaks in block comments are kept, so the
+// offsets and the line numbers of the remaining text do not change. It
+// fails if a block comment or a string is not terminated.
+func Strip(doc []byte) ([]byte, error) {
+	ret := make([]byte, len(doc))
+	copy(ret, doc)
+	// comma is the offset of the last comma that may be a trailing
+	// comma, or -1
+	comma := -1
+	for i := 0; i < len(ret); i++ {
+		switch c := ret[i]; {
+		case c == '"':
+			comma = -1
+			end, err := stringEnd(ret, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case c == '/' && i+1 < len(ret) && ret[i+1] == '/':
+			for ; i < len(ret) && ret[i] != '\n'; i++ {
+				ret[i] = ' '
+			}
+		case c == '/' && i+1 < len(ret) && ret[i+1] == '*':
+			start := i
+			for i += 2; i+1 < len(ret) && !(ret[i] == '*' && ret[i+1] == '/'); i++ {
+			}
+			if i+1 >= len(ret) {
+				return nil, fmt.Errorf("unterminated comment at offset %d", start)
+			}
+			i++
+			blank(ret[start : i+1])
+		case c == ',':
+			comma = i
+		case c == '}' || c == ']':
+			if comma != -1 {
+				ret[comma] = ' '
+			}
+			comma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			comma = -1
+		}
+	}
+	return ret, nil
+}
+
+// stringEnd returns the offset of the quote that ends the string
+// starting at offset start
+func stringEnd(data []byte, start int) (int, error) {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+// blank replaces the bytes of a comment with spaces, keeping the line
+// breaks
+func blank(data []byte) {
+	for i, c := range data {
+		if c != '\n' && c != '\r' {
+			data[i] = ' '
+		}
+	}
+}
+
+// parseError returns a *jsondiff.ParseError for the document. Offsets
+// are kept, because Strip does not move the text
+func parseError(doc int, err error) *jsondiff.ParseError {
+	offset := int64(-1)
+	if e, ok := err.(*json.SyntaxError); ok {
+		offset = e.Offset
+	}
+	return &jsondiff.ParseError{Doc: doc, Offset: offset, Err: err}
+}
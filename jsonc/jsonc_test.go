@@ -0,0 +1,57 @@
+package jsonc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+func TestJSONCDifference(t *testing.T) {
+	doc1 := []byte(`{
+  // Compiler options
+  "compilerOptions": {
+    "target": "es2017", /* the default */
+    "paths": ["src/*", "lib/*",],
+    "url": "http://example.com//x", // not a comment in a string
+  },
+}`)
+	doc2 := []byte(`{
+  "compilerOptions": {
+    /* changed
+       target */
+    "target": "es2020",
+    "paths": ["src/*", "lib/*"],
+    "url": "http://example.com//x"
+  }
+}`)
+	deltas, err := JSONCDifference(doc1, doc2)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	if len(deltas) != 1 {
+		t.Errorf("Unexpected diff: %v", deltas)
+		return
+	}
+	m, ok := deltas[0].(jsondiff.Modification)
+	if !ok || m.Name.String() != "compilerOptions/target" || m.New != "es2020" {
+		t.Errorf("Wrong delta: %v", deltas[0])
+	}
+
+	data, err := Strip([]byte(`{"a":"x\"//",/*c*/"b":[1,],}`))
+	if err != nil || string(data) != `{"a":"x\"//",     "b":[1 ] }` {
+		t.Errorf("Wrong strip: %q %v", data, err)
+	}
+
+	_, err = JSONCDifference(doc1, []byte(`{"a":1 /* x`))
+	var perr *jsondiff.ParseError
+	if !errors.As(err, &perr) || perr.Doc != 2 {
+		t.Errorf("Expected parse error, got %v", err)
+	}
+	_, err = JSONCDifference([]byte(`{"a":1,
+  "b": x}`), doc2)
+	if !errors.As(err, &perr) || perr.Doc != 1 || perr.Offset < 14 {
+		t.Errorf("Expected parse error, got %v", err)
+	}
+}
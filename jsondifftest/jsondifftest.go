@@ -0,0 +1,49 @@
+// Package jsondifftest provides test assertions that compare JSON
+// documents using jsondiff, and report the differences in a readable
+// form.
+package jsondifftest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bserdar/jsondiff/v2"
+	"github.com/bserdar/jsondiff/v2/render"
+)
+
+// AssertJSONEqual compares the JSON documents want and got, and fails
+// the test with the rendered differences if they are not equal. The
+// options configure the comparison, for example to ignore the order
+// of array elements. It returns whether the documents are equal.
+func AssertJSONEqual(t testing.TB, want, got []byte, opts ...jsondiff.Option) bool {
+	t.Helper()
+	wantNode, err := jsondiff.Decode(want, jsondiff.JSON)
+	if err != nil {
+		t.Errorf("cannot parse expected document: %s", err)
+		return false
+	}
+	gotNode, err := jsondiff.Decode(got, jsondiff.JSON)
+	if err != nil {
+		t.Errorf("cannot parse actual document: %s", err)
+		return false
+	}
+	return AssertEqual(t, wantNode, gotNode, opts...)
+}
+
+// AssertEqual compares two documents decoded into the jsondiff node
+// model, and fails the test with the rendered differences if they are
+// not equal. It returns whether the documents are equal.
+func AssertEqual(t testing.TB, want, got interface{}, opts ...jsondiff.Option) bool {
+	t.Helper()
+	deltas := jsondiff.NewDiffer(opts...).Difference(want, got)
+	if len(deltas) == 0 {
+		return true
+	}
+	var buf strings.Builder
+	if err := render.RenderText(&buf, deltas, render.RenderOptions{Doc: want, Context: 1}); err != nil {
+		t.Errorf("documents are not equal (%s), cannot render the differences: %s", jsondiff.Summarize(deltas), err)
+		return false
+	}
+	t.Errorf("documents are not equal (%s):\n%s", jsondiff.Summarize(deltas), buf.String())
+	return false
+}
@@ -0,0 +1,45 @@
+package jsondifftest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+// recorder records the errors reported by an assertion
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertJSONEqual(t *testing.T) {
+	r := &recorder{}
+	if !AssertJSONEqual(r, []byte(`{"a":1,"b":[1,2]}`), []byte(`{"b":[1,2],"a":1}`)) || len(r.errors) != 0 {
+		t.Errorf("Unexpected failure: %v", r.errors)
+	}
+
+	r = &recorder{}
+	if AssertJSONEqual(r, []byte(`{"a":1,"b":[1,2]}`), []byte(`{"a":2,"b":[1,2]}`)) || len(r.errors) != 1 {
+		t.Errorf("Expected failure: %v", r.errors)
+	} else if !strings.Contains(r.errors[0], "~ a: 1 -> 2") {
+		t.Errorf("Wrong message: %s", r.errors[0])
+	}
+
+	r = &recorder{}
+	if !AssertJSONEqual(r, []byte(`{"b":[1,2]}`), []byte(`{"b":[2,1]}`), jsondiff.ArraysAsSets()) {
+		t.Errorf("Unexpected failure: %v", r.errors)
+	}
+
+	r = &recorder{}
+	if AssertJSONEqual(r, []byte(`{`), []byte(`{}`)) || len(r.errors) != 1 {
+		t.Errorf("Expected parse failure: %v", r.errors)
+	}
+}
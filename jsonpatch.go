@@ -0,0 +1,266 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPatchOp is a single RFC 6902 JSON patch operation
+type jsonPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// DiffAfterPatch applies the RFC 6902 JSON patch to doc in memory,
+// and returns the differences between doc and the patched
+// document. This can be used to review a patch before applying it. If
+// doc or patch cannot be parsed, the returned error is a *ParseError.
+func DiffAfterPatch(doc []byte, patch []byte) ([]Delta, error) {
+	var original interface{}
+	if err := json.Unmarshal(doc, &original); err != nil {
+		return nil, newParseError(1, -1, err)
+	}
+	return FromJSONPatch(original, patch)
+}
+
+// FromJSONPatch converts the RFC 6902 JSON patch into deltas against
+// doc, so the patch can be inspected, inverted, and rendered like a
+// computed difference. The deltas are computed by applying the patch
+// to a copy of doc, so they describe the changes the patch makes, not
+// the individual patch operations. If the patch cannot be parsed, the
+// returned error is a *ParseError.
+func FromJSONPatch(doc interface{}, patch []byte) ([]Delta, error) {
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, newParseError(2, -1, err)
+	}
+	patched, err := applyJSONPatch(deepCopy(doc), ops)
+	if err != nil {
+		return nil, err
+	}
+	return Difference(doc, patched), nil
+}
+
+// applyJSONPatch applies the patch operations to doc. doc is modified
+func applyJSONPatch(doc interface{}, ops []jsonPatchOp) (interface{}, error) {
+	var err error
+	for i, op := range ops {
+		doc, err = applyJSONPatchOp(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("patch operation %d (%s %s): %s", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyJSONPatchOp(doc interface{}, op jsonPatchOp) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add":
+		return patchAdd(doc, path, op.Value)
+	case "remove":
+		return patchRemove(doc, path)
+	case "replace":
+		if _, err := pointerGet(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return op.Value, nil
+		}
+		if doc, err = patchRemove(doc, path); err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, path, op.Value)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return patchAdd(doc, path, deepCopy(value))
+		}
+		if len(from) < len(path) && strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move %s into itself", op.From)
+		}
+		if doc, err = patchRemove(doc, from); err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, path, value)
+	case "test":
+		value, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !IsEqual(value, op.Value) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation: %s", op.Op)
+}
+
+// parsePointer parses an RFC 6901 JSON pointer into unescaped
+// reference tokens
+func parsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer: %s", ptr)
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = pointerUnescaper.Replace(t)
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses a JSON pointer token as an index to an array of
+// length n. If allowEnd is set, n and "-" are accepted as the index
+// past the last element
+func arrayIndex(token string, n int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return n, nil
+	}
+	ix, err := strconv.Atoi(token)
+	if err != nil || ix < 0 || (token != "0" && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index: %s", token)
+	}
+	if ix > n || (ix == n && !allowEnd) {
+		return 0, fmt.Errorf("array index out of range: %s", token)
+	}
+	return ix, nil
+}
+
+// pointerGet returns the node at path
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch n := doc.(type) {
+		case map[string]interface{}:
+			v, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("field does not exist: %s", token)
+			}
+			doc = v
+		case []interface{}:
+			ix, err := arrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = n[ix]
+		default:
+			return nil, fmt.Errorf("cannot access %s: not a container", token)
+		}
+	}
+	return doc, nil
+}
+
+// patchContainer calls op with the parent container of path, and the
+// last token of path. op returns the new container, which replaces
+// the old one in its parent
+func patchContainer(doc interface{}, path []string, op func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return op(doc, path[0])
+	}
+	switch n := doc.(type) {
+	case map[string]interface{}:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("field does not exist: %s", path[0])
+		}
+		newChild, err := patchContainer(child, path[1:], op)
+		if err != nil {
+			return nil, err
+		}
+		n[path[0]] = newChild
+		return n, nil
+	case []interface{}:
+		ix, err := arrayIndex(path[0], len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := patchContainer(n[ix], path[1:], op)
+		if err != nil {
+			return nil, err
+		}
+		n[ix] = newChild
+		return n, nil
+	}
+	return nil, fmt.Errorf("cannot access %s: not a container", path[0])
+}
+
+func patchAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return patchContainer(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch n := container.(type) {
+		case map[string]interface{}:
+			n[token] = value
+			return n, nil
+		case []interface{}:
+			ix, err := arrayIndex(token, len(n), true)
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[ix+1:], n[ix:])
+			n[ix] = value
+			return n, nil
+		}
+		return nil, fmt.Errorf("cannot add %s: not a container", token)
+	})
+}
+
+func patchRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the document root")
+	}
+	return patchContainer(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch n := container.(type) {
+		case map[string]interface{}:
+			if _, ok := n[token]; !ok {
+				return nil, fmt.Errorf("field does not exist: %s", token)
+			}
+			delete(n, token)
+			return n, nil
+		case []interface{}:
+			ix, err := arrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(n[:ix], n[ix+1:]...), nil
+		}
+		return nil, fmt.Errorf("cannot remove %s: not a container", token)
+	})
+}
+
+// deepCopy returns a copy of the node with all the containers copied
+func deepCopy(node interface{}) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			ret[k] = deepCopy(v)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(n))
+		for i, v := range n {
+			ret[i] = deepCopy(v)
+		}
+		return ret
+	}
+	return node
+}
@@ -0,0 +1,53 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestDiffAfterPatch(t *testing.T) {
+	doc := []byte(`{"a":1,"b":{"c":[1,2,3]},"d":"x"}`)
+	patch := []byte(`[
+{"op":"test","path":"/a","value":1},
+{"op":"replace","path":"/a","value":2},
+{"op":"add","path":"/b/c/-","value":4},
+{"op":"remove","path":"/b/c/0"},
+{"op":"copy","from":"/d","path":"/e"},
+{"op":"move","from":"/d","path":"/b/d"}
+]`)
+	delta, err := DiffAfterPatch(doc, patch)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	doc1, _ := parse(string(doc))
+	doc2, _ := parse(`{"a":2,"b":{"c":[2,3,4],"d":"x"},"e":"x"}`)
+	result, err := Apply(doc1, delta)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	if !IsEqual(result, doc2) {
+		t.Errorf("Wrong deltas: %v", delta)
+	}
+}
+
+func TestDiffAfterPatchErrors(t *testing.T) {
+	doc := []byte(`{"a":1,"b":[1]}`)
+	patches := []string{
+		`[{"op":"test","path":"/a","value":2}]`,
+		`[{"op":"remove","path":"/x"}]`,
+		`[{"op":"add","path":"/b/5","value":2}]`,
+		`[{"op":"replace","path":"/x/y","value":2}]`,
+		`[{"op":"move","from":"/b","path":"/b/0"}]`,
+		`[{"op":"unknown","path":"/a"}]`,
+	}
+	for _, p := range patches {
+		if _, err := DiffAfterPatch(doc, []byte(p)); err == nil {
+			t.Errorf("Expected error for %s", p)
+		}
+	}
+	_, err := DiffAfterPatch(doc, []byte(`{`))
+	if perr, ok := err.(*ParseError); !ok || perr.Doc != 2 {
+		t.Errorf("Wrong error: %v", err)
+	}
+}
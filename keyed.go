@@ -0,0 +1,169 @@
+package jsondiff
+
+import "strconv"
+
+// arrayKey returns the key field for the array at fieldName, if the
+// elements of the array are matched by key
+func (d *Differ) arrayKey(fieldName FieldName) (string, bool) {
+	if m, ok := d.arrayMatcher(fieldName); ok {
+		if k, ok := m.(keyMatcher); ok {
+			return k.key, true
+		}
+	}
+	return "", false
+}
+
+// elementKey returns the key of an array element as a string that
+// can be used as a map key
+func elementKey(node interface{}, key string) (string, bool) {
+	obj, ok := node.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	v, ok := obj[key]
+	if !ok {
+		return "", false
+	}
+	// Strings and numbers are the common keys, and do not need to be
+	// hashed. The prefixes keep them apart from each other and from
+	// the hex hashes
+	switch k := v.(type) {
+	case string:
+		return "$s" + k, true
+	case float64:
+		if k == 0 {
+			// -0 == 0
+			k = 0
+		}
+		return "$d" + strconv.FormatFloat(k, 'g', -1, 64), true
+	}
+	return StableHash(v), true
+}
+
+// keyBasedEquivalence returns a function that matches array elements
+// using the key field. Elements without a key are matched using
+// computeEq
+func (d *Differ) keyBasedEquivalence(key string, computeEq func(node1, node2 []interface{}) dualMap) func(node1, node2 []interface{}) dualMap {
+	return func(node1, node2 []interface{}) dualMap {
+		equivalence := newDualMap(len(node1), len(node2))
+		keyIndex := make(map[string]int)
+		var keyless2 []int
+		for j, n := range node2 {
+			if k, ok := elementKey(n, key); ok {
+				if _, dup := keyIndex[k]; !dup {
+					keyIndex[k] = j
+				}
+			} else {
+				keyless2 = append(keyless2, j)
+			}
+		}
+		var keyless1 []int
+		for i, n := range node1 {
+			if k, ok := elementKey(n, key); ok {
+				if j, ok := keyIndex[k]; ok {
+					equivalence.insert(i, j)
+					delete(keyIndex, k)
+				}
+			} else {
+				keyless1 = append(keyless1, i)
+			}
+		}
+		if len(keyless1) > 0 && len(keyless2) > 0 {
+			values1 := make([]interface{}, len(keyless1))
+			for i, ix := range keyless1 {
+				values1[i] = node1[ix]
+			}
+			values2 := make([]interface{}, len(keyless2))
+			for j, ix := range keyless2 {
+				values2[j] = node2[ix]
+			}
+			eq := computeEq(values1, values2)
+			for i, ix := range keyless1 {
+				if j := eq.getNewIndex(i); j != -1 {
+					equivalence.insert(ix, keyless2[j])
+				}
+			}
+		}
+		return equivalence
+	}
+}
+
+// EntityChange contains the changes to an element of an array
+// matched using ArrayKey
+type EntityChange struct {
+	// Array is the field name of the array containing the element
+	Array FieldName
+	// Key is the value of the key field of the element
+	Key interface{}
+	// Deltas are all the changes to the element, including its
+	// insertion, deletion, or move
+	Deltas []Delta
+}
+
+// EntityChanges computes the difference between two documents, and
+// groups the deltas by the array elements they belong to. Only the
+// elements of arrays configured with ArrayKey are reported, and a
+// delta belongs to the innermost such element containing it. Deltas
+// that do not belong to an element are not returned. The changes of
+// an element are grouped together regardless of how its index
+// changes.
+func (d *Differ) EntityChanges(node1, node2 interface{}) []EntityChange {
+	var ret []EntityChange
+	index := make(map[string]int)
+	for _, delta := range d.Difference(node1, node2) {
+		array, key, ok := d.deltaEntity(delta, node2)
+		if !ok {
+			continue
+		}
+		id := array.String() + "\x00" + StableHash(key)
+		i, ok := index[id]
+		if !ok {
+			i = len(ret)
+			index[id] = i
+			ret = append(ret, EntityChange{Array: array, Key: key})
+		}
+		ret[i].Deltas = append(ret[i].Deltas, delta)
+	}
+	return ret
+}
+
+// deltaEntity returns the keyed array and the key of the element
+// the delta belongs to
+func (d *Differ) deltaEntity(delta Delta, node2 interface{}) (FieldName, interface{}, bool) {
+	name := delta.GetField()
+	for k := len(name) - 1; k >= 0; k-- {
+		if !name[k].IsIndex {
+			continue
+		}
+		key, ok := d.arrayKey(name[:k])
+		if !ok {
+			continue
+		}
+		var element interface{}
+		if k == len(name)-1 {
+			// The delta is for the element itself
+			switch x := delta.(type) {
+			case Insertion:
+				element = x.NewNode
+			case Deletion:
+				element = x.DeletedNode
+			case Move:
+				element = x.New
+			case Modification:
+				element = x.New
+			}
+		} else {
+			element, _ = lookup(node2, name[:k+1])
+		}
+		obj, ok := element.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		value, ok := obj[key]
+		if !ok {
+			continue
+		}
+		return name[:k], value, true
+	}
+	return nil, nil, false
+}
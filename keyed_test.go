@@ -0,0 +1,92 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestArrayKey(t *testing.T) {
+	doc1, err := parse(`{"f1":[{"_id":"1","a":"b","c":1},{"_id":"2","a":"e","c":2},{"_id":"3","a":"x"}]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"f1":[{"_id":"2","a":"e","c":3},{"a":"new"},{"_id":"1","a":"b","c":1}]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	differ := NewDiffer(ArrayKey("f1", "_id"))
+	delta := differ.Difference(doc1, doc2)
+	result, err := Apply(doc1, delta)
+	if err != nil {
+		t.Errorf("Cannot apply: %s", err)
+		return
+	}
+	if !IsEqual(result, doc2) {
+		t.Errorf("Wrong result: %v, delta: %v", result, delta)
+	}
+	found := false
+	for _, d := range delta {
+		if m, ok := d.(Modification); ok {
+			if m.Name.String() != "f1/0/c" || m.Old.(float64) != 2 || m.New.(float64) != 3 {
+				t.Errorf("Wrong modification: %v", m)
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected modification: %v", delta)
+	}
+}
+
+func TestEntityChanges(t *testing.T) {
+	doc1, err := parse(`{"users":[{"id":1,"email":"a","tags":["x"]},{"id":2,"email":"b"},{"id":3,"email":"c"}]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"users":[{"id":3,"email":"c"},{"id":1,"email":"aa","tags":["x","y"]},{"id":4,"email":"d"}]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	changes := NewDiffer(ArrayKey("users", "id")).EntityChanges(doc1, doc2)
+	byKey := make(map[float64]EntityChange)
+	for _, c := range changes {
+		if c.Array.String() != "users" {
+			t.Errorf("Wrong array: %v", c)
+		}
+		byKey[c.Key.(float64)] = c
+	}
+	if len(byKey) != 4 {
+		t.Errorf("Wrong changes: %v", changes)
+		return
+	}
+	if len(byKey[1].Deltas) != 2 {
+		t.Errorf("Wrong changes for 1: %v", byKey[1])
+	}
+	if len(byKey[2].Deltas) != 1 || byKey[2].Deltas[0].GetType() != DiffDel {
+		t.Errorf("Wrong changes for 2: %v", byKey[2])
+	}
+	if len(byKey[3].Deltas) != 1 || byKey[3].Deltas[0].GetType() != DiffMove {
+		t.Errorf("Wrong changes for 3: %v", byKey[3])
+	}
+	if len(byKey[4].Deltas) != 1 || byKey[4].Deltas[0].GetType() != DiffIns {
+		t.Errorf("Wrong changes for 4: %v", byKey[4])
+	}
+}
+
+func TestEntityChangesRoot(t *testing.T) {
+	doc1, _ := parse(`[{"id":1,"v":1},{"id":2,"v":1}]`)
+	doc2, _ := parse(`[{"id":2,"v":1},{"id":1,"v":2}]`)
+	changes := NewDiffer(ArrayKey("", "id")).EntityChanges(doc1, doc2)
+	if len(changes) != 2 {
+		t.Errorf("Wrong changes: %v", changes)
+		return
+	}
+	for _, c := range changes {
+		if len(c.Array) != 0 {
+			t.Errorf("Wrong array: %v", c)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// KeyedSegment is a segment of a KeyedPath. It is either an object
+// key, or an array element selected by the value of its identity
+// field. Elements without an identity are selected by a wildcard.
+type KeyedSegment struct {
+	// Key is the object key, if IsElement is not set
+	Key string
+	// IsElement is set for array elements
+	IsElement bool
+	// IDField is the identity field of the element. It is empty for
+	// a wildcard
+	IDField string
+	// ID is the value of the identity field of the element
+	ID interface{}
+}
+
+// KeyedPath is a field name where array indexes are replaced by the
+// identity of the array elements, so it does not change when the
+// elements of an array are reordered. Its string form is like
+// users[id=42]/email, or users[*]/email for elements without an
+// identity.
+type KeyedPath []KeyedSegment
+
+// keyedKeyEscaper escapes object keys in keyed paths. "[" is escaped
+// so element selectors are unambiguous
+var keyedKeyEscaper = strings.NewReplacer("~", "~0", "/", "~1", "[", "~2")
+
+// String returns the keyed path in the form users[id=42]/email.
+// Object keys are escaped as in a JSON pointer, and "[" in keys is
+// escaped as "~2". Identity values are written as JSON, except
+// strings that are written as is if they cannot be confused with
+// other values.
+func (p KeyedPath) String() string {
+	var sb strings.Builder
+	for i, s := range p {
+		if !s.IsElement {
+			if i > 0 {
+				sb.WriteByte('/')
+			}
+			sb.WriteString(keyedKeyEscaper.Replace(s.Key))
+			continue
+		}
+		if s.IDField == "" {
+			sb.WriteString("[*]")
+			continue
+		}
+		sb.WriteByte('[')
+		sb.WriteString(keyedText(s.IDField))
+		sb.WriteByte('=')
+		sb.WriteString(keyedText(s.ID))
+		sb.WriteByte(']')
+	}
+	return sb.String()
+}
+
+// keyedText returns the text of an identity field name or value in a
+// keyed path
+func keyedText(v interface{}) string {
+	s, ok := v.(string)
+	if ok && s != "" && !strings.ContainsAny(s, `[]="/`) {
+		var x interface{}
+		if json.Unmarshal([]byte(s), &x) != nil {
+			// Not a JSON literal or number
+			return s
+		}
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// KeyedPathOf returns the keyed path for the field name in doc. Array
+// elements are identified by the first of idFields that the element
+// has, if its value is a string, number, or boolean that is unique in
+// the array. Other elements are written as wildcards.
+func KeyedPathOf(doc interface{}, name FieldName, idFields ...string) KeyedPath {
+	ret := make(KeyedPath, len(name))
+	node := doc
+	for i, s := range name {
+		if !s.IsIndex {
+			ret[i] = KeyedSegment{Key: s.Key}
+			node, _ = lookup(node, FieldName{s})
+			continue
+		}
+		ret[i] = KeyedSegment{IsElement: true}
+		arr, _ := node.([]interface{})
+		node = nil
+		if s.Index < 0 || s.Index >= len(arr) {
+			continue
+		}
+		node = arr[s.Index]
+		if field, id, ok := elementIdentity(arr, s.Index, idFields); ok {
+			ret[i].IDField = field
+			ret[i].ID = id
+		}
+	}
+	return ret
+}
+
+// elementIdentity returns the identity field and value of the element
+// at index ix of arr
+func elementIdentity(arr []interface{}, ix int, idFields []string) (string, interface{}, bool) {
+	obj, ok := arr[ix].(map[string]interface{})
+	if !ok {
+		return "", nil, false
+	}
+	for _, field := range idFields {
+		id, ok := obj[field]
+		if !ok {
+			continue
+		}
+		switch id.(type) {
+		case string, float64, bool, json.Number:
+		default:
+			return "", nil, false
+		}
+		for j, elem := range arr {
+			if j == ix {
+				continue
+			}
+			if other, ok := elem.(map[string]interface{}); ok && IsEqual(other[field], id) {
+				return "", nil, false
+			}
+		}
+		return field, id, true
+	}
+	return "", nil, false
+}
+
+// KeyedPaths returns the keyed path of each delta, as returned by
+// GetField. The paths of deletions are resolved in the old document,
+// and the others in the new document.
+func KeyedPaths(oldDoc, newDoc interface{}, deltas []Delta, idFields ...string) []KeyedPath {
+	ret := make([]KeyedPath, len(deltas))
+	paths := newOldPaths(deltas)
+	for i, d := range deltas {
+		if x, ok := d.(Deletion); ok {
+			ret[i] = KeyedPathOf(oldDoc, paths.oldElementPath(x.Name), idFields...)
+			continue
+		}
+		ret[i] = KeyedPathOf(newDoc, d.GetField(), idFields...)
+	}
+	return ret
+}
+
+var keyedKeyUnescaper = strings.NewReplacer("~1", "/", "~2", "[", "~0", "~")
+
+// ParseKeyedPath parses a keyed path written by KeyedPath.String
+func ParseKeyedPath(s string) (KeyedPath, error) {
+	var ret KeyedPath
+	pos := 0
+	for pos < len(s) {
+		switch {
+		case s[pos] == '[':
+			end, seg, err := parseKeyedSelector(s, pos)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, seg)
+			pos = end
+		case s[pos] == '/' && pos > 0 && pos+1 < len(s) && s[pos+1] != '/' && s[pos+1] != '[':
+			pos++
+		case s[pos] == '/':
+			return nil, fmt.Errorf("invalid keyed path %q: empty key at %d", s, pos)
+		default:
+			end := strings.IndexAny(s[pos:], "/[")
+			if end == -1 {
+				end = len(s)
+			} else {
+				end += pos
+			}
+			if pos > 0 && s[pos-1] != '/' {
+				return nil, fmt.Errorf("invalid keyed path %q: expected / at %d", s, pos)
+			}
+			ret = append(ret, KeyedSegment{Key: keyedKeyUnescaper.Replace(s[pos:end])})
+			pos = end
+		}
+	}
+	return ret, nil
+}
+
+// parseKeyedSelector parses the element selector starting at s[pos],
+// and returns the position after the selector
+func parseKeyedSelector(s string, pos int) (int, KeyedSegment, error) {
+	if strings.HasPrefix(s[pos:], "[*]") {
+		return pos + 3, KeyedSegment{IsElement: true}, nil
+	}
+	field, pos, err := parseKeyedText(s, pos+1, '=')
+	if err != nil {
+		return 0, KeyedSegment{}, err
+	}
+	id, pos, err := parseKeyedText(s, pos+1, ']')
+	if err != nil {
+		return 0, KeyedSegment{}, err
+	}
+	fieldName, ok := field.(string)
+	if !ok || fieldName == "" {
+		return 0, KeyedSegment{}, fmt.Errorf("invalid keyed path %q: invalid identity field", s)
+	}
+	return pos + 1, KeyedSegment{IsElement: true, IDField: fieldName, ID: id}, nil
+}
+
+// parseKeyedText parses an identity field name or value written by
+// keyedText, starting at s[pos] and ending at the terminator. It
+// returns the value and the position of the terminator
+func parseKeyedText(s string, pos int, terminator byte) (interface{}, int, error) {
+	end := -1
+	if pos < len(s) && s[pos] == '"' {
+		// Find the closing quote
+		for i := pos + 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] == '"' {
+				end = i + 1
+				break
+			}
+		}
+	} else if i := strings.IndexByte(s[pos:], terminator); i > 0 {
+		end = pos + i
+	}
+	if end == -1 || end >= len(s) || s[end] != terminator {
+		return nil, 0, fmt.Errorf("invalid keyed path %q: expected %c", s, terminator)
+	}
+	text := s[pos:end]
+	var v interface{}
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		if text[0] == '"' {
+			return nil, 0, fmt.Errorf("invalid keyed path %q: %w", s, err)
+		}
+		return text, end, nil
+	}
+	return v, end, nil
+}
+
+// Resolve returns the field name of the node at the keyed path in
+// doc. Elements selected by identity are searched in their arrays.
+// Wildcard elements are resolved using the index at the same position
+// in fallback, if fallback has the same length as p.
+func (p KeyedPath) Resolve(doc interface{}, fallback FieldName) (FieldName, error) {
+	ret := make(FieldName, len(p))
+	node := doc
+	for i, s := range p {
+		if !s.IsElement {
+			ret[i] = KeySegment(s.Key)
+			node, _ = lookup(node, FieldName{ret[i]})
+			continue
+		}
+		arr, ok := node.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: not an array", pathText(ret[:i]))
+		}
+		ix, err := s.elementIndex(arr)
+		if err != nil {
+			if len(fallback) != len(p) || !fallback[i].IsIndex {
+				return nil, fmt.Errorf("%s: %w", KeyedPath(p[:i+1]), err)
+			}
+			ix = fallback[i].Index
+		}
+		ret[i] = IndexSegment(ix)
+		node = nil
+		if ix < len(arr) {
+			node = arr[ix]
+		}
+	}
+	return ret, nil
+}
+
+// elementIndex returns the index of the element selected by s in arr
+func (s KeyedSegment) elementIndex(arr []interface{}) (int, error) {
+	if s.IDField == "" {
+		return 0, fmt.Errorf("wildcard element")
+	}
+	for i, elem := range arr {
+		if obj, ok := elem.(map[string]interface{}); ok {
+			if id, ok := obj[s.IDField]; ok && IsEqual(id, s.ID) {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("element not found")
+}
+
+// ApplyKeyed applies deltas addressed by keyed paths to doc, so the
+// deltas can be applied to a copy of the document whose arrays are
+// reordered. paths[i] is the keyed path of deltas[i], as returned by
+// KeyedPaths. Each delta is applied in order to the result of the
+// previous ones, and its path is resolved using Resolve with the
+// delta field name as the fallback:
+//
+//   - A Modification replaces the resolved node. A nil New value
+//     removes an object field, unless SetNullFields is given.
+//   - A Deletion removes the resolved node.
+//   - An Insertion inserts an array element at the index of the
+//     delta, or at the end of the array if it is shorter, or adds an
+//     object field. It fails if the element already exists.
+//   - A Move moves the element to the index of the delta, or to the
+//     end of the array if it is shorter.
+//
+// doc is not modified.
+func ApplyKeyed(doc interface{}, deltas []Delta, paths []KeyedPath, opts ...ApplyOption) (interface{}, error) {
+	if len(deltas) != len(paths) {
+		return nil, fmt.Errorf("%d deltas, %d paths", len(deltas), len(paths))
+	}
+	a := applier{}
+	for _, opt := range opts {
+		opt(&a)
+	}
+	doc = deepCopy(doc)
+	for i, d := range deltas {
+		op, err := a.keyedPatchOp(doc, d, paths[i])
+		if err == nil {
+			doc, err = applyJSONPatchOp(doc, op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", paths[i], err)
+		}
+	}
+	return doc, nil
+}
+
+// keyedPatchOp returns the JSON patch operation for a delta addressed
+// by a keyed path
+func (a applier) keyedPatchOp(doc interface{}, d Delta, path KeyedPath) (jsonPatchOp, error) {
+	name := d.GetField()
+	if x, ok := d.(Insertion); ok && len(path) > 0 && path[len(path)-1].IsElement {
+		parent, err := path[:len(path)-1].Resolve(doc, name[:len(name)-1])
+		if err != nil {
+			return jsonPatchOp{}, err
+		}
+		arr, _ := lookup(doc, parent)
+		elems, ok := arr.([]interface{})
+		if !ok {
+			return jsonPatchOp{}, fmt.Errorf("not an array")
+		}
+		if _, err := path[len(path)-1].elementIndex(elems); err == nil {
+			return jsonPatchOp{}, fmt.Errorf("element already exists")
+		}
+		return jsonPatchOp{Op: "add", Path: parent.child(IndexSegment(clampIndex(name, len(elems)))).Pointer(), Value: x.NewNode}, nil
+	}
+	if x, ok := d.(Move); ok {
+		from, err := path.Resolve(doc, x.From)
+		if err != nil {
+			return jsonPatchOp{}, err
+		}
+		arr, _ := lookup(doc, from[:len(from)-1])
+		elems, _ := arr.([]interface{})
+		to := from[:len(from)-1].child(IndexSegment(clampIndex(name, len(elems)-1)))
+		return jsonPatchOp{Op: "move", From: from.Pointer(), Path: to.Pointer()}, nil
+	}
+	if x, ok := d.(Rename); ok && len(path) > 0 {
+		parent, err := path[:len(path)-1].Resolve(doc, x.Name)
+		if err != nil {
+			return jsonPatchOp{}, err
+		}
+		return jsonPatchOp{Op: "move", From: parent.child(KeySegment(x.OldKey)).Pointer(), Path: parent.child(KeySegment(x.NewKey)).Pointer()}, nil
+	}
+	resolved, err := path.Resolve(doc, name)
+	if err != nil {
+		return jsonPatchOp{}, err
+	}
+	ptr := resolved.Pointer()
+	switch x := d.(type) {
+	case Insertion:
+		return jsonPatchOp{Op: "add", Path: ptr, Value: x.NewNode}, nil
+	case Deletion:
+		return jsonPatchOp{Op: "remove", Path: ptr}, nil
+	case Modification:
+		if len(resolved) > 0 && !resolved[len(resolved)-1].IsIndex {
+			if x.New == nil && !a.setNulls {
+				return jsonPatchOp{Op: "remove", Path: ptr}, nil
+			}
+			return jsonPatchOp{Op: "add", Path: ptr, Value: x.New}, nil
+		}
+		return jsonPatchOp{Op: "replace", Path: ptr, Value: x.New}, nil
+	}
+	return jsonPatchOp{}, fmt.Errorf("unknown delta type %T", d)
+}
+
+// clampIndex returns the last index of name, or n if it is larger
+func clampIndex(name FieldName, n int) int {
+	ix := name[len(name)-1].Index
+	if ix > n {
+		return n
+	}
+	return ix
+}
@@ -0,0 +1,87 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestKeyedPaths(t *testing.T) {
+	doc1, _ := parse(`{"users":[{"id":42,"email":"a"},{"id":7,"email":"b"},{"name":"x"}],"m":[[1,2]],"tags":[{"id":"1"},{"id":"dup"},{"id":"dup"}]}`)
+	doc2, _ := parse(`{"users":[{"id":7,"email":"b"},{"id":42,"email":"c"},{"name":"y"}],"m":[[1,3]],"tags":[{"id":"1","x":true},{"id":"dup"}]}`)
+	deltas := NewDiffer(ArrayKey("users", "id"), ArrayKey("tags", "id")).Difference(doc1, doc2)
+	paths := map[string]bool{}
+	for _, p := range KeyedPaths(doc1, doc2, deltas, "id") {
+		paths[p.String()] = true
+	}
+	for _, expected := range []string{"users[id=42]/email", "users[id=7]", "users[*]", "m[*]", "tags[id=\"1\"]/x", "tags[*]"} {
+		if !paths[expected] {
+			t.Errorf("Missing path %s: %v", expected, paths)
+		}
+	}
+
+	p := KeyedPath{{Key: "a/b[c"}, {IsElement: true, IDField: "name", ID: "x]y"}, {IsElement: true, IDField: "k", ID: "plain"}}
+	if p.String() != `a~1b~2c[name="x]y"][k=plain]` {
+		t.Errorf("Wrong path: %s", p)
+	}
+}
+
+func TestParseKeyedPath(t *testing.T) {
+	paths := []KeyedPath{
+		{},
+		{{Key: "users"}, {IsElement: true, IDField: "id", ID: 42.0}, {Key: "email"}},
+		{{IsElement: true}, {IsElement: true, IDField: "name", ID: "x]y"}, {Key: "a/b[c~"}},
+		{{Key: "t"}, {IsElement: true, IDField: "a=b", ID: "42"}, {IsElement: true, IDField: "k", ID: true}},
+	}
+	for _, p := range paths {
+		parsed, err := ParseKeyedPath(p.String())
+		if err != nil {
+			t.Errorf("Cannot parse %s: %s", p, err)
+			continue
+		}
+		if parsed.String() != p.String() || len(parsed) != len(p) {
+			t.Errorf("Wrong parse for %s: %#v", p, parsed)
+			continue
+		}
+		for i := range p {
+			if parsed[i] != p[i] {
+				t.Errorf("Wrong parse for %s: %#v", p, parsed)
+			}
+		}
+	}
+	for _, s := range []string{"a//b", "a[id=1", "a[=1]", "a[1]b", `a["x]`, "/a"} {
+		if _, err := ParseKeyedPath(s); err == nil {
+			t.Errorf("Expected error for %s", s)
+		}
+	}
+}
+
+func TestApplyKeyed(t *testing.T) {
+	doc1, _ := parse(`{"users":[{"id":1,"email":"a"},{"id":2,"email":"b"},{"id":3,"email":"c"}],"n":1}`)
+	doc2, _ := parse(`{"users":[{"id":1,"email":"a"},{"id":3,"email":"x"},{"id":4,"email":"d"}],"n":2}`)
+	deltas := NewDiffer(ArrayKey("users", "id")).Difference(doc1, doc2)
+	var paths []KeyedPath
+	for _, p := range KeyedPaths(doc1, doc2, deltas, "id") {
+		// Paths survive serialization
+		parsed, err := ParseKeyedPath(p.String())
+		if err != nil {
+			t.Errorf("Cannot parse %s: %s", p, err)
+			return
+		}
+		paths = append(paths, parsed)
+	}
+	result, err := ApplyKeyed(doc1, deltas, paths)
+	if err != nil || !IsEqual(result, doc2) {
+		t.Errorf("Wrong result: %v %v", result, err)
+	}
+	// Apply to a reordered document
+	reordered, _ := parse(`{"users":[{"id":3,"email":"c"},{"id":2,"email":"b"},{"id":1,"email":"a"}],"n":1}`)
+	expected, _ := parse(`{"users":[{"id":3,"email":"x"},{"id":1,"email":"a"},{"id":4,"email":"d"}],"n":2}`)
+	result, err = ApplyKeyed(reordered, deltas, paths)
+	if err != nil || !IsEqual(result, expected) {
+		t.Errorf("Wrong result: %v %v", result, err)
+	}
+	// Missing elements are errors
+	missing, _ := parse(`{"users":[{"id":1,"email":"a"}],"n":1}`)
+	if _, err := ApplyKeyed(missing, deltas, paths); err == nil {
+		t.Errorf("Expected error")
+	}
+}
@@ -0,0 +1,42 @@
+package jsondiff
+
+import "encoding/json"
+
+// NodeKind is the JSON type of a node
+type NodeKind string
+
+// Node kinds
+const (
+	KindNull   NodeKind = "null"
+	KindBool   NodeKind = "boolean"
+	KindNumber NodeKind = "number"
+	KindString NodeKind = "string"
+	KindObject NodeKind = "object"
+	KindArray  NodeKind = "array"
+	// KindOther is the kind of values that are not JSON values
+	KindOther NodeKind = "other"
+)
+
+// KindOf returns the JSON type of a node
+func KindOf(node interface{}) NodeKind {
+	switch node.(type) {
+	case nil:
+		return KindNull
+	case bool:
+		return KindBool
+	case float64, float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, json.Number:
+		return KindNumber
+	case string:
+		return KindString
+	case map[string]interface{}:
+		return KindObject
+	case []interface{}:
+		return KindArray
+	}
+	return KindOther
+}
+
+// typeChanged returns if the nodes are of different kinds
+func typeChanged(node1, node2 interface{}) bool {
+	return KindOf(node1) != KindOf(node2)
+}
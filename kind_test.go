@@ -0,0 +1,37 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTypeChanged(t *testing.T) {
+	doc1, _ := parse(`{"a":{"x":1},"b":"1","c":null,"d":1,"e":[1],"f":1}`)
+	doc2, _ := parse(`{"a":[1],"b":1,"c":"x","d":2,"e":[2],"g":"x"}`)
+	expected := map[string]bool{"a": true, "b": true, "c": true, "d": false, "f": false, "g": false}
+	deltas := Difference(doc1, doc2)
+	for _, d := range deltas {
+		m, ok := d.(Modification)
+		if !ok {
+			continue
+		}
+		changed, ok := expected[m.Name.String()]
+		if !ok || m.TypeChanged != changed {
+			t.Errorf("Wrong type change: %v %v", m, m.TypeChanged)
+		}
+		data, _ := json.Marshal(m)
+		x, _ := UnmarshalDelta(data)
+		if x.(Modification).TypeChanged != changed {
+			t.Errorf("Type change not serialized: %s", data)
+		}
+	}
+	if len(deltas) != 8 {
+		t.Errorf("Unexpected diff: %v", deltas)
+	}
+	for node, kind := range map[interface{}]NodeKind{nil: KindNull, true: KindBool, 1.5: KindNumber,
+		"x": KindString, json.Number("1"): KindNumber, struct{}{}: KindOther} {
+		if KindOf(node) != kind {
+			t.Errorf("Wrong kind for %v: %s", node, KindOf(node))
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package jsondiff
+
+import "fmt"
+
+// Logger receives the debug messages of a Differ. The arguments are
+// alternating keys and values. *slog.Logger implements Logger.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+}
+
+// WithLogger writes debug messages describing how the arrays are
+// matched to l
+func WithLogger(l Logger) Option {
+	return func(d *Differ) {
+		d.logger = l
+	}
+}
+
+// debug writes a debug message if there is a logger
+func (d *Differ) debug(msg string, args ...interface{}) {
+	if d.logger != nil {
+		d.logger.Debug(msg, args...)
+	}
+}
+
+// matcherName returns the name of an array matcher for debug messages
+func matcherName(m ArrayMatcher) string {
+	switch x := m.(type) {
+	case valueMatcher:
+		return "value"
+	case keyMatcher:
+		return "key:" + x.key
+	case lcsMatcher:
+		return "lcs"
+	case positionalMatcher:
+		return "positional"
+	}
+	return fmt.Sprintf("%T", m)
+}
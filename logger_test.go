@@ -0,0 +1,32 @@
+package jsondiff
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWithLogger(t *testing.T) {
+	doc1, _ := parse(`{"a":[{"id":1},{"id":2}],"b":[1,2,3]}`)
+	doc2, _ := parse(`{"a":[{"id":2},{"id":1,"x":1}],"b":[1,3]}`)
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	NewDiffer(WithLogger(l), ArrayKey("a", "id")).Difference(doc1, doc2)
+	out := buf.String()
+	for _, s := range []string{
+		"path=a matcher=key:id",
+		"path=b matcher=value",
+		"path=b old=3 new=2 matched=2",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Missing %q in log: %s", s, out)
+		}
+	}
+	// Without a logger nothing is written
+	buf.Reset()
+	NewDiffer(ArrayKey("a", "id")).Difference(doc1, doc2)
+	if buf.Len() != 0 {
+		t.Errorf("Unexpected log: %s", buf.String())
+	}
+}
@@ -0,0 +1,96 @@
+package jsondiff
+
+// Conflict describes two incompatible changes made to the same part
+// of a document by two concurrent edits
+type Conflict struct {
+	Mine   Delta
+	Theirs Delta
+}
+
+// Merge performs a three-way merge. mine and theirs are two
+// concurrently edited versions of base. The changes made in both
+// versions are applied to base. If both versions change the same part
+// of the document differently, the change in mine is used, and the
+// pair of changes is reported as a conflict.
+func Merge(base, mine, theirs interface{}) (merged interface{}, conflicts []Conflict, err error) {
+	myDeltas := Difference(base, mine)
+	theirDeltas := Difference(base, theirs)
+	deltas := make([]Delta, 0, len(myDeltas)+len(theirDeltas))
+	deltas = append(deltas, myDeltas...)
+	for _, t := range theirDeltas {
+		duplicate := false
+		conflicted := false
+		for _, m := range myDeltas {
+			if !overlaps(deltaScope(m), deltaScope(t)) {
+				continue
+			}
+			if isDeltaEqual(m, t) {
+				duplicate = true
+				continue
+			}
+			conflicted = true
+			conflicts = append(conflicts, Conflict{Mine: m, Theirs: t})
+		}
+		if !duplicate && !conflicted {
+			deltas = append(deltas, t)
+		}
+	}
+	merged, err = Apply(base, deltas)
+	if err != nil {
+		return nil, nil, err
+	}
+	return merged, conflicts, nil
+}
+
+// deltaScope returns the part of the document affected by the
+// delta. Array insertions, deletions, and moves shift the indexes of
+// the array, so they affect the whole array. Renames affect two
+// fields of the object
+func deltaScope(d Delta) FieldName {
+	switch x := d.(type) {
+	case Rename:
+		return x.Name
+	case Insertion, Deletion, Move:
+		name := d.GetField()
+		if len(name) > 0 {
+			return name[:len(name)-1]
+		}
+		return name
+	}
+	return d.GetField()
+}
+
+// overlaps returns if one of the fields is the same as or contains the other
+func overlaps(f1, f2 FieldName) bool {
+	if len(f1) > len(f2) {
+		f1, f2 = f2, f1
+	}
+	for i := range f1 {
+		if f1[i] != f2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isDeltaEqual returns if the two deltas describe the same change
+func isDeltaEqual(d1, d2 Delta) bool {
+	if d1.GetType() != d2.GetType() || !sameField(d1.GetField(), d2.GetField()) {
+		return false
+	}
+	switch x := d1.(type) {
+	case Insertion:
+		return IsEqual(x.NewNode, d2.(Insertion).NewNode)
+	case Deletion:
+		return IsEqual(x.DeletedNode, d2.(Deletion).DeletedNode)
+	case Move:
+		return sameField(x.From, d2.(Move).From)
+	case Rename:
+		y := d2.(Rename)
+		return x.OldKey == y.OldKey && IsEqual(x.Value, y.Value)
+	case Modification:
+		y := d2.(Modification)
+		return IsEqual(x.Old, y.Old) && IsEqual(x.New, y.New)
+	}
+	return false
+}
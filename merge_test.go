@@ -0,0 +1,64 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestMergeNoConflict(t *testing.T) {
+	base, _ := parse(`{"a":1,"b":{"c":1,"d":2},"e":[1,2,3]}`)
+	mine, _ := parse(`{"a":2,"b":{"c":1,"d":2},"e":[1,2,3]}`)
+	theirs, _ := parse(`{"a":1,"b":{"c":1,"d":3},"e":[1,2,3,4]}`)
+	expected, _ := parse(`{"a":2,"b":{"c":1,"d":3},"e":[1,2,3,4]}`)
+	merged, conflicts, err := Merge(base, mine, theirs)
+	if err != nil {
+		t.Errorf("Merge error: %s", err)
+		return
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("Unexpected conflicts: %v", conflicts)
+	}
+	if !IsEqual(merged, expected) {
+		t.Errorf("Wrong merge: %v", merged)
+	}
+}
+
+func TestMergeSameChange(t *testing.T) {
+	base, _ := parse(`{"a":1,"b":2}`)
+	mine, _ := parse(`{"a":2,"b":2}`)
+	theirs, _ := parse(`{"a":2,"b":3}`)
+	expected, _ := parse(`{"a":2,"b":3}`)
+	merged, conflicts, err := Merge(base, mine, theirs)
+	if err != nil {
+		t.Errorf("Merge error: %s", err)
+		return
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("Unexpected conflicts: %v", conflicts)
+	}
+	if !IsEqual(merged, expected) {
+		t.Errorf("Wrong merge: %v", merged)
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	base, _ := parse(`{"a":1,"b":{"c":1},"e":[1,2,3]}`)
+	mine, _ := parse(`{"a":2,"b":{"c":1},"e":[1,2,3,5]}`)
+	theirs, _ := parse(`{"a":3,"b":{"c":2},"e":[1,2,3,4]}`)
+	expected, _ := parse(`{"a":2,"b":{"c":2},"e":[1,2,3,5]}`)
+	merged, conflicts, err := Merge(base, mine, theirs)
+	if err != nil {
+		t.Errorf("Merge error: %s", err)
+		return
+	}
+	if len(conflicts) != 2 {
+		t.Errorf("Wrong conflicts: %v", conflicts)
+	}
+	for _, c := range conflicts {
+		if c.Mine.GetField().String() != c.Theirs.GetField().String() {
+			t.Errorf("Wrong conflict: %v", c)
+		}
+	}
+	if !IsEqual(merged, expected) {
+		t.Errorf("Wrong merge: %v", merged)
+	}
+}
@@ -0,0 +1,66 @@
+package jsondiff
+
+import (
+	"encoding/json"
+)
+
+// FromMergePatch converts the RFC 7386 JSON merge patch into deltas
+// against doc. Each field set by the patch becomes a Modification
+// whose Old value is taken from doc. Fields removed by the patch
+// become Modifications with nil New value. Changes that do not modify
+// doc are omitted. If the patch cannot be parsed, the returned error
+// is a *ParseError.
+func FromMergePatch(doc interface{}, patch []byte) ([]Delta, error) {
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, newParseError(2, -1, err)
+	}
+	var ret []Delta
+	mergePatchDeltas(FieldName{}, doc, true, p, &ret)
+	return ret, nil
+}
+
+// mergePatchDeltas appends the deltas for applying patch to node to
+// ret. exists is false if node does not exist in the document
+func mergePatchDeltas(fieldName FieldName, node interface{}, exists bool, patch interface{}, ret *[]Delta) {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		if !exists || !IsEqual(node, patch) {
+			*ret = append(*ret, Modification{Name: fieldName, Old: node, New: patch})
+		}
+		return
+	}
+	nodeObj, ok := node.(map[string]interface{})
+	if !ok {
+		// The patch replaces the node with an object
+		*ret = append(*ret, Modification{Name: fieldName, Old: node, New: mergePatchValue(patchObj)})
+		return
+	}
+	for key, value := range patchObj {
+		old, exists := nodeObj[key]
+		name := fieldName.child(KeySegment(key))
+		if value == nil {
+			if exists {
+				*ret = append(*ret, Modification{Name: name, Old: old, New: nil})
+			}
+			continue
+		}
+		mergePatchDeltas(name, old, exists, value, ret)
+	}
+}
+
+// mergePatchValue returns the value of the patch applied to an empty
+// object, that is, the patch with all null fields removed
+func mergePatchValue(patch interface{}) interface{} {
+	obj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	ret := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		if v != nil {
+			ret[k] = mergePatchValue(v)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,56 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestFromMergePatch(t *testing.T) {
+	doc, err := parse(`{"a":"b","c":{"d":"e","f":"g"},"h":[1,2],"i":1}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	delta, err := FromMergePatch(doc, []byte(`{"a":"z","c":{"f":null,"x":{"y":null,"z":1}},"h":[3],"i":1,"j":null}`))
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	if len(delta) != 4 {
+		t.Errorf("Unexpected diff: %v", delta)
+	}
+	result, err := Apply(doc, delta)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected, _ := parse(`{"a":"z","c":{"d":"e","x":{"z":1}},"h":[3],"i":1}`)
+	if !IsEqual(result, expected) {
+		t.Errorf("Wrong result: %v", result)
+	}
+}
+
+func TestFromJSONPatch(t *testing.T) {
+	doc, err := parse(`{"a":[1,2,3]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	delta, err := FromJSONPatch(doc, []byte(`[{"op":"move","from":"/a/0","path":"/a/-"}]`))
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	result, err := Apply(doc, delta)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected, _ := parse(`{"a":[2,3,1]}`)
+	if !IsEqual(result, expected) {
+		t.Errorf("Wrong result: %v", result)
+	}
+	check, _ := parse(`{"a":[1,2,3]}`)
+	if !IsEqual(doc, check) {
+		t.Errorf("Document modified: %v", doc)
+	}
+}
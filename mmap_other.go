@@ -0,0 +1,13 @@
+//go:build !unix
+
+package jsondiff
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapFile is not supported on this platform, so the file is read
+func mmapFile(f *os.File, size int64) ([]byte, func(), error) {
+	return nil, nil, errors.New("memory mapping is not supported")
+}
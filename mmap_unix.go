@@ -0,0 +1,18 @@
+//go:build unix
+
+package jsondiff
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile maps size bytes of the file into memory read-only. The
+// returned function unmaps the file
+func mmapFile(f *os.File, size int64) ([]byte, func(), error) {
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() { syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,136 @@
+// Package mongodiff computes differences between MongoDB documents
+// exported as Extended JSON, using the jsondiff engine. The type
+// wrappers of Extended JSON are replaced by the values they wrap
+// before the documents are compared, so the same document exported
+// in canonical and relaxed mode has no differences.
+//
+// Importing this package registers the ExtJSON format, so exported
+// Mongo documents can be compared with other documents using
+// jsondiff.DifferenceAny.
+package mongodiff
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+// ExtJSON is the MongoDB Extended JSON document format
+const ExtJSON jsondiff.Format = "mongo-extjson"
+
+func init() {
+	jsondiff.RegisterFormat(ExtJSON, Unmarshal)
+}
+
+// ExtJSONDifference computes difference between two MongoDB Extended
+// JSON documents. If one of the documents cannot be parsed, the
+// returned error is a *jsondiff.ParseError.
+func ExtJSONDifference(doc1, doc2 []byte, opts ...jsondiff.Option) ([]jsondiff.Delta, error) {
+	return jsondiff.DifferenceAny(doc1, doc2, ExtJSON, ExtJSON, opts...)
+}
+
+// Unmarshal parses an Extended JSON document, and returns it in the
+// node model used by jsondiff
+func Unmarshal(doc []byte) (interface{}, error) {
+	var node interface{}
+	if err := json.Unmarshal(doc, &node); err != nil {
+		return nil, err
+	}
+	return Normalize(node), nil
+}
+
+// Normalize replaces the Extended JSON type wrappers in a decoded
+// document with the values they wrap:
+//
+//	{"$oid": "..."}                    the object id hex string
+//	{"$date": ...}                     an RFC 3339 string in UTC
+//	{"$numberLong": "..."}, {"$numberInt": "..."},
+//	{"$numberDouble": "..."}, {"$numberDecimal": "..."}
+//	                                   a float64
+//
+// Dates can be ISO-8601 strings, milliseconds since the epoch as a
+// number, or as a $numberLong. Numbers that cannot be represented as a
+// float64, such as "NaN", and other wrappers are not changed. Long
+// integers larger than 2^53 lose precision, which is reported by the
+// differ as a WarnLossyNumber warning.
+func Normalize(node interface{}) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if v, ok := unwrap(n); ok {
+			return v
+		}
+		ret := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			ret[k] = Normalize(v)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(n))
+		for i, v := range n {
+			ret[i] = Normalize(v)
+		}
+		return ret
+	}
+	return node
+}
+
+// unwrap returns the value wrapped by an Extended JSON type wrapper
+func unwrap(obj map[string]interface{}) (interface{}, bool) {
+	if len(obj) != 1 {
+		return nil, false
+	}
+	for k, v := range obj {
+		switch k {
+		case "$oid":
+			s, ok := v.(string)
+			return s, ok
+		case "$numberLong", "$numberInt", "$numberDouble", "$numberDecimal":
+			return number(v)
+		case "$date":
+			return date(v)
+		}
+	}
+	return nil, false
+}
+
+// number returns the value of a number wrapper
+func number(v interface{}) (interface{}, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, false
+	}
+	return f, true
+}
+
+// date returns the value of a $date wrapper as an RFC 3339 string
+func date(v interface{}) (interface{}, bool) {
+	var ms float64
+	switch x := v.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, x)
+		if err != nil {
+			return nil, false
+		}
+		return t.UTC().Format(time.RFC3339Nano), true
+	case float64:
+		ms = x
+	case map[string]interface{}:
+		n, ok := unwrap(x)
+		if !ok {
+			return nil, false
+		}
+		if ms, ok = n.(float64); !ok {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	return time.UnixMilli(int64(ms)).UTC().Format(time.RFC3339Nano), true
+}
@@ -0,0 +1,51 @@
+package mongodiff
+
+import (
+	"testing"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+func TestExtJSONDifference(t *testing.T) {
+	canonical := []byte(`{"_id":{"$oid":"5f1a2b3c4d5e6f7a8b9c0d1e"},
+"created":{"$date":{"$numberLong":"1577836800000"}},
+"count":{"$numberLong":"42"},"ratio":{"$numberDouble":"0.5"},
+"items":[{"n":{"$numberInt":"1"}}],"nan":{"$numberDouble":"NaN"}}`)
+	relaxed := []byte(`{"_id":{"$oid":"5f1a2b3c4d5e6f7a8b9c0d1e"},
+"created":{"$date":"2020-01-01T00:00:00Z"},
+"count":42,"ratio":0.5,
+"items":[{"n":1}],"nan":{"$numberDouble":"NaN"}}`)
+	deltas, err := ExtJSONDifference(canonical, relaxed)
+	if err != nil || len(deltas) != 0 {
+		t.Errorf("Unexpected diff: %v %v", deltas, err)
+	}
+
+	changed := []byte(`{"_id":{"$oid":"5f1a2b3c4d5e6f7a8b9c0d1f"},
+"created":{"$date":"2020-01-01T00:00:01Z"},
+"count":43,"ratio":0.5,
+"items":[{"n":1}],"nan":{"$numberDouble":"NaN"}}`)
+	deltas, err = jsondiff.DifferenceAny(canonical, changed, ExtJSON, jsondiff.JSON)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	// The second document is not normalized, so the date is a wrapper
+	if len(deltas) != 3 {
+		t.Errorf("Unexpected diff: %v", deltas)
+	}
+	deltas, _ = ExtJSONDifference(canonical, changed)
+	if len(deltas) != 3 {
+		t.Errorf("Unexpected diff: %v", deltas)
+	}
+	for _, d := range deltas {
+		if d.GetField().String() == "created" {
+			m := d.(jsondiff.Modification)
+			if m.Old != "2020-01-01T00:00:00Z" || m.New != "2020-01-01T00:00:01Z" {
+				t.Errorf("Wrong dates: %v", m)
+			}
+		}
+	}
+	if _, err := ExtJSONDifference([]byte(`{`), relaxed); err == nil {
+		t.Errorf("Expected error")
+	}
+}
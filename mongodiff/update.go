@@ -0,0 +1,157 @@
+package mongodiff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+// ToMongoUpdate converts deltas to a MongoDB update document that
+// applies them as a partial update. The deltas are expected to be in
+// the form returned by Difference. Changed and added fields are
+// written under $set, and removed fields under $unset, keyed by
+// dotted paths. Elements inserted into an array are written as a
+// $push with $each and $position, and renamed fields as a $rename.
+//
+// A single update can express only a subset of array changes. An
+// error is returned for array element deletions and moves, for
+// insertions into an array that are not contiguous, for changes to
+// paths that conflict with each other, for renames of fields inside
+// array elements, which $rename does not support, and for keys that
+// cannot be written in a dotted path. Replace the document, or the
+// changed array, in these cases.
+//
+// The returned map can be passed to the MongoDB driver as a bson.M.
+func ToMongoUpdate(deltas []jsondiff.Delta) (map[string]interface{}, error) {
+	set := make(map[string]interface{})
+	unset := make(map[string]interface{})
+	rename := make(map[string]interface{})
+	// Elements inserted into arrays, by the array path
+	pushed := make(map[string]map[int]interface{})
+	var paths []string
+	for _, d := range deltas {
+		name := d.GetField()
+		if len(name) == 0 {
+			return nil, fmt.Errorf("cannot update the whole document")
+		}
+		isElement := name[len(name)-1].IsIndex
+		path, err := dottedPath(name)
+		if err != nil {
+			return nil, err
+		}
+		switch x := d.(type) {
+		case jsondiff.Modification:
+			if x.New == nil && !isElement {
+				unset[path] = ""
+			} else {
+				set[path] = x.New
+			}
+		case jsondiff.Insertion:
+			if !isElement {
+				set[path] = x.NewNode
+				break
+			}
+			parent, _ := dottedPath(name[:len(name)-1])
+			if pushed[parent] == nil {
+				pushed[parent] = make(map[int]interface{})
+				paths = append(paths, parent)
+			}
+			pushed[parent][name[len(name)-1].Index] = x.NewNode
+			continue
+		case jsondiff.Deletion:
+			if isElement {
+				return nil, fmt.Errorf("cannot delete array element %s in an update", name)
+			}
+			unset[path] = ""
+		case jsondiff.Move:
+			return nil, fmt.Errorf("cannot move array element %s in an update", x.From)
+		case jsondiff.Rename:
+			for _, s := range x.Name {
+				if s.IsIndex {
+					return nil, fmt.Errorf("cannot rename a field of an array element %s in an update", name)
+				}
+			}
+			from, err := dottedPath(append(append(jsondiff.FieldName{}, x.Name...), jsondiff.KeySegment(x.OldKey)))
+			if err != nil {
+				return nil, err
+			}
+			rename[from] = path
+			paths = append(paths, from)
+		default:
+			return nil, fmt.Errorf("unknown delta %v", d)
+		}
+		paths = append(paths, path)
+	}
+	push := make(map[string]interface{})
+	for parent, elements := range pushed {
+		indexes := make([]int, 0, len(elements))
+		for ix := range elements {
+			indexes = append(indexes, ix)
+		}
+		sort.Ints(indexes)
+		each := make([]interface{}, 0, len(indexes))
+		for i, ix := range indexes {
+			if ix != indexes[0]+i {
+				return nil, fmt.Errorf("cannot insert non-contiguous elements into %s in an update", parent)
+			}
+			each = append(each, elements[ix])
+		}
+		push[parent] = map[string]interface{}{"$each": each, "$position": indexes[0]}
+	}
+	if err := checkConflicts(paths); err != nil {
+		return nil, err
+	}
+	ret := make(map[string]interface{})
+	if len(set) > 0 {
+		ret["$set"] = set
+	}
+	if len(unset) > 0 {
+		ret["$unset"] = unset
+	}
+	if len(push) > 0 {
+		ret["$push"] = push
+	}
+	if len(rename) > 0 {
+		ret["$rename"] = rename
+	}
+	return ret, nil
+}
+
+// dottedPath returns the MongoDB dotted path for name
+func dottedPath(name jsondiff.FieldName) (string, error) {
+	parts := make([]string, len(name))
+	for i, s := range name {
+		if s.IsIndex {
+			parts[i] = strconv.Itoa(s.Index)
+			continue
+		}
+		if s.Key == "" || strings.Contains(s.Key, ".") || strings.HasPrefix(s.Key, "$") {
+			return "", fmt.Errorf("cannot write key %q of %s in a dotted path", s.Key, name)
+		}
+		parts[i] = s.Key
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// checkConflicts returns an error if a path is repeated, or is a
+// prefix of another path. MongoDB rejects updates with such paths
+func checkConflicts(paths []string) error {
+	seen := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		if seen[p] {
+			return fmt.Errorf("conflicting changes at %s", p)
+		}
+		seen[p] = true
+	}
+	for _, p := range paths {
+		for i := strings.LastIndex(p, "."); i > 0; i = strings.LastIndex(p[:i], ".") {
+			if seen[p[:i]] {
+				return fmt.Errorf("conflicting changes at %s and %s", p[:i], p)
+			}
+		}
+	}
+	return nil
+}
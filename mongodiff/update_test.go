@@ -0,0 +1,66 @@
+package mongodiff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+func TestToMongoUpdate(t *testing.T) {
+	doc1, _ := Unmarshal([]byte(`{"a":1,"b":"x","c":{"d":[1,2]}}`))
+	doc2, _ := Unmarshal([]byte(`{"a":2,"c":{"d":[1,2,3,4]},"g":true}`))
+	update, err := ToMongoUpdate(jsondiff.Difference(doc1, doc2))
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	data, _ := json.Marshal(update)
+	expected := `{"$push":{"c.d":{"$each":[3,4],"$position":2}},"$set":{"a":2,"g":true},"$unset":{"b":""}}`
+	if string(data) != expected {
+		t.Errorf("Wrong update: %s", data)
+	}
+
+	for _, docs := range [][2]string{
+		{`{"a":[1,2,3]}`, `{"a":[1,3]}`},
+		{`{"a":[1,2]}`, `{"a":[0,1,5,2,6]}`},
+		{`{"a.b":1}`, `{"a.b":2}`},
+		{`1`, `2`},
+	} {
+		doc1, _ := Unmarshal([]byte(docs[0]))
+		doc2, _ := Unmarshal([]byte(docs[1]))
+		if _, err := ToMongoUpdate(jsondiff.Difference(doc1, doc2)); err == nil {
+			t.Errorf("Expected error for %s %s", docs[0], docs[1])
+		}
+	}
+
+	deltas := []jsondiff.Delta{
+		jsondiff.Modification{Name: jsondiff.FieldName{jsondiff.KeySegment("e"), jsondiff.IndexSegment(1), jsondiff.KeySegment("f")}, Old: 2.0, New: 3.0},
+	}
+	update, _ = ToMongoUpdate(deltas)
+	data, _ = json.Marshal(update)
+	if string(data) != `{"$set":{"e.1.f":3}}` {
+		t.Errorf("Wrong update: %s", data)
+	}
+
+	deltas = []jsondiff.Delta{
+		jsondiff.Modification{Name: jsondiff.FieldName{jsondiff.KeySegment("a")}, New: map[string]interface{}{}},
+		jsondiff.Modification{Name: jsondiff.FieldName{jsondiff.KeySegment("a"), jsondiff.KeySegment("b")}, New: 1.0},
+	}
+	if _, err := ToMongoUpdate(deltas); err == nil {
+		t.Errorf("Expected conflict")
+	}
+
+	deltas = []jsondiff.Delta{
+		jsondiff.Rename{Name: jsondiff.FieldName{jsondiff.KeySegment("a")}, OldKey: "b", NewKey: "c", Value: 1.0},
+	}
+	update, _ = ToMongoUpdate(deltas)
+	data, _ = json.Marshal(update)
+	if string(data) != `{"$rename":{"a.b":"a.c"}}` {
+		t.Errorf("Wrong update: %s", data)
+	}
+	deltas[0] = jsondiff.Rename{Name: jsondiff.FieldName{jsondiff.KeySegment("a"), jsondiff.IndexSegment(0)}, OldKey: "b", NewKey: "c", Value: 1.0}
+	if _, err := ToMongoUpdate(deltas); err == nil {
+		t.Errorf("Expected error for rename in array element")
+	}
+}
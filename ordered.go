@@ -0,0 +1,199 @@
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// ObjectField is a field of an OrderedObject
+type ObjectField struct {
+	Key   string
+	Value interface{}
+}
+
+// OrderedObject is a JSON object that keeps the order of its fields.
+// Documents containing OrderedObjects are compared using the
+// OrderedObjects option.
+type OrderedObject []ObjectField
+
+// MarshalJSON writes the object with its fields in order
+func (o OrderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ParseOrdered parses a JSON document like json.Unmarshal, but returns
+// the objects as OrderedObjects
+func ParseOrdered(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	node, err := DecodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, newParseError(1, dec.InputOffset(), fmt.Errorf("invalid data after the document"))
+	}
+	return node, nil
+}
+
+// DecodeOrdered decodes the next JSON value from the token stream of
+// dec, and returns its objects as OrderedObjects. If an object has
+// duplicate keys, the last value is kept in the position of the first
+// key.
+func DecodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, newParseError(1, dec.InputOffset(), err)
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			ret := OrderedObject{}
+			index := make(map[string]int)
+			for dec.More() {
+				tok, err := dec.Token()
+				if err != nil {
+					return nil, newParseError(1, dec.InputOffset(), err)
+				}
+				key, ok := tok.(string)
+				if !ok {
+					return nil, newParseError(1, dec.InputOffset(), fmt.Errorf("invalid object key %v", tok))
+				}
+				value, err := DecodeOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				if ix, ok := index[key]; ok {
+					ret[ix].Value = value
+					continue
+				}
+				index[key] = len(ret)
+				ret = append(ret, ObjectField{Key: key, Value: value})
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, newParseError(1, dec.InputOffset(), err)
+			}
+			return ret, nil
+		case '[':
+			ret := make([]interface{}, 0)
+			for dec.More() {
+				value, err := DecodeOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				ret = append(ret, value)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, newParseError(1, dec.InputOffset(), err)
+			}
+			return ret, nil
+		}
+		return nil, newParseError(1, dec.InputOffset(), fmt.Errorf("unexpected %v", t))
+	}
+	return tok, nil
+}
+
+// OrderedObjects compares the order of the fields of objects decoded
+// as OrderedObjects. A field that is in both objects, but is not in
+// the longest sequence of fields that are in the same order in both,
+// is reported as a Move whose From and To are the field. Without this
+// option, the documents must not contain OrderedObjects. The
+// documents are converted to maps before they are compared, so the
+// values in the deltas are maps, and the deltas can be applied to the
+// documents decoded by json.Unmarshal. Apply ignores the moves of
+// object fields.
+func OrderedObjects() Option {
+	return func(d *Differ) {
+		d.ordered = true
+	}
+}
+
+// unorder converts the OrderedObjects in the documents to maps if the
+// OrderedObjects option is set, and records their key order
+func (d *Differ) unorder(node1, node2 interface{}) (interface{}, interface{}) {
+	if !d.ordered {
+		return node1, node2
+	}
+	d.keyOrder = make(map[uintptr][]string)
+	return d.plainNode(node1), d.plainNode(node2)
+}
+
+// plainNode returns a copy of node in which OrderedObjects are
+// replaced by maps
+func (d *Differ) plainNode(node interface{}) interface{} {
+	switch n := node.(type) {
+	case OrderedObject:
+		ret := make(map[string]interface{}, len(n))
+		keys := make([]string, 0, len(n))
+		for _, f := range n {
+			if _, ok := ret[f.Key]; !ok {
+				keys = append(keys, f.Key)
+			}
+			ret[f.Key] = d.plainNode(f.Value)
+		}
+		d.keyOrder[reflect.ValueOf(ret).Pointer()] = keys
+		return ret
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			ret[k] = d.plainNode(v)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(n))
+		for i, v := range n {
+			ret[i] = d.plainNode(v)
+		}
+		return ret
+	}
+	return node
+}
+
+// keyMoves reports the fields of two objects converted from
+// OrderedObjects that changed their order
+func (d *Differ) keyMoves(fieldName FieldName, node1, node2 map[string]interface{}, emit func(Delta)) {
+	order1, ok1 := d.keyOrder[reflect.ValueOf(node1).Pointer()]
+	order2, ok2 := d.keyOrder[reflect.ValueOf(node2).Pointer()]
+	if !ok1 || !ok2 {
+		return
+	}
+	index2 := make(map[string]int, len(order2))
+	for j, key := range order2 {
+		index2[key] = j
+	}
+	equivalence := newDualMap(len(order1), len(order2))
+	for i, key := range order1 {
+		if j, ok := index2[key]; ok {
+			equivalence.insert(i, j)
+		}
+	}
+	stable := stableMatches(equivalence, len(order2))
+	for j, key := range order2 {
+		if equivalence.getOldIndex(j) != -1 && !stable[j] {
+			name := fieldName.next(KeySegment(key)).clone()
+			emit(Move{From: name, To: name, Old: node1[key], New: node2[key]})
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOrderedObjects(t *testing.T) {
+	doc1, err := ParseOrdered([]byte(`{"a":1,"b":{"x":1,"y":2},"c":3,"d":[{"p":1,"q":2}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc2, err := ParseOrdered([]byte(`{"b":{"y":2,"x":1},"a":1,"c":4,"d":[{"p":1,"q":2}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deltas := NewDiffer(OrderedObjects()).Difference(doc1, doc2)
+	moves := make(map[string]bool)
+	for _, d := range deltas {
+		switch x := d.(type) {
+		case Move:
+			if !sameField(x.From, x.To) {
+				t.Errorf("Wrong move: %v", x)
+			}
+			moves[x.To.String()] = true
+		case Modification:
+			if x.Name.String() != "c" || x.New != 4.0 {
+				t.Errorf("Wrong modification: %v", x)
+			}
+		default:
+			t.Errorf("Unexpected delta: %v", d)
+		}
+	}
+	if len(moves) != 2 || !(moves["a"] || moves["b"]) || !(moves["b/x"] || moves["b/y"]) {
+		t.Errorf("Wrong moves: %v", deltas)
+	}
+
+	// The deltas apply to unordered documents
+	var plain1, plain2 interface{}
+	json.Unmarshal([]byte(`{"a":1,"b":{"x":1,"y":2},"c":3,"d":[{"p":1,"q":2}]}`), &plain1)
+	json.Unmarshal([]byte(`{"b":{"y":2,"x":1},"a":1,"c":4,"d":[{"p":1,"q":2}]}`), &plain2)
+	result, err := Apply(plain1, deltas)
+	if err != nil || !IsEqual(result, plain2) {
+		t.Errorf("Wrong result: %v %v", result, err)
+	}
+
+	data, err := json.Marshal(doc2)
+	if err != nil || string(data) != `{"b":{"y":2,"x":1},"a":1,"c":4,"d":[{"p":1,"q":2}]}` {
+		t.Errorf("Wrong encoding: %s %v", data, err)
+	}
+	if _, err := ParseOrdered([]byte(`{"a":1} 2`)); err == nil {
+		t.Errorf("Expected error")
+	}
+	if _, err := ParseOrdered([]byte(`{"a":`)); err == nil {
+		t.Errorf("Expected error")
+	}
+}
@@ -0,0 +1,347 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Segment is a single element of a field name. A segment is either an
+// object key, or an array index.
+type Segment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// KeySegment returns a segment for the object key
+func KeySegment(key string) Segment {
+	return Segment{Key: key}
+}
+
+// IndexSegment returns a segment for the array index
+func IndexSegment(index int) Segment {
+	return Segment{Index: index, IsIndex: true}
+}
+
+// String returns the array index, or the object key escaped as in a
+// JSON pointer, "~" as "~0", and "/" as "~1"
+func (s Segment) String() string {
+	if s.IsIndex {
+		return strconv.Itoa(s.Index)
+	}
+	return pointerEscaper.Replace(s.Key)
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// patternUnescaper unescapes the literal segments of path patterns,
+// where "#" is also escaped as "~2"
+var patternUnescaper = strings.NewReplacer("~1", "/", "~2", "#", "~0", "~")
+
+// patternKey returns the object key escaped as a literal segment of a
+// path pattern
+func patternKey(key string) string {
+	if key == "#" {
+		return "~2"
+	}
+	return pointerEscaper.Replace(key)
+}
+
+// FieldName contains field name parts
+type FieldName []Segment
+
+// String returns the field name segments separated by "/". Object
+// keys containing "/" or "~" are escaped as in a JSON pointer, so the
+// segments are unambiguous.
+func (f FieldName) String() string {
+	parts := make([]string, len(f))
+	for i, s := range f {
+		parts[i] = s.String()
+	}
+	return strings.Join(parts, "/")
+}
+
+// Pointer returns the field name as an RFC 6901 JSON pointer
+func (f FieldName) Pointer() string {
+	if len(f) == 0 {
+		return ""
+	}
+	return "/" + f.String()
+}
+
+// MarshalJSON encodes the field name as a JSON array, where object
+// keys are strings and array indexes are numbers
+func (f FieldName) MarshalJSON() ([]byte, error) {
+	parts := make([]interface{}, len(f))
+	for i, s := range f {
+		if s.IsIndex {
+			parts[i] = s.Index
+		} else {
+			parts[i] = s.Key
+		}
+	}
+	return json.Marshal(parts)
+}
+
+// UnmarshalJSON decodes a field name encoded by MarshalJSON
+func (f *FieldName) UnmarshalJSON(data []byte) error {
+	var parts []interface{}
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return err
+	}
+	if parts == nil {
+		*f = nil
+		return nil
+	}
+	name := make(FieldName, len(parts))
+	for i, p := range parts {
+		switch x := p.(type) {
+		case string:
+			name[i] = KeySegment(x)
+		case float64:
+			if x != float64(int(x)) || x < 0 {
+				return fmt.Errorf("invalid array index: %v", x)
+			}
+			name[i] = IndexSegment(int(x))
+		default:
+			return fmt.Errorf("invalid field name segment: %v", p)
+		}
+	}
+	*f = name
+	return nil
+}
+
+// child returns the field name of the child of f at seg. The result
+// is a new slice, so it does not share its backing array with f or
+// with the other children of f. Appending to f directly would let
+// sibling field names overwrite each other's last segment.
+func (f FieldName) child(seg Segment) FieldName {
+	ret := make(FieldName, len(f)+1)
+	copy(ret, f)
+	ret[len(f)] = seg
+	return ret
+}
+
+// next returns the field name of the child at seg like child, but
+// reuses the spare capacity of f. The result is overwritten by the
+// next sibling, so it must be copied with clone before it is kept
+func (f FieldName) next(seg Segment) FieldName {
+	return append(f, seg)
+}
+
+// clone returns a copy of f
+func (f FieldName) clone() FieldName {
+	ret := make(FieldName, len(f))
+	copy(ret, f)
+	return ret
+}
+
+// sameField returns if the two field names are the same
+func sameField(f1, f2 FieldName) bool {
+	if len(f1) != len(f2) {
+		return false
+	}
+	for i := range f1 {
+		if f1[i] != f2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// compareFieldNames compares two field names segment by segment, and
+// returns -1, 0, or 1. Array indexes are compared as numbers, and
+// sort before object keys. A field name sorts before the names under it
+func compareFieldNames(f1, f2 FieldName) int {
+	for i := 0; i < len(f1) && i < len(f2); i++ {
+		s1, s2 := f1[i], f2[i]
+		switch {
+		case s1.IsIndex && !s2.IsIndex:
+			return -1
+		case !s1.IsIndex && s2.IsIndex:
+			return 1
+		case s1.IsIndex && s1.Index != s2.Index:
+			if s1.Index < s2.Index {
+				return -1
+			}
+			return 1
+		case !s1.IsIndex && s1.Key != s2.Key:
+			if s1.Key < s2.Key {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(f1) < len(f2):
+		return -1
+	case len(f1) > len(f2):
+		return 1
+	}
+	return 0
+}
+
+// MatchPath returns if path matches the pattern. This is how the
+// patterns of the options, such as ArraysAsSets and RedactPaths, are
+// matched. A pattern is a list of segments separated by "/", where
+// "~", "/", and "#" in segments are escaped as "~0", "~1", and "~2".
+// A "*" segment matches exactly one path segment, a "#" segment
+// matches exactly one array index, and a "**" segment matches zero or
+// more segments. Other segments match an object key, or an array
+// index written in decimal. Patterns are compiled once, and cached.
+func MatchPath(pattern string, path FieldName) bool {
+	return compilePattern(pattern).match(path)
+}
+
+// Kinds of pattern segments
+const (
+	segLiteral = iota
+	segAny
+	segIndex
+	segDeep
+)
+
+// patternSegment is a compiled segment of a path pattern
+type patternSegment struct {
+	kind int
+	// text is the unescaped text of a literal segment
+	text string
+}
+
+// compiledPattern is a compiled path pattern
+type compiledPattern []patternSegment
+
+// maxCachedPatterns limits the number of cached patterns, in case
+// patterns are generated
+const maxCachedPatterns = 4096
+
+var (
+	patternCache      sync.Map
+	patternCacheCount atomic.Int64
+)
+
+// compilePattern returns the compiled pattern, using the cache
+func compilePattern(pattern string) compiledPattern {
+	if p, ok := patternCache.Load(pattern); ok {
+		return p.(compiledPattern)
+	}
+	var ret compiledPattern
+	if pattern != "" {
+		for _, s := range strings.Split(pattern, "/") {
+			switch s {
+			case "*":
+				ret = append(ret, patternSegment{kind: segAny})
+			case "#":
+				ret = append(ret, patternSegment{kind: segIndex})
+			case "**":
+				ret = append(ret, patternSegment{kind: segDeep})
+			default:
+				ret = append(ret, patternSegment{kind: segLiteral, text: patternUnescaper.Replace(s)})
+			}
+		}
+	}
+	if patternCacheCount.Load() < maxCachedPatterns {
+		if _, loaded := patternCache.LoadOrStore(pattern, ret); !loaded {
+			patternCacheCount.Add(1)
+		}
+	}
+	return ret
+}
+
+func (p compiledPattern) match(name FieldName) bool {
+	for len(p) > 0 {
+		if p[0].kind == segDeep {
+			for i := 0; i <= len(name); i++ {
+				if p[1:].match(name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		switch p[0].kind {
+		case segIndex:
+			if !name[0].IsIndex {
+				return false
+			}
+		case segLiteral:
+			if p[0].text != segmentText(name[0]) {
+				return false
+			}
+		}
+		p = p[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
+
+// segmentText returns the unescaped text of the segment
+func segmentText(s Segment) string {
+	if s.IsIndex {
+		return strconv.Itoa(s.Index)
+	}
+	return s.Key
+}
+
+// pathSelector selects document paths using a list of patterns. If
+// all is set, it selects all paths.
+type pathSelector struct {
+	all      bool
+	patterns []string
+}
+
+// newPathSelector returns a selector for the given patterns. If
+// there are no patterns, the selector selects all paths
+func newPathSelector(patterns []string) pathSelector {
+	return pathSelector{all: len(patterns) == 0, patterns: patterns}
+}
+
+func (s pathSelector) matches(name FieldName) bool {
+	if s.all {
+		return true
+	}
+	for _, p := range s.patterns {
+		if MatchPath(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the node at path in doc
+func lookup(doc interface{}, path FieldName) (interface{}, bool) {
+	for _, s := range path {
+		switch n := doc.(type) {
+		case map[string]interface{}:
+			if s.IsIndex {
+				return nil, false
+			}
+			v, ok := n[s.Key]
+			if !ok {
+				return nil, false
+			}
+			doc = v
+		case []interface{}:
+			if !s.IsIndex || s.Index < 0 || s.Index >= len(n) {
+				return nil, false
+			}
+			doc = n[s.Index]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// hasPrefix returns if prefix is the same as or a parent of name
+func hasPrefix(name, prefix FieldName) bool {
+	return len(prefix) <= len(name) && sameField(name[:len(prefix)], prefix)
+}
@@ -0,0 +1,168 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func keys(k ...string) FieldName {
+	ret := make(FieldName, len(k))
+	for i, x := range k {
+		ret[i] = KeySegment(x)
+	}
+	return ret
+}
+
+func TestMatchPath(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    FieldName
+		match   bool
+	}{
+		{"a", keys("a"), true},
+		{"a", keys("b"), false},
+		{"a/*", keys("a", "b"), true},
+		{"a/*", keys("a"), false},
+		{"a/*", keys("a", "b", "c"), false},
+		{"a/**", keys("a"), true},
+		{"a/**", keys("a", "b", "c"), true},
+		{"**/c", keys("a", "b", "c"), true},
+		{"**/c", keys("c"), true},
+		{"**/c", keys("a", "c", "d"), false},
+		{"a/**/d", keys("a", "b", "c", "d"), true},
+		{"", keys(), true},
+		{"**", keys(), true},
+		{"a/1", FieldName{KeySegment("a"), IndexSegment(1)}, true},
+		{"a~1b/c", keys("a/b", "c"), true},
+		{"a/#/b", FieldName{KeySegment("a"), IndexSegment(3), KeySegment("b")}, true},
+		{"a/#/b", keys("a", "3", "b"), false},
+		{"a/#", keys("a", "x"), false},
+		{"**/#", FieldName{KeySegment("a"), IndexSegment(0), IndexSegment(1)}, true},
+		{"a/#", keys("a", "#"), false},
+		{"a/~2", keys("a", "#"), true},
+		{"a/~2", FieldName{KeySegment("a"), IndexSegment(0)}, false},
+		{"a/x~2y", keys("a", "x#y"), true},
+		{"a/x#y", keys("a", "x#y"), true},
+	}
+	for _, c := range cases {
+		// The second match uses the cached pattern
+		for i := 0; i < 2; i++ {
+			if MatchPath(c.pattern, c.name) != c.match {
+				t.Errorf("Wrong match for %s, %s: expected %v", c.pattern, c.name, c.match)
+			}
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	name := FieldName{KeySegment("a/b"), IndexSegment(2), KeySegment("2"), KeySegment("c~")}
+	if name.String() != "a~1b/2/2/c~0" {
+		t.Errorf("Wrong string: %s", name.String())
+	}
+	if name.Pointer() != "/a~1b/2/2/c~0" {
+		t.Errorf("Wrong pointer: %s", name.Pointer())
+	}
+	data, err := json.Marshal(name)
+	if err != nil {
+		t.Errorf("Marshal error: %s", err)
+		return
+	}
+	if string(data) != `["a/b",2,"2","c~"]` {
+		t.Errorf("Wrong json: %s", string(data))
+	}
+	var name2 FieldName
+	if err := json.Unmarshal(data, &name2); err != nil {
+		t.Errorf("Unmarshal error: %s", err)
+		return
+	}
+	if !sameField(name, name2) {
+		t.Errorf("Wrong field name: %v", name2)
+	}
+}
+
+// deepDoc returns a document of the given depth, where every object
+// has width fields and an array of two objects with id fields, and
+// the leaves are leaf
+func deepDoc(depth, width int, leaf interface{}) interface{} {
+	if depth == 0 {
+		return leaf
+	}
+	obj := make(map[string]interface{}, width)
+	for i := 0; i < width; i++ {
+		obj[fmt.Sprintf("f%d", i)] = deepDoc(depth-1, width, leaf)
+	}
+	if depth > 1 {
+		arr := make([]interface{}, 2)
+		for i := range arr {
+			elem := deepDoc(depth-1, width, leaf).(map[string]interface{})
+			elem["id"] = float64(i)
+			arr[i] = elem
+		}
+		obj["arr"] = arr
+	}
+	return obj
+}
+
+// countLeaves returns the number of string values in doc
+func countLeaves(doc interface{}) int {
+	switch n := doc.(type) {
+	case map[string]interface{}:
+		count := 0
+		for _, v := range n {
+			count += countLeaves(v)
+		}
+		return count
+	case []interface{}:
+		count := 0
+		for _, v := range n {
+			count += countLeaves(v)
+		}
+		return count
+	case string:
+		return 1
+	}
+	return 0
+}
+
+func TestDeltaPathsAreIndependent(t *testing.T) {
+	doc1 := deepDoc(4, 5, "a")
+	doc2 := deepDoc(4, 5, "b")
+	deltas := NewDiffer(ArrayKey("**/arr", "id")).Difference(doc1, doc2)
+	seen := make(map[string]struct{})
+	for _, d := range deltas {
+		m, ok := d.(Modification)
+		if !ok {
+			t.Errorf("Unexpected delta: %v", d)
+			continue
+		}
+		name := m.Name.String()
+		if _, ok := seen[name]; ok {
+			t.Errorf("Duplicate path: %s", name)
+		}
+		seen[name] = struct{}{}
+		if v, ok := lookup(doc1, m.Name); !ok || v != m.Old {
+			t.Errorf("Wrong old value at %s: %v", name, v)
+		}
+		if v, ok := lookup(doc2, m.Name); !ok || v != m.New {
+			t.Errorf("Wrong new value at %s: %v", name, v)
+		}
+	}
+	if len(deltas) != countLeaves(doc1) {
+		t.Errorf("Wrong number of deltas: %d", len(deltas))
+	}
+}
+
+func TestNestedArrayPaths(t *testing.T) {
+	doc1, _ := parse(`{"u":[{"id":1,"x":[1,2],"y":[5]},{"id":2,"x":[3]},{"id":3,"x":[]}]}`)
+	doc2, _ := parse(`{"u":[{"id":3,"x":[4]},{"id":2,"x":[3,6]},{"id":1,"x":[2,1,3],"y":[]}]}`)
+	deltas := NewDiffer(ArrayKey("u", "id")).Difference(doc1, doc2)
+	result, err := Apply(doc1, deltas)
+	if err != nil {
+		t.Errorf("Cannot apply %v: %s", deltas, err)
+		return
+	}
+	if !IsEqual(result, doc2) {
+		t.Errorf("Wrong result: %v, deltas: %v", result, deltas)
+	}
+}
@@ -0,0 +1,69 @@
+package jsondiff
+
+// PolicyRule describes a change that is allowed by a Policy
+type PolicyRule struct {
+	// Path is a pattern matched against the field of the delta, using
+	// the same syntax as ArraysAsSets
+	Path string
+	// Types are the allowed delta types. If empty, all types are
+	// allowed
+	Types []DiffType
+	// Values, if set, is called with the old and new values of the
+	// delta, and the change is allowed only if it returns true. The
+	// old value of an insertion and the new value of a deletion are
+	// nil
+	Values func(old, new interface{}) bool
+}
+
+// Policy is a set of rules describing the allowed changes to a
+// document. A delta is allowed if it matches one of the rules.
+type Policy struct {
+	Rules []PolicyRule
+}
+
+// Allows returns if the delta matches one of the rules of the policy
+func (p Policy) Allows(d Delta) bool {
+	for _, r := range p.Rules {
+		if r.allows(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// allows returns if the delta matches the rule
+func (r PolicyRule) allows(d Delta) bool {
+	if !MatchPath(r.Path, d.GetField()) {
+		return false
+	}
+	if len(r.Types) > 0 {
+		found := false
+		for _, t := range r.Types {
+			if t == d.GetType() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.Values != nil {
+		return r.Values(oldValue(d), newValue(d))
+	}
+	return true
+}
+
+// Evaluate splits the deltas into the deltas allowed by the policy and
+// the violations, keeping their order. A drift detection gate passes
+// if there are no violations.
+func Evaluate(deltas []Delta, p Policy) (allowed, violations []Delta) {
+	for _, d := range deltas {
+		if p.Allows(d) {
+			allowed = append(allowed, d)
+		} else {
+			violations = append(violations, d)
+		}
+	}
+	return allowed, violations
+}
@@ -0,0 +1,31 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	doc1, _ := parse(`{"spec":{"replicas":2,"image":"app:1","ports":[80]},"owner":"a"}`)
+	doc2, _ := parse(`{"spec":{"replicas":5,"image":"app:2","ports":[80,443]},"owner":"b"}`)
+	p := Policy{Rules: []PolicyRule{
+		{Path: "spec/replicas", Types: []DiffType{DiffMod}, Values: func(old, new interface{}) bool {
+			n, ok := new.(float64)
+			return ok && n <= 10
+		}},
+		{Path: "spec/ports/*", Types: []DiffType{DiffIns}},
+		{Path: "spec/image"},
+	}}
+	allowed, violations := Evaluate(Difference(doc1, doc2), p)
+	if len(allowed) != 3 {
+		t.Errorf("Wrong allowed: %v", allowed)
+	}
+	if len(violations) != 1 || violations[0].GetField().String() != "owner" {
+		t.Errorf("Wrong violations: %v", violations)
+	}
+
+	doc3, _ := parse(`{"spec":{"replicas":50,"image":"app:1","ports":[]},"owner":"a"}`)
+	allowed, violations = Evaluate(Difference(doc1, doc3), p)
+	if len(allowed) != 0 || len(violations) != 2 {
+		t.Errorf("Wrong evaluation: %v %v", allowed, violations)
+	}
+}
@@ -0,0 +1,283 @@
+package jsondiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DocProfile describes the shape of a document
+type DocProfile struct {
+	// Nodes is the number of nodes of each kind
+	Nodes map[NodeKind]int
+	// MaxDepth is the length of the longest field name in the
+	// document. The depth of a scalar document is 0
+	MaxDepth int
+	// LargestArrays are the longest arrays of the document, longest
+	// first
+	LargestArrays []ArrayProfile
+	// Arrays describes the arrays at each path pattern, where array
+	// indexes are replaced by "*"
+	Arrays map[string]ArrayProfile
+	// KeyCardinality is the number of objects containing each key
+	KeyCardinality map[string]int
+}
+
+// ArrayProfile describes an array, or the arrays at a path pattern
+type ArrayProfile struct {
+	// Path is the field name of the array. For the arrays at a path
+	// pattern, it is the field name of the longest array
+	Path FieldName
+	// Length is the number of elements. For the arrays at a path
+	// pattern, it is the length of the longest array
+	Length int
+	// Count is the number of arrays at the path pattern
+	Count int
+	// Objects is the number of elements that are objects
+	Objects int
+	// Keys are the fields that have a unique scalar value in every
+	// object element of every array, in sorted order. They can be
+	// used to match the elements with ArrayKey
+	Keys []string
+}
+
+// maxLargestArrays is the number of arrays in DocProfile.LargestArrays
+const maxLargestArrays = 5
+
+// Profile returns the profile of the document
+func Profile(doc interface{}) DocProfile {
+	p := DocProfile{
+		Nodes:          make(map[NodeKind]int),
+		Arrays:         make(map[string]ArrayProfile),
+		KeyCardinality: make(map[string]int),
+	}
+	p.add(FieldName{}, nil, doc)
+	sort.SliceStable(p.LargestArrays, func(i, j int) bool {
+		return p.LargestArrays[i].Length > p.LargestArrays[j].Length
+	})
+	return p
+}
+
+// add adds the node at name to the profile. pattern is the path
+// pattern of name
+func (p *DocProfile) add(name FieldName, pattern []string, node interface{}) {
+	p.Nodes[KindOf(node)]++
+	if len(name) > p.MaxDepth {
+		p.MaxDepth = len(name)
+	}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for k, v := range n {
+			p.KeyCardinality[k]++
+			p.add(name.child(KeySegment(k)), append(pattern[:len(pattern):len(pattern)], patternKey(k)), v)
+		}
+	case []interface{}:
+		p.addArray(name, strings.Join(pattern, "/"), n)
+		for i, v := range n {
+			p.add(name.child(IndexSegment(i)), append(pattern[:len(pattern):len(pattern)], "*"), v)
+		}
+	}
+}
+
+// addArray adds the array at name to the array profiles
+func (p *DocProfile) addArray(name FieldName, pattern string, arr []interface{}) {
+	a := ArrayProfile{Path: name, Length: len(arr), Count: 1, Keys: uniqueKeys(arr)}
+	for _, v := range arr {
+		if _, ok := v.(map[string]interface{}); ok {
+			a.Objects++
+		}
+	}
+	p.LargestArrays = append(p.LargestArrays, a)
+	if len(p.LargestArrays) > maxLargestArrays {
+		// Drop the shortest array
+		min := 0
+		for i, x := range p.LargestArrays {
+			if x.Length < p.LargestArrays[min].Length {
+				min = i
+			}
+		}
+		p.LargestArrays = append(p.LargestArrays[:min], p.LargestArrays[min+1:]...)
+	}
+	prev, ok := p.Arrays[pattern]
+	if !ok {
+		p.Arrays[pattern] = a
+		return
+	}
+	if prev.Length > a.Length {
+		a.Path, a.Length = prev.Path, prev.Length
+	}
+	a.Count += prev.Count
+	a.Objects += prev.Objects
+	a.Keys = intersectKeys(prev.Keys, a.Keys)
+	p.Arrays[pattern] = a
+}
+
+// merge returns the profile of two documents compared with each other
+func (p DocProfile) merge(q DocProfile) DocProfile {
+	ret := DocProfile{
+		Nodes:          make(map[NodeKind]int),
+		MaxDepth:       p.MaxDepth,
+		Arrays:         make(map[string]ArrayProfile),
+		KeyCardinality: make(map[string]int),
+	}
+	if q.MaxDepth > ret.MaxDepth {
+		ret.MaxDepth = q.MaxDepth
+	}
+	for _, x := range []DocProfile{p, q} {
+		for k, n := range x.Nodes {
+			ret.Nodes[k] += n
+		}
+		for k, n := range x.KeyCardinality {
+			ret.KeyCardinality[k] += n
+		}
+		ret.LargestArrays = append(ret.LargestArrays, x.LargestArrays...)
+	}
+	sort.SliceStable(ret.LargestArrays, func(i, j int) bool {
+		return ret.LargestArrays[i].Length > ret.LargestArrays[j].Length
+	})
+	if len(ret.LargestArrays) > maxLargestArrays {
+		ret.LargestArrays = ret.LargestArrays[:maxLargestArrays]
+	}
+	for pattern, a := range p.Arrays {
+		ret.Arrays[pattern] = a
+	}
+	for pattern, b := range q.Arrays {
+		a, ok := ret.Arrays[pattern]
+		if !ok {
+			ret.Arrays[pattern] = b
+			continue
+		}
+		if b.Length > a.Length {
+			a.Path, a.Length = b.Path, b.Length
+		}
+		a.Count += b.Count
+		a.Objects += b.Objects
+		a.Keys = intersectKeys(a.Keys, b.Keys)
+		ret.Arrays[pattern] = a
+	}
+	return ret
+}
+
+// uniqueKeys returns the fields that have a unique scalar value in
+// every element of arr, if all the elements are objects
+func uniqueKeys(arr []interface{}) []string {
+	if len(arr) == 0 {
+		return nil
+	}
+	first, ok := arr[0].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var keys []string
+	for k := range first {
+		seen := make(map[interface{}]bool, len(arr))
+		unique := true
+		for _, v := range arr {
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			value, ok := obj[k]
+			if !ok || value == nil || !isScalar(value) || !isComparable(value) || seen[value] {
+				unique = false
+				break
+			}
+			seen[value] = true
+		}
+		if unique {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// intersectKeys returns the keys in both sorted lists
+func intersectKeys(a, b []string) []string {
+	var ret []string
+	for _, k := range a {
+		if i := sort.SearchStrings(b, k); i < len(b) && b[i] == k {
+			ret = append(ret, k)
+		}
+	}
+	return ret
+}
+
+// Recommendation is a difference option recommended for documents
+// with a profile
+type Recommendation struct {
+	// Option is the recommended option
+	Option Option
+	// Description is the option as Go code
+	Description string
+	// Reason explains the recommendation
+	Reason string
+	// pattern is the path pattern of the arrays an array strategy is
+	// recommended for
+	pattern string
+}
+
+// preferredKeys are the key fields recommended first, if they are
+// unique
+var preferredKeys = []string{"id", "_id", "key", "name", "uuid"}
+
+// Recommendation thresholds
+const (
+	// minKeyedArrayLength is the length of the shortest array whose
+	// elements are recommended to be matched by key
+	minKeyedArrayLength = 2
+	// manyNodes is the number of nodes above which MaxDeltas is
+	// recommended
+	manyNodes = 100000
+	// recommendedMaxDeltas is the recommended MaxDeltas limit
+	recommendedMaxDeltas = 10000
+)
+
+// Recommend returns difference options recommended for documents
+// like the profiled document, most useful first. Arrays of objects
+// with a unique key field are matched by that key, so the changes to
+// the elements are reported as changes to their fields instead of
+// whole elements being deleted and inserted. The number of deltas is
+// limited for large documents.
+func (p DocProfile) Recommend() []Recommendation {
+	var ret []Recommendation
+	patterns := make([]string, 0, len(p.Arrays))
+	for pattern := range p.Arrays {
+		patterns = append(patterns, pattern)
+	}
+	// Longest arrays first
+	sort.Slice(patterns, func(i, j int) bool {
+		a, b := p.Arrays[patterns[i]], p.Arrays[patterns[j]]
+		if a.Length != b.Length {
+			return a.Length > b.Length
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pattern := range patterns {
+		a := p.Arrays[pattern]
+		if a.Length < minKeyedArrayLength || len(a.Keys) == 0 {
+			continue
+		}
+		key := a.Keys[0]
+		for _, k := range preferredKeys {
+			if i := sort.SearchStrings(a.Keys, k); i < len(a.Keys) && a.Keys[i] == k {
+				key = k
+				break
+			}
+		}
+		ret = append(ret, Recommendation{Option: ArrayKey(pattern, key),
+			Description: fmt.Sprintf("ArrayKey(%q, %q)", pattern, key),
+			Reason:      fmt.Sprintf("the elements of %d arrays at %q are objects with the unique field %q", a.Count, pattern, key),
+			pattern:     pattern})
+	}
+	total := 0
+	for _, n := range p.Nodes {
+		total += n
+	}
+	if total > manyNodes {
+		ret = append(ret, Recommendation{Option: MaxDeltas(recommendedMaxDeltas),
+			Description: fmt.Sprintf("MaxDeltas(%d)", recommendedMaxDeltas),
+			Reason:      fmt.Sprintf("the document has %d nodes, so very different documents can have many deltas", total)})
+	}
+	return ret
+}
@@ -0,0 +1,62 @@
+package jsondiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	doc, _ := parse(`{"users":[{"id":1,"name":"a","tags":["x"]},{"id":2,"name":"a","tags":[]}],
+"groups":[{"members":[{"uid":"u1"},{"uid":"u2"}]},{"members":[{"uid":"u1"}]}],
+"list":[1,2,3,4]}`)
+	p := Profile(doc)
+	if p.MaxDepth != 5 {
+		t.Errorf("Wrong depth: %d", p.MaxDepth)
+	}
+	expected := map[NodeKind]int{KindObject: 8, KindArray: 7, KindNumber: 6, KindString: 6}
+	if !reflect.DeepEqual(p.Nodes, expected) {
+		t.Errorf("Wrong counts: %v", p.Nodes)
+	}
+	if p.KeyCardinality["uid"] != 3 || p.KeyCardinality["id"] != 2 {
+		t.Errorf("Wrong key cardinality: %v", p.KeyCardinality)
+	}
+	if len(p.LargestArrays) != 5 || p.LargestArrays[0].Path.String() != "list" {
+		t.Errorf("Wrong largest arrays: %v", p.LargestArrays)
+	}
+	members := p.Arrays["groups/*/members"]
+	if members.Count != 2 || members.Length != 2 || members.Objects != 3 || !reflect.DeepEqual(members.Keys, []string{"uid"}) {
+		t.Errorf("Wrong array profile: %+v", members)
+	}
+	if keys := p.Arrays["users"].Keys; !reflect.DeepEqual(keys, []string{"id"}) {
+		t.Errorf("Wrong keys: %v", keys)
+	}
+
+	rec := p.Recommend()
+	var descriptions []string
+	for _, r := range rec {
+		descriptions = append(descriptions, r.Description)
+	}
+	if !reflect.DeepEqual(descriptions, []string{`ArrayKey("groups/*/members", "uid")`, `ArrayKey("users", "id")`}) {
+		t.Errorf("Wrong recommendations: %v", descriptions)
+	}
+	var opts []Option
+	for _, r := range rec {
+		opts = append(opts, r.Option)
+	}
+	doc2, _ := parse(`{"users":[{"id":2,"name":"b","tags":[]},{"id":1,"name":"a","tags":["x"]}],
+"groups":[{"members":[{"uid":"u1"},{"uid":"u2"}]},{"members":[{"uid":"u1"}]}],
+"list":[1,2,3,4]}`)
+	deltas := NewDiffer(opts...).Difference(doc, doc2)
+	if len(deltas) != 2 {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+}
+
+func TestProfileHashKey(t *testing.T) {
+	doc, _ := parse(`{"#":[{"id":1}]}`)
+	p := Profile(doc)
+	a, ok := p.Arrays["~2"]
+	if !ok || !MatchPath("~2", a.Path) {
+		t.Errorf("Wrong arrays: %v", p.Arrays)
+	}
+}
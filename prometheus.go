@@ -0,0 +1,78 @@
+package jsondiff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diffTypeNames are the label values used for delta types in metrics
+var diffTypeNames = []struct {
+	t    DiffType
+	name string
+}{
+	{DiffIns, "insertion"},
+	{DiffDel, "deletion"},
+	{DiffMove, "move"},
+	{DiffMod, "modification"},
+	{DiffRename, "rename"},
+}
+
+// WritePrometheus writes the deltas as Prometheus metrics in the text
+// exposition format, suitable for the node exporter textfile
+// collector. The jsondiff_deltas gauge gives the number of deltas by
+// type. For each path pattern, the jsondiff_path_deltas gauge gives the
+// number of deltas whose field matches the pattern, or is under a
+// field matching the pattern. Patterns use the same syntax as
+// ArraysAsSets. Repeated patterns are written once.
+func WritePrometheus(w io.Writer, deltas []Delta, patterns ...string) error {
+	patterns = uniqueStrings(patterns)
+	counts := make(map[DiffType]int)
+	pathCounts := make([]int, len(patterns))
+	for _, d := range deltas {
+		counts[d.GetType()]++
+		name := d.GetField()
+		for i, p := range patterns {
+			for k := len(name); k >= 0; k-- {
+				if MatchPath(p, name[:k]) {
+					pathCounts[i]++
+					break
+				}
+			}
+		}
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# HELP jsondiff_deltas Number of differences by type.")
+	fmt.Fprintln(bw, "# TYPE jsondiff_deltas gauge")
+	for _, t := range diffTypeNames {
+		fmt.Fprintf(bw, "jsondiff_deltas{type=\"%s\"} %d\n", t.name, counts[t.t])
+	}
+	if len(patterns) > 0 {
+		fmt.Fprintln(bw, "# HELP jsondiff_path_deltas Number of differences at or under a path pattern.")
+		fmt.Fprintln(bw, "# TYPE jsondiff_path_deltas gauge")
+		for i, p := range patterns {
+			fmt.Fprintf(bw, "jsondiff_path_deltas{pattern=\"%s\"} %d\n", escapeLabelValue(p), pathCounts[i])
+		}
+	}
+	return bw.Flush()
+}
+
+// uniqueStrings returns the strings without repetitions, in the order
+// of their first occurrence
+func uniqueStrings(s []string) []string {
+	seen := make(map[string]bool, len(s))
+	ret := make([]string, 0, len(s))
+	for _, x := range s {
+		if !seen[x] {
+			seen[x] = true
+			ret = append(ret, x)
+		}
+	}
+	return ret
+}
+
+// escapeLabelValue escapes a Prometheus label value
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,41 @@
+package jsondiff
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	doc1, err := parse(`{"f1":"value1","f2":{"a":1,"b":2},"f3":[1,2]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"f1":"value2","f2":{"a":2,"b":3},"f3":[1,2,3]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	var buf bytes.Buffer
+	err = WritePrometheus(&buf, Difference(doc1, doc2), "f2/*", "f2", "f2", `a"b`)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected := `# HELP jsondiff_deltas Number of differences by type.
+# TYPE jsondiff_deltas gauge
+jsondiff_deltas{type="insertion"} 1
+jsondiff_deltas{type="deletion"} 0
+jsondiff_deltas{type="move"} 0
+jsondiff_deltas{type="modification"} 3
+jsondiff_deltas{type="rename"} 0
+# HELP jsondiff_path_deltas Number of differences at or under a path pattern.
+# TYPE jsondiff_path_deltas gauge
+jsondiff_path_deltas{pattern="f2/*"} 2
+jsondiff_path_deltas{pattern="f2"} 2
+jsondiff_path_deltas{pattern="a\"b"} 0
+`
+	if buf.String() != expected {
+		t.Errorf("Wrong output: %s", buf.String())
+	}
+}
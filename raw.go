@@ -0,0 +1,113 @@
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// DifferenceRaw computes difference between two JSON documents
+// without decoding the parts of the documents that are identical.
+// Objects are decoded one level at a time, keeping the field values
+// as json.RawMessage, and only the fields whose raw bytes differ are
+// decoded further. This reduces allocations for large documents
+// where most of the tree is unchanged. Arrays and values that differ
+// are decoded and compared as usual, so the result is the same as
+// JSONDifference. If one of the documents cannot be parsed, the
+// returned error is a *ParseError. If a limit set by MaxDeltas or
+// MaxDepth is reached, the deltas found until then are returned with
+// ErrTooManyDeltas or ErrTooDeep.
+func DifferenceRaw(doc1, doc2 []byte, opts ...Option) ([]Delta, error) {
+	var raw1, raw2 json.RawMessage
+	if err := json.Unmarshal(doc1, &raw1); err != nil {
+		return nil, newParseError(1, -1, err)
+	}
+	if err := json.Unmarshal(doc2, &raw2); err != nil {
+		return nil, newParseError(2, -1, err)
+	}
+	var ret []Delta
+	d := NewDiffer(opts...)
+	run, emit := d.start(nil, func(x Delta) {
+		ret = append(ret, x)
+	})
+	defer d.release(run)
+	if err := run.rawDifference(FieldName{}, raw1, raw2, emit); err != nil {
+		return nil, err
+	}
+	return ret, run.err
+}
+
+// rawDifference computes the difference between two raw nodes
+func (d *Differ) rawDifference(fieldName FieldName, raw1, raw2 json.RawMessage, emit func(Delta)) error {
+	if bytes.Equal(raw1, raw2) || d.stopped(fieldName) {
+		return nil
+	}
+	// Equality functions for scalars do not apply to objects. Objects
+	// whose fields are not paired by their exact keys are decoded
+	if !d.renames && !d.foldKeys && isRawObject(raw1) && isRawObject(raw2) && d.customEqual(fieldName, map[string]interface{}{}, map[string]interface{}{}) == nil {
+		var obj1, obj2 map[string]json.RawMessage
+		if err := json.Unmarshal(raw1, &obj1); err != nil {
+			return newParseError(1, -1, err)
+		}
+		if err := json.Unmarshal(raw2, &obj2); err != nil {
+			return newParseError(2, -1, err)
+		}
+		return d.rawObjectDifference(fieldName, obj1, obj2, emit)
+	}
+	node1, err := decodeRaw(1, raw1)
+	if err != nil {
+		return err
+	}
+	node2, err := decodeRaw(2, raw2)
+	if err != nil {
+		return err
+	}
+	d.nodeDifference(fieldName, node1, node2, emit)
+	return nil
+}
+
+func (d *Differ) rawObjectDifference(fieldName FieldName, obj1, obj2 map[string]json.RawMessage, emit func(Delta)) error {
+	for key, v1 := range obj1 {
+		name := fieldName.child(KeySegment(key))
+		if v2, ok := obj2[key]; ok {
+			if err := d.rawDifference(name, v1, v2, emit); err != nil {
+				return err
+			}
+			continue
+		}
+		node1, err := decodeRaw(1, v1)
+		if err != nil {
+			return err
+		}
+		if !d.isAbsent(name, node1) {
+			emit(d.removedField(name, node1))
+		}
+	}
+	for key, v2 := range obj2 {
+		if _, ok := obj1[key]; ok {
+			continue
+		}
+		node2, err := decodeRaw(2, v2)
+		if err != nil {
+			return err
+		}
+		if name := fieldName.child(KeySegment(key)); !d.isAbsent(name, node2) {
+			emit(d.addedField(name, node2))
+		}
+	}
+	return nil
+}
+
+// isRawObject returns if the raw node is a JSON object
+func isRawObject(raw json.RawMessage) bool {
+	raw = bytes.TrimLeft(raw, " \t\r\n")
+	return len(raw) > 0 && raw[0] == '{'
+}
+
+// decodeRaw decodes a raw node of document doc
+func decodeRaw(doc int, raw json.RawMessage) (interface{}, error) {
+	var node interface{}
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return nil, newParseError(doc, -1, err)
+	}
+	return node, nil
+}
@@ -0,0 +1,40 @@
+package jsondiff
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDifferenceRaw(t *testing.T) {
+	docs := [][2]string{
+		{`{"a":{"b":[1,2,3],"c":"x"},"d":1}`, `{"a":{"b":[1,2,3],"c":"y"},"d":1}`},
+		{`{"a":{"b":[1,2,3]},"d":1}`, `{"a":{"b":[3,2,4]}, "e":{"x":1}}`},
+		{`{"a":1.0}`, `{"a":1}`},
+		{`{"a":{"x":1,"y":2}}`, `{"a": {"y":2, "x":1}}`},
+		{`[1,{"a":1}]`, `{"a":1}`},
+	}
+	for _, doc := range docs {
+		raw, err := DifferenceRaw([]byte(doc[0]), []byte(doc[1]))
+		if err != nil {
+			t.Errorf("Error: %s", err)
+			continue
+		}
+		expected, _ := JSONDifference([]byte(doc[0]), []byte(doc[1]))
+		if len(raw) != len(expected) {
+			t.Errorf("Wrong result for %v: %v, expected %v", doc, raw, expected)
+			continue
+		}
+		doc1, _ := parse(doc[0])
+		doc2, _ := parse(doc[1])
+		result, err := Apply(doc1, raw)
+		if err != nil || !IsEqual(result, doc2) {
+			t.Errorf("Wrong result for %v: %v %v", doc, result, err)
+		}
+	}
+
+	_, err := DifferenceRaw([]byte(`{}`), []byte(`{`))
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Doc != 2 {
+		t.Errorf("Expected parse error for document 2, got %v", err)
+	}
+}
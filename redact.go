@@ -0,0 +1,108 @@
+package jsondiff
+
+// Redacted is the value that replaces the values of redacted fields
+const Redacted = "[REDACTED]"
+
+// RedactPaths replaces the values of the fields matching one of the
+// path patterns with Redacted in the emitted deltas. Patterns use the
+// same syntax as ArraysAsSets. Changes to redacted fields are still
+// reported, but their old and new values, and their text diffs, are
+// not. Matching fields nested in inserted, deleted, moved, or
+// modified containers are redacted as well. The fields are compared
+// using their real values. Deltas with redacted values cannot be
+// applied to restore the values.
+func RedactPaths(globs ...string) Option {
+	return func(d *Differ) {
+		d.redact = append(d.redact, globs...)
+	}
+}
+
+// redacted returns if the field is redacted
+func (d *Differ) redacted(name FieldName) bool {
+	for _, p := range d.redact {
+		if MatchPath(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactDelta returns the delta with the values of the redacted
+// fields replaced
+func (d *Differ) redactDelta(x Delta) Delta {
+	if len(d.redact) == 0 {
+		return x
+	}
+	switch t := x.(type) {
+	case Modification:
+		old, ok1 := d.redactValue(t.Name, t.Old)
+		new, ok2 := d.redactValue(t.Name, t.New)
+		if ok1 || ok2 {
+			t.Old, t.New, t.TextDiff = old, new, nil
+		}
+		return t
+	case Insertion:
+		t.NewNode, _ = d.redactValue(t.Name, t.NewNode)
+		return t
+	case Deletion:
+		t.DeletedNode, _ = d.redactValue(t.Name, t.DeletedNode)
+		return t
+	case Move:
+		t.Old, _ = d.redactValue(t.From, t.Old)
+		t.New, _ = d.redactValue(t.To, t.New)
+		return t
+	case Rename:
+		if d.redacted(t.Name.child(KeySegment(t.OldKey))) {
+			t.Value = Redacted
+		} else {
+			t.Value, _ = d.redactValue(t.GetField(), t.Value)
+		}
+		return t
+	}
+	return x
+}
+
+// redactValue returns the value of the field name with the redacted
+// fields replaced, and whether anything was replaced. Containers are
+// copied only if something under them is replaced. A nil value is
+// not replaced, so added and removed fields can still be told apart
+func (d *Differ) redactValue(name FieldName, value interface{}) (interface{}, bool) {
+	if value == nil {
+		return nil, false
+	}
+	if d.redacted(name) {
+		return Redacted, true
+	}
+	switch n := value.(type) {
+	case map[string]interface{}:
+		var ret map[string]interface{}
+		for k, v := range n {
+			if r, ok := d.redactValue(name.child(KeySegment(k)), v); ok {
+				if ret == nil {
+					ret = make(map[string]interface{}, len(n))
+					for k2, v2 := range n {
+						ret[k2] = v2
+					}
+				}
+				ret[k] = r
+			}
+		}
+		if ret != nil {
+			return ret, true
+		}
+	case []interface{}:
+		var ret []interface{}
+		for i, v := range n {
+			if r, ok := d.redactValue(name.child(IndexSegment(i)), v); ok {
+				if ret == nil {
+					ret = append([]interface{}{}, n...)
+				}
+				ret[i] = r
+			}
+		}
+		if ret != nil {
+			return ret, true
+		}
+	}
+	return value, false
+}
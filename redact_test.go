@@ -0,0 +1,52 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestRedactPaths(t *testing.T) {
+	doc1, _ := parse(`{"user":{"password":"old secret","name":"a"},"tokens":[{"value":"t1"}],"keys":{"k1":"x"}}`)
+	doc2, _ := parse(`{"user":{"password":"new secret","name":"b"},"tokens":[{"value":"t2"},{"value":"t3","id":1}],"ssn":"123"}`)
+	deltas := NewDiffer(RedactPaths("**/password", "tokens/*/value", "ssn", "keys"), TextDiffs(1, TextWords)).Difference(doc1, doc2)
+	found := map[string]bool{}
+	for _, d := range deltas {
+		found[d.GetField().String()] = true
+		switch x := d.(type) {
+		case Modification:
+			switch x.Name.String() {
+			case "user/password":
+				if x.Old != Redacted || x.New != Redacted || x.TextDiff != nil {
+					t.Errorf("Value not redacted: %v", x)
+				}
+			case "user/name":
+				if x.Old != "a" || x.New != "b" {
+					t.Errorf("Value redacted: %v", x)
+				}
+			case "ssn":
+				if x.Old != nil || x.New != Redacted {
+					t.Errorf("Value not redacted: %v", x)
+				}
+			case "keys":
+				if x.Old != Redacted || x.New != nil {
+					t.Errorf("Value not redacted: %v", x)
+				}
+			}
+		case Insertion:
+			m := x.NewNode.(map[string]interface{})
+			if m["value"] != Redacted || (m["id"] != nil && m["id"] != 1.0) {
+				t.Errorf("Nested value not redacted: %v", x)
+			}
+		case Deletion:
+			if x.DeletedNode.(map[string]interface{})["value"] != Redacted {
+				t.Errorf("Nested value not redacted: %v", x)
+			}
+		}
+	}
+	if !found["user/password"] || !found["ssn"] || !found["keys"] {
+		t.Errorf("Missing deltas: %v", deltas)
+	}
+	// The documents are not changed
+	if doc2.(map[string]interface{})["tokens"].([]interface{})[1].(map[string]interface{})["value"] != "t3" {
+		t.Errorf("Document changed: %v", doc2)
+	}
+}
@@ -0,0 +1,353 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+// Annotation markers
+const (
+	markInserted  = "+"
+	markDeleted   = "-"
+	markChanged   = "~"
+	markMoved     = ">"
+	markUnchanged = " "
+)
+
+// annotation describes the change of a node in the annotated view
+type annotation struct {
+	marker string
+	// old is the old value of a changed node
+	old interface{}
+	// from is the old index of a moved element, or the old key of a
+	// renamed field
+	from jsondiff.Segment
+	// renamed is set for renamed fields
+	renamed bool
+}
+
+// ghost is a deleted node shown in the annotated view
+type ghost struct {
+	seg   jsondiff.Segment
+	value interface{}
+}
+
+// annotator writes a document with annotations
+type annotator struct {
+	w      *bufio.Writer
+	opts   RenderOptions
+	marks  map[string]annotation
+	ghosts map[string][]ghost
+	deltas []jsondiff.Delta
+	// reverse is set when the document is the old document, and the
+	// deltas are inverted
+	reverse bool
+	// ghostMarker is the marker of ghost entries
+	ghostMarker string
+}
+
+// RenderAnnotated writes the new document in full, with each line
+// marked by how it changed: "+" for inserted nodes and added fields,
+// "~" for modified values followed by their old value as a comment,
+// ">" for moved array elements followed by their old index, and "-"
+// for ghost entries showing the deleted nodes and removed fields where
+// they were. Renamed fields are marked with ">" followed by their old
+// key. Unchanged lines are indented with a space. The output is
+// JSON-like, but is not valid JSON. opts.Doc and opts.Context are not
+// used.
+//
+//	  {
+//	~   "a": 2,  // was 1
+//	-   "b": "x",
+//	+   "c": [1]
+//	  }
+func RenderAnnotated(w io.Writer, newDoc interface{}, deltas []jsondiff.Delta, opts RenderOptions) error {
+	return renderAnnotated(w, newDoc, deltas, opts, false)
+}
+
+// RenderAnnotatedOld writes the old document in full, annotated with
+// what the deltas will change: "-" for nodes and fields that will be
+// removed, "~" for values that will be modified followed by their new
+// value as a comment, ">" for array elements that will be moved
+// followed by their new index, and "+" for ghost entries showing the
+// nodes and fields that will be added. deltas are computed from oldDoc
+// to the new document, as returned by Difference.
+//
+//	  {
+//	~   "a": 1,  // becomes 2
+//	-   "b": "x",
+//	+   "c": [1]
+//	  }
+func RenderAnnotatedOld(w io.Writer, oldDoc interface{}, deltas []jsondiff.Delta, opts RenderOptions) error {
+	return renderAnnotated(w, oldDoc, jsondiff.Invert(deltas), opts, true)
+}
+
+func renderAnnotated(w io.Writer, doc interface{}, deltas []jsondiff.Delta, opts RenderOptions, reverse bool) error {
+	if opts.Indent == "" {
+		opts.Indent = "  "
+	}
+	// In the old document, the nodes that are inserted by the inverted
+	// deltas are the ones that will be removed
+	inserted, deleted := markInserted, markDeleted
+	if reverse {
+		inserted, deleted = markDeleted, markInserted
+	}
+	a := annotator{w: bufio.NewWriter(w),
+		opts:        opts,
+		marks:       make(map[string]annotation),
+		ghosts:      make(map[string][]ghost),
+		deltas:      deltas,
+		reverse:     reverse,
+		ghostMarker: deleted,
+	}
+	for _, d := range deltas {
+		name := d.GetField()
+		switch x := d.(type) {
+		case jsondiff.Insertion:
+			a.marks[pathKey(name)] = annotation{marker: inserted}
+		case jsondiff.Deletion:
+			a.addGhost(name, x.DeletedNode)
+		case jsondiff.Move:
+			a.marks[pathKey(name)] = annotation{marker: markMoved, from: x.From[len(x.From)-1]}
+		case jsondiff.Rename:
+			a.marks[pathKey(name)] = annotation{marker: markMoved, from: jsondiff.KeySegment(x.OldKey), renamed: true}
+		case jsondiff.Modification:
+			isField := len(name) > 0 && !name[len(name)-1].IsIndex
+			switch {
+			case isField && x.Old == nil && x.New != nil:
+				a.marks[pathKey(name)] = annotation{marker: inserted}
+			case isField && x.New == nil && x.Old != nil:
+				a.addGhost(name, x.Old)
+			default:
+				a.marks[pathKey(name)] = annotation{marker: markChanged, old: x.Old}
+			}
+		}
+	}
+	a.node(nil, doc, "", "", markUnchanged, "")
+	return a.w.Flush()
+}
+
+func (a annotator) addGhost(name jsondiff.FieldName, value interface{}) {
+	if len(name) == 0 {
+		return
+	}
+	parent := pathKey(name[:len(name)-1])
+	a.ghosts[parent] = append(a.ghosts[parent], ghost{seg: name[len(name)-1], value: value})
+}
+
+// pathKey returns a map key for the field name. Unlike
+// FieldName.String, it distinguishes array indexes from numeric keys
+func pathKey(name jsondiff.FieldName) string {
+	var sb strings.Builder
+	for _, s := range name {
+		if s.IsIndex {
+			sb.WriteString("#" + strconv.Itoa(s.Index))
+		} else {
+			sb.WriteString("." + strconv.Quote(s.Key))
+		}
+	}
+	return sb.String()
+}
+
+// node writes the node at path. label is the "key": prefix, and
+// suffix is written after the node, before the annotation comment.
+// Nodes under a changed node inherit its marker
+func (a annotator) node(path jsondiff.FieldName, node interface{}, indent, label, marker, suffix string) {
+	comment := ""
+	if m, ok := a.marks[pathKey(path)]; ok && marker == markUnchanged {
+		marker = m.marker
+		switch {
+		case m.marker == markChanged && a.reverse:
+			comment = "  // becomes " + compact(m.old)
+		case m.marker == markChanged:
+			comment = "  // was " + compact(m.old)
+		case m.renamed && a.reverse:
+			comment = "  // renamed to " + compact(m.from.Key)
+		case m.renamed:
+			comment = "  // renamed from " + compact(m.from.Key)
+		case m.marker == markMoved && a.reverse:
+			comment = "  // moves to " + m.from.String()
+		case m.marker == markMoved:
+			comment = "  // moved from " + m.from.String()
+		}
+	}
+	a.container(path, node, indent, label, marker, suffix, comment)
+}
+
+// container writes a node, recursing into objects and arrays
+func (a annotator) container(path jsondiff.FieldName, node interface{}, indent, label, marker, suffix, comment string) {
+	type entry struct {
+		seg   jsondiff.Segment
+		value interface{}
+		ghost bool
+	}
+	var entries []entry
+	var open, close string
+	// The children of a moved element are not moved, but the children
+	// of inserted, deleted, and changed nodes are
+	childMarker := marker
+	if marker == markMoved {
+		childMarker = markUnchanged
+	}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		open, close = "{", "}"
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		if childMarker == markUnchanged {
+			for _, g := range a.ghosts[pathKey(path)] {
+				if !g.seg.IsIndex {
+					keys = append(keys, g.seg.Key)
+				}
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if v, ok := n[k]; ok {
+				entries = append(entries, entry{seg: jsondiff.KeySegment(k), value: v})
+				continue
+			}
+			for _, g := range a.ghosts[pathKey(path)] {
+				if !g.seg.IsIndex && g.seg.Key == k {
+					entries = append(entries, entry{seg: g.seg, value: g.value, ghost: true})
+				}
+			}
+		}
+	case []interface{}:
+		open, close = "[", "]"
+		var ghosts []ghost
+		if childMarker == markUnchanged {
+			for _, g := range a.ghosts[pathKey(path)] {
+				if g.seg.IsIndex {
+					ghosts = append(ghosts, g)
+				}
+			}
+			sort.Slice(ghosts, func(i, j int) bool { return ghosts[i].seg.Index < ghosts[j].seg.Index })
+		}
+		origins := elementOrigins(path, len(n), a.deltas)
+		for i, v := range n {
+			// Deleted elements are shown before the first element
+			// that was after them in the old array
+			for len(ghosts) > 0 && origins[i] > ghosts[0].seg.Index {
+				entries = append(entries, entry{seg: ghosts[0].seg, value: ghosts[0].value, ghost: true})
+				ghosts = ghosts[1:]
+			}
+			entries = append(entries, entry{seg: jsondiff.IndexSegment(i), value: v})
+		}
+		for _, g := range ghosts {
+			entries = append(entries, entry{seg: g.seg, value: g.value, ghost: true})
+		}
+	default:
+		a.line(marker, indent+label+compact(node)+suffix+comment)
+		return
+	}
+	if len(entries) == 0 {
+		a.line(marker, indent+label+open+close+suffix+comment)
+		return
+	}
+	a.line(marker, indent+label+open+comment)
+	for i, e := range entries {
+		childSuffix := ","
+		if i == len(entries)-1 {
+			childSuffix = ""
+		}
+		childLabel := ""
+		if !e.seg.IsIndex {
+			childLabel = compact(e.seg.Key) + ": "
+		}
+		if e.ghost {
+			a.container(nil, e.value, indent+a.opts.Indent, childLabel, a.ghostMarker, childSuffix, "")
+			continue
+		}
+		a.node(childPath(path, e.seg), e.value, indent+a.opts.Indent, childLabel, childMarker, childSuffix)
+	}
+	a.line(marker, indent+close+suffix)
+}
+
+// childPath returns a new field name for the child of path at seg
+func childPath(path jsondiff.FieldName, seg jsondiff.Segment) jsondiff.FieldName {
+	ret := make(jsondiff.FieldName, len(path)+1)
+	copy(ret, path)
+	ret[len(path)] = seg
+	return ret
+}
+
+// line writes a line prefixed with the marker
+func (a annotator) line(marker, text string) {
+	color := ""
+	switch marker {
+	case markInserted:
+		color = colorGreen
+	case markDeleted:
+		color = colorRed
+	case markChanged:
+		color = colorYellow
+	case markMoved:
+		color = colorCyan
+	}
+	if a.opts.Color && color != "" {
+		fmt.Fprintf(a.w, "%s%s %s%s\n", color, marker, text, colorReset)
+		return
+	}
+	fmt.Fprintf(a.w, "%s %s\n", marker, text)
+}
+
+// compact returns the compact JSON representation of v
+func compact(v interface{}) string {
+	data, err := jsondiff.CanonicalJSON(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// elementOrigins returns the old index of each element of the array
+// at path with n elements, or -1 for inserted and moved elements.
+// Elements that are not inserted or moved fill the positions of the
+// elements that are not deleted, in order
+func elementOrigins(path jsondiff.FieldName, n int, deltas []jsondiff.Delta) []int {
+	removed := make(map[int]bool)
+	added := make(map[int]bool)
+	isElement := func(name jsondiff.FieldName) bool {
+		return len(name) == len(path)+1 && name[len(path)].IsIndex && pathKey(name[:len(path)]) == pathKey(path)
+	}
+	for _, d := range deltas {
+		switch x := d.(type) {
+		case jsondiff.Deletion:
+			if isElement(x.Name) {
+				removed[x.Name[len(path)].Index] = true
+			}
+		case jsondiff.Insertion:
+			if isElement(x.Name) {
+				added[x.Name[len(path)].Index] = true
+			}
+		case jsondiff.Move:
+			if isElement(x.To) && isElement(x.From) {
+				removed[x.From[len(path)].Index] = true
+				added[x.To[len(path)].Index] = true
+			}
+		}
+	}
+	ret := make([]int, n)
+	old := 0
+	for i := range ret {
+		if added[i] {
+			ret[i] = -1
+			continue
+		}
+		for removed[old] {
+			old++
+		}
+		ret[i] = old
+		old++
+	}
+	return ret
+}
@@ -0,0 +1,321 @@
+// Package render renders jsondiff deltas in a human readable form,
+// similar to the output of git diff, and as JUnit XML and SARIF
+// reports for CI tools. It also renders documents as line based
+// unified diffs
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+// ANSI color codes
+const (
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+	colorReset  = "\x1b[0m"
+)
+
+// RenderOptions control how deltas are rendered
+type RenderOptions struct {
+	// Color enables ANSI colors: green for insertions, red for
+	// deletions, yellow for modifications, and cyan for moves and
+	// renames
+	Color bool
+	// Indent is the indentation used for nested values. If empty,
+	// two spaces are used
+	Indent string
+	// Doc is the original document. If set, up to Context unchanged
+	// fields around each change are printed from Doc
+	Doc interface{}
+	// Context is the number of unchanged fields printed before and
+	// after each change. Used only if Doc is set
+	Context int
+}
+
+// hunk contains the deltas under the same parent field
+type hunk struct {
+	parent jsondiff.FieldName
+	deltas []jsondiff.Delta
+}
+
+// RenderText writes the deltas to w. Deltas are grouped by their
+// parent field, and each group is printed with a header line giving
+// the parent field as a JSON pointer. Groups are sorted by the parent
+// field:
+//
+//	@@ /spec/ports @@
+//	- 0: 80
+//	+ 0: 8080
+//	~ name: "a" -> "b"
+//	> 1 -> 2
+//
+// Moves and renames are written with the old and the new index or key.
+func RenderText(w io.Writer, deltas []jsondiff.Delta, opts RenderOptions) error {
+	var docs []interface{}
+	if opts.Doc != nil {
+		docs = append(docs, opts.Doc)
+	}
+	return renderText(w, deltas, opts, docs)
+}
+
+// RenderWithContext writes the deltas from doc1 to doc2 to w like
+// RenderText, printing up to contextLines unchanged sibling fields or
+// elements before and after each change, similar to the context lines
+// of a unified diff. The siblings are taken from doc2, or from doc1 if
+// the parent of a change is not in doc2.
+func RenderWithContext(w io.Writer, doc1, doc2 interface{}, deltas []jsondiff.Delta, contextLines int) error {
+	return renderText(w, deltas, RenderOptions{Context: contextLines}, []interface{}{doc2, doc1})
+}
+
+// renderText writes the deltas to w. The context lines are looked up
+// in the first document of docs containing the parent of a change
+func renderText(w io.Writer, deltas []jsondiff.Delta, opts RenderOptions, docs []interface{}) error {
+	if opts.Indent == "" {
+		opts.Indent = "  "
+	}
+	hunks := make([]*hunk, 0)
+	hunkMap := make(map[string]*hunk)
+	for _, d := range deltas {
+		name := d.GetField()
+		var parent jsondiff.FieldName
+		if len(name) > 0 {
+			parent = name[:len(name)-1]
+		}
+		key := parent.String()
+		h, ok := hunkMap[key]
+		if !ok {
+			h = &hunk{parent: parent}
+			hunkMap[key] = h
+			hunks = append(hunks, h)
+		}
+		h.deltas = append(h.deltas, d)
+	}
+	sort.SliceStable(hunks, func(i, j int) bool {
+		return hunks[i].parent.String() < hunks[j].parent.String()
+	})
+	bw := bufio.NewWriter(w)
+	r := renderer{w: bw, opts: opts, docs: docs}
+	for _, h := range hunks {
+		r.hunk(h)
+	}
+	return bw.Flush()
+}
+
+type renderer struct {
+	w    *bufio.Writer
+	opts RenderOptions
+	docs []interface{}
+}
+
+func (r renderer) hunk(h *hunk) {
+	header := h.parent.Pointer()
+	if header == "" {
+		header = "(root)"
+	}
+	fmt.Fprintf(r.w, "@@ %s @@\n", header)
+	var parentNode interface{}
+	if r.opts.Context > 0 {
+		for _, doc := range r.docs {
+			if n, ok := lookup(doc, h.parent); ok {
+				parentNode = n
+				break
+			}
+		}
+	}
+	siblings := children(parentNode)
+	for _, d := range h.deltas {
+		// A change of the document root replaces the whole document,
+		// so there are no siblings to print as context
+		if len(d.GetField()) == 0 {
+			siblings = nil
+			break
+		}
+	}
+	if len(siblings) == 0 {
+		for _, d := range h.deltas {
+			r.delta(d)
+		}
+		return
+	}
+	// Merge changed segments into the sibling list, and print
+	// unchanged siblings close to a change as context
+	changes := make(map[jsondiff.Segment][]jsondiff.Delta)
+	for _, d := range h.deltas {
+		name := d.GetField()
+		seg := name[len(name)-1]
+		if _, ok := changes[seg]; !ok {
+			found := false
+			for _, s := range siblings {
+				if s == seg {
+					found = true
+					break
+				}
+			}
+			if !found {
+				siblings = append(siblings, seg)
+			}
+		}
+		changes[seg] = append(changes[seg], d)
+	}
+	sortSegments(siblings)
+	for i, s := range siblings {
+		if ds, ok := changes[s]; ok {
+			for _, d := range ds {
+				r.delta(d)
+			}
+			continue
+		}
+		near := false
+		for j := i - r.opts.Context; j <= i+r.opts.Context && !near; j++ {
+			if j >= 0 && j < len(siblings) {
+				_, near = changes[siblings[j]]
+			}
+		}
+		if near {
+			v, _ := lookup(parentNode, jsondiff.FieldName{s})
+			r.line(" ", "", label(s)+r.value(v, " "))
+		}
+	}
+}
+
+func (r renderer) delta(d jsondiff.Delta) {
+	name := d.GetField()
+	var l string
+	if len(name) > 0 {
+		l = label(name[len(name)-1])
+	}
+	switch x := d.(type) {
+	case jsondiff.Insertion:
+		r.line("+", colorGreen, l+r.value(x.NewNode, "+"))
+	case jsondiff.Deletion:
+		r.line("-", colorRed, l+r.value(x.DeletedNode, "-"))
+	case jsondiff.Modification:
+		if len(x.TextDiff) > 0 {
+			r.line("~", colorYellow, l+textDiff(x.TextDiff))
+			return
+		}
+		r.line("~", colorYellow, l+r.value(x.Old, "~")+" -> "+r.value(x.New, "~"))
+	case jsondiff.Move:
+		from := ""
+		if len(x.From) > 0 {
+			from = x.From[len(x.From)-1].String()
+		}
+		r.line(">", colorCyan, from+" -> "+x.To[len(x.To)-1].String())
+	case jsondiff.Rename:
+		r.line(">", colorCyan, jsondiff.KeySegment(x.OldKey).String()+" -> "+jsondiff.KeySegment(x.NewKey).String())
+	default:
+		r.line("?", "", fmt.Sprint(d))
+	}
+}
+
+// line writes text prefixed with marker. Multi-line text is written
+// with the marker repeated at the beginning of each line
+func (r renderer) line(marker, color, text string) {
+	if r.opts.Color && color != "" {
+		fmt.Fprintf(r.w, "%s%s %s%s\n", color, marker, text, colorReset)
+		return
+	}
+	fmt.Fprintf(r.w, "%s %s\n", marker, text)
+}
+
+// value returns the indented JSON representation of v. Continuation
+// lines are prefixed with marker
+func (r renderer) value(v interface{}, marker string) string {
+	data, err := jsondiff.CanonicalJSON(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, marker+" ", r.opts.Indent); err != nil {
+		return string(data)
+	}
+	return buf.String()
+}
+
+// textDiff returns the text diff in word diff format, where deleted
+// text is written as [-text-] and inserted text as {+text+}.
+// Continuation lines are prefixed with "~"
+func textDiff(edits []jsondiff.TextEdit) string {
+	var sb strings.Builder
+	for _, e := range edits {
+		switch e.Op {
+		case jsondiff.TextDelete:
+			sb.WriteString("[-" + e.Text + "-]")
+		case jsondiff.TextInsert:
+			sb.WriteString("{+" + e.Text + "+}")
+		default:
+			sb.WriteString(e.Text)
+		}
+	}
+	return strings.ReplaceAll(strings.TrimSuffix(sb.String(), "\n"), "\n", "\n~ ")
+}
+
+// label returns the "key: " prefix for a segment
+func label(s jsondiff.Segment) string {
+	return s.String() + ": "
+}
+
+// lookup returns the node at the given path
+func lookup(doc interface{}, path jsondiff.FieldName) (interface{}, bool) {
+	for _, s := range path {
+		switch n := doc.(type) {
+		case map[string]interface{}:
+			if s.IsIndex {
+				return nil, false
+			}
+			v, ok := n[s.Key]
+			if !ok {
+				return nil, false
+			}
+			doc = v
+		case []interface{}:
+			if !s.IsIndex || s.Index < 0 || s.Index >= len(n) {
+				return nil, false
+			}
+			doc = n[s.Index]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// children returns the segments for the children of a container node
+func children(node interface{}) []jsondiff.Segment {
+	var ret []jsondiff.Segment
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for k := range n {
+			ret = append(ret, jsondiff.KeySegment(k))
+		}
+	case []interface{}:
+		for i := range n {
+			ret = append(ret, jsondiff.IndexSegment(i))
+		}
+	}
+	return ret
+}
+
+// sortSegments sorts indexes numerically, and keys alphabetically
+func sortSegments(segments []jsondiff.Segment) {
+	sort.Slice(segments, func(i, j int) bool {
+		a, b := segments[i], segments[j]
+		if a.IsIndex && b.IsIndex {
+			return a.Index < b.Index
+		}
+		if a.IsIndex != b.IsIndex {
+			return a.IsIndex
+		}
+		return strings.Compare(a.Key, b.Key) < 0
+	})
+}
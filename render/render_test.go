@@ -0,0 +1,202 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+func parse(s string) (interface{}, error) {
+	var doc interface{}
+	e := json.Unmarshal([]byte(s), &doc)
+	return doc, e
+}
+
+func TestRenderText(t *testing.T) {
+	doc1, err := parse(`{"a":1,"b":2,"c":3,"d":4,"e":5,"f":[1,2]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"a":1,"b":2,"c":30,"d":4,"e":5,"f":[2,1]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	var buf bytes.Buffer
+	err = RenderText(&buf, jsondiff.Difference(doc1, doc2), RenderOptions{Doc: doc1, Context: 1})
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected := `@@ (root) @@
+  b: 2
+~ c: 3 -> 30
+  d: 4
+@@ /f @@
+> 1 -> 0
+  1: 2
+`
+	if buf.String() != expected {
+		t.Errorf("Wrong output: %s", buf.String())
+	}
+}
+
+func TestRenderWithContext(t *testing.T) {
+	doc1, _ := parse(`{"spec":{"a":1,"b":2,"c":3,"d":4,"e":5,"g":7}}`)
+	doc2, _ := parse(`{"spec":{"a":1,"b":2,"c":3,"d":40,"e":5,"f":6}}`)
+	var buf bytes.Buffer
+	err := RenderWithContext(&buf, doc1, doc2, jsondiff.Difference(doc1, doc2), 2)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected := `@@ /spec @@
+  b: 2
+  c: 3
+~ d: 4 -> 40
+  e: 5
+~ f: null -> 6
+~ g: 7 -> null
+`
+	if buf.String() != expected {
+		t.Errorf("Wrong output: %s", buf.String())
+	}
+}
+
+func TestRenderRoot(t *testing.T) {
+	doc1, _ := parse(`{"a":1}`)
+	doc2, _ := parse(`[1]`)
+	deltas := jsondiff.Difference(doc1, doc2)
+	expected := `@@ (root) @@
+~ {
+~   "a": 1
+~ } -> [
+~   1
+~ ]
+`
+	var buf bytes.Buffer
+	if err := RenderText(&buf, deltas, RenderOptions{Doc: doc1, Context: 1}); err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	if buf.String() != expected {
+		t.Errorf("Wrong output: %s", buf.String())
+	}
+	buf.Reset()
+	if err := RenderWithContext(&buf, doc1, doc2, deltas, 1); err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	if buf.String() != expected {
+		t.Errorf("Wrong output: %s", buf.String())
+	}
+}
+
+func TestRenderTextColor(t *testing.T) {
+	deltas := []jsondiff.Delta{
+		jsondiff.Insertion{Name: jsondiff.FieldName{jsondiff.KeySegment("a"), jsondiff.IndexSegment(0)},
+			NewNode: map[string]interface{}{"x": 1}},
+		jsondiff.Deletion{Name: jsondiff.FieldName{jsondiff.KeySegment("b")}, DeletedNode: "y"},
+	}
+	var buf bytes.Buffer
+	err := RenderText(&buf, deltas, RenderOptions{Color: true})
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected := "@@ (root) @@\n" +
+		colorRed + "- b: \"y\"" + colorReset + "\n" +
+		"@@ /a @@\n" +
+		colorGreen + "+ 0: {\n+   \"x\": 1\n+ }" + colorReset + "\n"
+	if buf.String() != expected {
+		t.Errorf("Wrong output: %q", buf.String())
+	}
+}
+
+func TestRenderTextDiff(t *testing.T) {
+	doc1, _ := parse(`{"desc":"the quick fox\njumps"}`)
+	doc2, _ := parse(`{"desc":"the slow fox\njumps"}`)
+	deltas := jsondiff.NewDiffer(jsondiff.TextDiffs(0, jsondiff.TextWords)).Difference(doc1, doc2)
+	var buf bytes.Buffer
+	RenderText(&buf, deltas, RenderOptions{})
+	expected := `@@ (root) @@
+~ desc: the [-quick-]{+slow+} fox
+~ jumps
+`
+	if buf.String() != expected {
+		t.Errorf("Wrong output: %s", buf.String())
+	}
+}
+
+func TestRenderAnnotated(t *testing.T) {
+	doc1, _ := parse(`{"a":1,"b":"x","c":[1,2,3],"d":{"e":true},"f":[5,6]}`)
+	doc2, _ := parse(`{"a":2,"c":[1,3,4],"d":{"e":true},"f":[6,5],"g":{"h":null}}`)
+	var buf bytes.Buffer
+	err := RenderAnnotated(&buf, doc2, jsondiff.Difference(doc1, doc2), RenderOptions{})
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected := `  {
+~   "a": 2,  // was 1
+-   "b": "x",
+    "c": [
+      1,
+-     2,
+      3,
++     4
+    ],
+    "d": {
+      "e": true
+    },
+    "f": [
+>     6,  // moved from 1
+      5
+    ],
++   "g": {
++     "h": null
++   }
+  }
+`
+	if buf.String() != expected {
+		t.Errorf("Wrong output:\n%s", buf.String())
+	}
+}
+
+func TestRenderAnnotatedOld(t *testing.T) {
+	doc1, _ := parse(`{"a":1,"b":"x","c":[1,2,3],"d":{"e":true},"f":[5,6]}`)
+	doc2, _ := parse(`{"a":2,"c":[1,3,4],"d":{"e":true},"f":[6,5],"g":{"h":null}}`)
+	var buf bytes.Buffer
+	err := RenderAnnotatedOld(&buf, doc1, jsondiff.Difference(doc1, doc2), RenderOptions{})
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected := `  {
+~   "a": 1,  // becomes 2
+-   "b": "x",
+    "c": [
+      1,
+-     2,
+      3,
++     4
+    ],
+    "d": {
+      "e": true
+    },
+    "f": [
+      5,
+>     6  // moves to 0
+    ],
++   "g": {
++     "h": null
++   }
+  }
+`
+	if buf.String() != expected {
+		t.Errorf("Wrong output:\n%s", buf.String())
+	}
+}
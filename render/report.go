@@ -0,0 +1,198 @@
+package render
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+// change describes a delta for the report formats
+type change struct {
+	// kind is inserted, deleted, moved, modified, or renamed
+	kind string
+	// path is the JSON pointer of the changed field
+	path    string
+	message string
+}
+
+// describe returns the description of a delta
+func describe(d jsondiff.Delta) change {
+	path := d.GetField().Pointer()
+	if path == "" {
+		path = "(root)"
+	}
+	c := change{path: path}
+	switch x := d.(type) {
+	case jsondiff.Insertion:
+		c.kind = "inserted"
+		c.message = fmt.Sprintf("%s inserted: %s", path, compact(x.NewNode))
+	case jsondiff.Deletion:
+		c.kind = "deleted"
+		c.message = fmt.Sprintf("%s deleted: %s", path, compact(x.DeletedNode))
+	case jsondiff.Move:
+		c.kind = "moved"
+		c.message = fmt.Sprintf("%s moved from %s", path, x.From.Pointer())
+	case jsondiff.Modification:
+		c.kind = "modified"
+		c.message = fmt.Sprintf("%s modified: %s -> %s", path, compact(x.Old), compact(x.New))
+	case jsondiff.Rename:
+		c.kind = "renamed"
+		c.message = fmt.Sprintf("%s renamed from %s", path, compact(x.OldKey))
+	default:
+		c.kind = "changed"
+		c.message = fmt.Sprintf("%s changed: %v", path, d)
+	}
+	return c
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// RenderJUnit writes the deltas as a JUnit XML report with a test
+// suite named suite. Each delta is a failed test case named by the
+// JSON pointer of the changed field. If there are no deltas, the
+// report contains a single passed test case named suite, so the
+// comparison shows up in test report viewers either way.
+func RenderJUnit(w io.Writer, suite string, deltas []jsondiff.Delta) error {
+	s := junitTestSuite{Name: suite}
+	for _, d := range deltas {
+		c := describe(d)
+		s.Cases = append(s.Cases, junitTestCase{Name: c.path,
+			ClassName: suite,
+			Failure:   &junitFailure{Message: c.message, Type: c.kind, Text: fmt.Sprint(d)}})
+	}
+	s.Failures = len(s.Cases)
+	if len(s.Cases) == 0 {
+		s.Cases = []junitTestCase{{Name: suite, ClassName: suite}}
+	}
+	s.Tests = len(s.Cases)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{s}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifRules are the rules of the SARIF report, one for each kind of
+// change
+var sarifRules = []sarifRule{
+	{ID: "inserted", ShortDescription: sarifMessage{Text: "Node inserted"}},
+	{ID: "deleted", ShortDescription: sarifMessage{Text: "Node deleted"}},
+	{ID: "moved", ShortDescription: sarifMessage{Text: "Array element moved"}},
+	{ID: "modified", ShortDescription: sarifMessage{Text: "Value modified"}},
+	{ID: "renamed", ShortDescription: sarifMessage{Text: "Field renamed"}},
+	{ID: "changed", ShortDescription: sarifMessage{Text: "Node changed"}},
+}
+
+// RenderSARIF writes the deltas as a SARIF 2.1.0 log with one result
+// for each delta, for code scanning tools. The rule of a result is
+// the kind of change, and its logical location is the JSON pointer of
+// the changed field. If artifact is not empty, it is the URI of the
+// compared document, given as the physical location of the results.
+// Results have the "error" level.
+func RenderSARIF(w io.Writer, artifact string, deltas []jsondiff.Delta) error {
+	run := sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: "jsondiff",
+		InformationURI: "https://github.com/bserdar/jsondiff",
+		Rules:          sarifRules}},
+		Results: []sarifResult{}}
+	for _, d := range deltas {
+		c := describe(d)
+		loc := sarifLocation{LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: c.path, Kind: "member"}}}
+		if artifact != "" {
+			loc.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: artifact}}
+		}
+		run.Results = append(run.Results, sarifResult{RuleID: c.kind,
+			Level:     "error",
+			Message:   sarifMessage{Text: c.message},
+			Locations: []sarifLocation{loc}})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Version: "2.1.0",
+		Schema: "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:   []sarifRun{run}})
+}
@@ -0,0 +1,81 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+func TestRenderJUnit(t *testing.T) {
+	doc1, _ := parse(`{"a":1,"b":"<x>"}`)
+	doc2, _ := parse(`{"a":2}`)
+	var buf bytes.Buffer
+	if err := RenderJUnit(&buf, "contract", jsondiff.Difference(doc1, doc2)); err != nil {
+		t.Fatal(err)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid XML: %s %s", err, buf.String())
+	}
+	s := report.Suites[0]
+	if s.Name != "contract" || s.Tests != 2 || s.Failures != 2 {
+		t.Errorf("Wrong suite: %s", buf.String())
+	}
+	for _, c := range s.Cases {
+		switch c.Name {
+		case "/a":
+			if c.Failure == nil || c.Failure.Type != "modified" || c.Failure.Message != "/a modified: 1 -> 2" {
+				t.Errorf("Wrong test case: %+v", c)
+			}
+		case "/b":
+			if c.Failure == nil || !strings.Contains(c.Failure.Message, "/b modified") {
+				t.Errorf("Wrong test case: %+v", c)
+			}
+		default:
+			t.Errorf("Unexpected test case: %+v", c)
+		}
+	}
+
+	buf.Reset()
+	if err := RenderJUnit(&buf, "contract", nil); err != nil {
+		t.Fatal(err)
+	}
+	report = junitTestSuites{}
+	xml.Unmarshal(buf.Bytes(), &report)
+	if s := report.Suites[0]; s.Tests != 1 || s.Failures != 0 || s.Cases[0].Failure != nil {
+		t.Errorf("Wrong empty report: %s", buf.String())
+	}
+}
+
+func TestRenderSARIF(t *testing.T) {
+	doc1, _ := parse(`{"a":[1,2],"c":1}`)
+	doc2, _ := parse(`{"a":[2,1,3]}`)
+	var buf bytes.Buffer
+	if err := RenderSARIF(&buf, "api/contract.json", jsondiff.Difference(doc1, doc2)); err != nil {
+		t.Fatal(err)
+	}
+	var log map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid JSON: %s", err)
+	}
+	if log["version"] != "2.1.0" {
+		t.Errorf("Wrong version: %v", log["version"])
+	}
+	results := log["runs"].([]interface{})[0].(map[string]interface{})["results"].([]interface{})
+	rules := map[string]bool{}
+	for _, r := range results {
+		result := r.(map[string]interface{})
+		rules[result["ruleId"].(string)] = true
+		loc := result["locations"].([]interface{})[0].(map[string]interface{})
+		if loc["physicalLocation"].(map[string]interface{})["artifactLocation"].(map[string]interface{})["uri"] != "api/contract.json" {
+			t.Errorf("Wrong location: %v", loc)
+		}
+	}
+	if len(results) != 3 || !rules["inserted"] || !rules["moved"] || !rules["modified"] {
+		t.Errorf("Wrong results: %s", buf.String())
+	}
+}
@@ -0,0 +1,145 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+// unifiedLine is a line of a unified diff
+type unifiedLine struct {
+	// op is ' ' for unchanged lines, '-' for deleted lines, and '+'
+	// for inserted lines
+	op   byte
+	text string
+}
+
+// RenderUnified writes a classic unified diff of two documents to w,
+// for tools that only understand line based diffs. The documents are
+// printed as indented JSON with sorted keys, and compared line by
+// line. name1 and name2 are written in the "---" and "+++" header
+// lines, and up to context unchanged lines are written around each
+// change. Nothing is written if the documents are printed the same.
+//
+//	--- old.json
+//	+++ new.json
+//	@@ -1,4 +1,4 @@
+//	 {
+//	-  "a": 1,
+//	+  "a": 2,
+//	   "b": 2
+//	 }
+func RenderUnified(w io.Writer, name1 string, doc1 interface{}, name2 string, doc2 interface{}, context int) error {
+	text1, err := indented(doc1)
+	if err != nil {
+		return err
+	}
+	text2, err := indented(doc2)
+	if err != nil {
+		return err
+	}
+	var lines []unifiedLine
+	for _, e := range jsondiff.TextDifference(text1, text2, jsondiff.TextLines) {
+		op := byte(' ')
+		switch e.Op {
+		case jsondiff.TextDelete:
+			op = '-'
+		case jsondiff.TextInsert:
+			op = '+'
+		}
+		for _, l := range strings.SplitAfter(strings.TrimSuffix(e.Text, "\n"), "\n") {
+			lines = append(lines, unifiedLine{op: op, text: strings.TrimSuffix(l, "\n")})
+		}
+	}
+	// oldLine[i] and newLine[i] are the number of lines of each
+	// document before lines[i]
+	oldLine := make([]int, len(lines)+1)
+	newLine := make([]int, len(lines)+1)
+	for i, l := range lines {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if l.op != '+' {
+			oldLine[i+1]++
+		}
+		if l.op != '-' {
+			newLine[i+1]++
+		}
+	}
+	bw := bufio.NewWriter(w)
+	header := false
+	for i := 0; i < len(lines); {
+		for i < len(lines) && lines[i].op == ' ' {
+			i++
+		}
+		if i == len(lines) {
+			break
+		}
+		// Changes separated by at most 2*context unchanged lines are
+		// in the same hunk
+		end := i
+		for {
+			for end < len(lines) && lines[end].op != ' ' {
+				end++
+			}
+			next := end
+			for next < len(lines) && lines[next].op == ' ' {
+				next++
+			}
+			if next == len(lines) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		stop := end + context
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+		if !header {
+			fmt.Fprintf(bw, "--- %s\n+++ %s\n", name1, name2)
+			header = true
+		}
+		fmt.Fprintf(bw, "@@ -%s +%s @@\n",
+			hunkRange(oldLine[start], oldLine[stop]-oldLine[start]),
+			hunkRange(newLine[start], newLine[stop]-newLine[start]))
+		for _, l := range lines[start:stop] {
+			fmt.Fprintf(bw, "%c%s\n", l.op, l.text)
+		}
+		i = stop
+	}
+	return bw.Flush()
+}
+
+// hunkRange returns the range of a hunk header, given the number of
+// lines before the hunk and the number of lines in the hunk
+func hunkRange(before, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprint(before + 1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, n)
+}
+
+// indented returns the document as indented JSON with sorted keys,
+// ending with a newline
+func indented(doc interface{}) (string, error) {
+	data, err := jsondiff.CanonicalJSON(doc)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return "", err
+	}
+	buf.WriteByte('\n')
+	return buf.String(), nil
+}
@@ -0,0 +1,39 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRenderUnified(t *testing.T) {
+	doc1, _ := parse(`{"a":1,"b":2,"c":3,"d":4,"e":5,"f":6,"g":7,"h":8,"i":9}`)
+	doc2, _ := parse(`{"a":10,"b":2,"c":3,"d":4,"e":5,"f":6,"g":7,"h":8,"j":9}`)
+	var buf bytes.Buffer
+	if err := RenderUnified(&buf, "old.json", doc1, "new.json", doc2, 2); err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected := `--- old.json
++++ new.json
+@@ -1,4 +1,4 @@
+ {
+-  "a": 1,
++  "a": 10,
+   "b": 2,
+   "c": 3,
+@@ -8,4 +8,4 @@
+   "g": 7,
+   "h": 8,
+-  "i": 9
++  "j": 9
+ }
+`
+	if buf.String() != expected {
+		t.Errorf("Wrong output: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := RenderUnified(&buf, "a", doc1, "b", doc1, 3); err != nil || buf.Len() != 0 {
+		t.Errorf("Wrong output for equal documents: %s %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,16 @@
+package jsondiff
+
+import (
+	_ "embed"
+)
+
+//go:embed delta.schema.json
+var deltaSchema []byte
+
+// DeltaSchema returns the JSON Schema describing a serialized delta
+// of the current FormatVersion, as written by the MarshalJSON methods
+// of the delta types. The schema can be used by non-Go consumers to
+// validate deltas and generate bindings.
+func DeltaSchema() []byte {
+	return append([]byte(nil), deltaSchema...)
+}
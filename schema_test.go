@@ -0,0 +1,33 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDeltaSchema(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(DeltaSchema(), &schema); err != nil {
+		t.Errorf("Invalid schema: %s", err)
+		return
+	}
+	props := schema["properties"].(map[string]interface{})
+	if v := props["v"].(map[string]interface{})["const"]; v != float64(FormatVersion) {
+		t.Errorf("Schema version %v does not match format version %d", v, FormatVersion)
+	}
+	// All fields written by the encoder are described by the schema
+	deltas := []Delta{Insertion{Name: FieldName{KeySegment("a")}, NewNode: 1},
+		Move{From: FieldName{IndexSegment(0)}, To: FieldName{IndexSegment(1)}, Old: 1, New: 1},
+		Modification{Name: FieldName{KeySegment("a")}, Old: "x y", New: "x z",
+			TextDiff: TextDifference("x y", "x z", TextWords), TypeChanged: true}}
+	for _, d := range deltas {
+		data, _ := json.Marshal(d)
+		var fields map[string]interface{}
+		json.Unmarshal(data, &fields)
+		for k := range fields {
+			if _, ok := props[k]; !ok {
+				t.Errorf("Field %s is not in schema", k)
+			}
+		}
+	}
+}
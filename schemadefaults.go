@@ -0,0 +1,129 @@
+package jsondiff
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxSchemaRefs is the maximum number of schema references followed
+// while looking up the schema of a field, to stop at cyclic references
+const maxSchemaRefs = 32
+
+// SchemaDefaults uses the defaults declared in a JSON Schema to
+// ignore fields that are missing from one document and set to their
+// default value in the other. schema is a decoded JSON Schema, the
+// result of json.Unmarshal(&interface{}). The schemas of fields are
+// found using properties, patternProperties, additionalProperties,
+// items, prefixItems, local $refs, and allOf, anyOf, and oneOf. If any
+// of the schemas of a field has a default equal to the field value,
+// the missing field is not reported. Array elements that differ only
+// in such fields are matched as equal.
+func SchemaDefaults(schema interface{}) Option {
+	return func(d *Differ) {
+		d.schema = schema
+	}
+}
+
+// isSchemaDefault returns if value is the default value of the field
+// in the schema given by SchemaDefaults
+func (d *Differ) isSchemaDefault(name FieldName, value interface{}) bool {
+	if d.schema == nil {
+		return false
+	}
+	for _, s := range schemasAt(d.schema, d.schema, name) {
+		if def, ok := s["default"]; ok && IsEqual(def, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemasAt returns the schemas that apply to the field at name,
+// starting from schema
+func schemasAt(root, schema interface{}, name FieldName) []map[string]interface{} {
+	schemas := expandSchema(root, schema, nil, 0)
+	if len(name) == 0 {
+		return schemas
+	}
+	seg := name[0]
+	var next []interface{}
+	for _, s := range schemas {
+		if seg.IsIndex {
+			if prefix, ok := s["prefixItems"].([]interface{}); ok && seg.Index < len(prefix) {
+				next = append(next, prefix[seg.Index])
+				continue
+			}
+			switch items := s["items"].(type) {
+			case map[string]interface{}:
+				next = append(next, items)
+			case []interface{}:
+				if seg.Index < len(items) {
+					next = append(next, items[seg.Index])
+				} else if additional, ok := s["additionalItems"].(map[string]interface{}); ok {
+					next = append(next, additional)
+				}
+			}
+			continue
+		}
+		if props, ok := s["properties"].(map[string]interface{}); ok {
+			if p, ok := props[seg.Key]; ok {
+				next = append(next, p)
+				continue
+			}
+		}
+		matched := false
+		if patterns, ok := s["patternProperties"].(map[string]interface{}); ok {
+			for pattern, p := range patterns {
+				if re, err := regexp.Compile(pattern); err == nil && re.MatchString(seg.Key) {
+					next = append(next, p)
+					matched = true
+				}
+			}
+		}
+		if additional, ok := s["additionalProperties"].(map[string]interface{}); ok && !matched {
+			next = append(next, additional)
+		}
+	}
+	var ret []map[string]interface{}
+	for _, s := range next {
+		ret = append(ret, schemasAt(root, s, name[1:])...)
+	}
+	return ret
+}
+
+// expandSchema returns schema, and the schemas it references using
+// $ref, allOf, anyOf, and oneOf
+func expandSchema(root, schema interface{}, ret []map[string]interface{}, nRefs int) []map[string]interface{} {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return ret
+	}
+	ret = append(ret, s)
+	if ref, ok := s["$ref"].(string); ok && nRefs < maxSchemaRefs {
+		if target, ok := resolveSchemaRef(root, ref); ok {
+			ret = expandSchema(root, target, ret, nRefs+1)
+		}
+	}
+	for _, kw := range []string{"allOf", "anyOf", "oneOf"} {
+		if subs, ok := s[kw].([]interface{}); ok {
+			for _, sub := range subs {
+				ret = expandSchema(root, sub, ret, nRefs)
+			}
+		}
+	}
+	return ret
+}
+
+// resolveSchemaRef resolves a local schema reference of the form
+// "#/json/pointer"
+func resolveSchemaRef(root interface{}, ref string) (interface{}, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+	tokens, err := parsePointer(ref[1:])
+	if err != nil {
+		return nil, false
+	}
+	target, err := pointerGet(root, tokens)
+	return target, err == nil
+}
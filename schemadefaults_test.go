@@ -0,0 +1,43 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestSchemaDefaults(t *testing.T) {
+	schema, _ := parse(`{
+  "type": "object",
+  "properties": {
+    "replicas": {"type": "integer", "default": 1},
+    "ports": {"type": "array", "items": {"$ref": "#/$defs/port"}},
+    "labels": {"type": "object", "additionalProperties": {"type": "string", "default": ""}}
+  },
+  "$defs": {
+    "port": {
+      "type": "object",
+      "allOf": [{"properties": {"protocol": {"default": "TCP"}}}]
+    }
+  }
+}`)
+	doc1, _ := parse(`{"ports":[{"port":80}],"labels":{"a":"x"},"extra":1}`)
+	doc2, _ := parse(`{"replicas":1,"ports":[{"port":80,"protocol":"TCP"}],"labels":{"a":"x","b":""}}`)
+	deltas := NewDiffer(SchemaDefaults(schema)).Difference(doc1, doc2)
+	if len(deltas) != 1 || deltas[0].GetField().String() != "extra" {
+		t.Errorf("Unexpected diff: %v", deltas)
+	}
+	deltas = NewDiffer(SchemaDefaults(schema)).Difference(doc2, doc1)
+	if len(deltas) != 1 || deltas[0].GetField().String() != "extra" {
+		t.Errorf("Unexpected diff: %v", deltas)
+	}
+	// Values different from the default are reported
+	doc2, _ = parse(`{"replicas":2,"ports":[{"port":80,"protocol":"UDP"}],"labels":{"a":"x"},"extra":1}`)
+	deltas = NewDiffer(SchemaDefaults(schema)).Difference(doc1, doc2)
+	if len(deltas) != 3 {
+		t.Errorf("Unexpected diff: %v", deltas)
+	}
+	// Without the option, defaults are reported
+	doc2, _ = parse(`{"replicas":1,"ports":[{"port":80}],"labels":{"a":"x"},"extra":1}`)
+	if deltas := Difference(doc1, doc2); len(deltas) != 1 {
+		t.Errorf("Unexpected diff: %v", deltas)
+	}
+}
@@ -0,0 +1,113 @@
+package jsondiff
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// scratchPool holds the buffers that a Differ keeps between
+// difference computations, so a Differ that is used for many
+// documents does not allocate them for every document. Concurrent
+// computations take different buffers from the pool. The pool is
+// shared by the copies of the Differ made for each computation
+type scratchPool struct {
+	pool sync.Pool
+	// generation is incremented by Reset. Buffers of earlier
+	// generations are dropped instead of being reused
+	generation atomic.Int64
+}
+
+// scratch is the set of buffers used by one difference computation
+type scratch struct {
+	generation int64
+	hashes     hashCache
+	classes    map[uint64]int
+	lcs        []int32
+}
+
+// classRep is the representative node of a class of equal array
+// elements. next is the next class with the same hash, or -1
+type classRep struct {
+	node interface{}
+	next int
+}
+
+// Reset releases the buffers pooled by the Differ between difference
+// computations. The buffers grow to fit the largest documents
+// compared, so call Reset after comparing unusually large documents to
+// return the memory. The options of the Differ are not changed. Reset
+// can be called while the Differ is in use.
+func (d *Differ) Reset() {
+	if d.pool != nil {
+		d.pool.generation.Add(1)
+	}
+}
+
+// acquire returns scratch buffers from the pool of the Differ, or new
+// buffers
+func (d *Differ) acquire() *scratch {
+	if d.pool == nil {
+		return &scratch{hashes: make(hashCache)}
+	}
+	generation := d.pool.generation.Load()
+	if s, ok := d.pool.pool.Get().(*scratch); ok && s.generation == generation {
+		// Nodes are identified by their addresses, which can be
+		// reused by the nodes of the next document
+		clear(s.hashes)
+		return s
+	}
+	return &scratch{generation: generation, hashes: make(hashCache)}
+}
+
+// release returns the scratch buffers acquired for the run to the pool
+func (d *Differ) release(run *Differ) {
+	if d.pool != nil && run.scratch.generation == d.pool.generation.Load() {
+		d.pool.pool.Put(run.scratch)
+	}
+}
+
+// classMap returns an empty map to assign element classes. Give it
+// back with putClassMap
+func (d *Differ) classMap() map[uint64]int {
+	if d.scratch == nil {
+		return make(map[uint64]int)
+	}
+	m := d.scratch.classes
+	if m == nil {
+		return make(map[uint64]int)
+	}
+	d.scratch.classes = nil
+	return m
+}
+
+// putClassMap returns the map obtained from classMap for reuse
+func (d *Differ) putClassMap(m map[uint64]int) {
+	if d.scratch == nil {
+		return
+	}
+	clear(m)
+	d.scratch.classes = m
+}
+
+// lcsTable returns a zeroed table of n cells. Give it back with
+// putLCSTable
+func (d *Differ) lcsTable(n int) []int32 {
+	if d.scratch == nil {
+		return make([]int32, n)
+	}
+	t := d.scratch.lcs
+	d.scratch.lcs = nil
+	if cap(t) < n {
+		return make([]int32, n)
+	}
+	t = t[:n]
+	clear(t)
+	return t
+}
+
+// putLCSTable returns the table obtained from lcsTable for reuse
+func (d *Differ) putLCSTable(t []int32) {
+	if d.scratch != nil {
+		d.scratch.lcs = t
+	}
+}
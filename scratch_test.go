@@ -0,0 +1,83 @@
+package jsondiff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// sortedDeltas returns the string representations of deltas in
+// sorted order, because the order of object fields is not fixed
+func sortedDeltas(deltas []Delta) []string {
+	ret := make([]string, len(deltas))
+	for i, d := range deltas {
+		ret[i] = fmt.Sprint(d)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func TestDifferReuse(t *testing.T) {
+	docs := []string{
+		`{"a":[1,2,3,{"b":1}],"c":[[1],[2]]}`,
+		`{"a":[3,1,{"b":2}],"c":[[2],[1],[3]]}`,
+		`{"a":[{"b":1},1,2,3],"c":[]}`,
+		`{"a":[1,2,3,{"b":1}],"c":[[1],[2]]}`,
+	}
+	d := NewDiffer()
+	for i := range docs {
+		for j := range docs {
+			doc1, _ := parse(docs[i])
+			doc2, _ := parse(docs[j])
+			reused := sortedDeltas(d.Difference(doc1, doc2))
+			fresh := sortedDeltas(NewDiffer().Difference(doc1, doc2))
+			if !reflect.DeepEqual(reused, fresh) {
+				t.Errorf("Different deltas for %d %d: %v %v", i, j, reused, fresh)
+			}
+		}
+		d.Reset()
+	}
+
+	// Concurrent computations do not share the buffers
+	doc1, _ := parse(docs[0])
+	doc2, _ := parse(docs[1])
+	expected := sortedDeltas(d.Difference(doc1, doc2))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if x := sortedDeltas(d.Difference(doc1, doc2)); !reflect.DeepEqual(x, expected) {
+				t.Errorf("Wrong deltas: %v", x)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDifferConcurrent(t *testing.T) {
+	doc1, _ := parse(`{"a":[{"id":1,"x":"a b c"},{"id":2}],"b":[3,1,2,[1]],"c":{"d":1},"e":[1,2,3,4,5]}`)
+	doc2, _ := parse(`{"a":[{"id":2},{"id":1,"x":"a c"}],"b":[1,2,[1],4],"c":{"f":1},"e":[5,4,3,2,1]}`)
+	d := NewDiffer(ArrayKey("a", "id"), TextDiffs(1, TextWords), DetectRenames(), WithAutoTune(),
+		MatchArrays("e", LCSMatcher()), TruncateValuesAt(3, 0))
+	expected := sortedDeltas(d.Difference(doc1, doc2))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if j == 25 && i == 0 {
+					d.Reset()
+				}
+				if x := sortedDeltas(d.Difference(doc1, doc2)); !reflect.DeepEqual(x, expected) {
+					t.Errorf("Wrong deltas: %v", x)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
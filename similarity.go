@@ -0,0 +1,59 @@
+package jsondiff
+
+// leafKey identifies a leaf node by its path, without the array
+// indexes, and the hash of its value
+type leafKey struct {
+	path string
+	hash int
+}
+
+// Similarity returns a score between 0 and 1 giving how similar two
+// documents are: the fraction of leaf nodes the documents share,
+// computed as 2*shared/(leaves1+leaves2). Leaf nodes are the values
+// that are not objects or arrays, and empty objects and arrays. Two
+// leaves are shared if they have the same value at the same path,
+// where array indexes are ignored, so reordered array elements do not
+// lower the score. Values are compared using NodeHash, so the score is
+// approximate. Identical documents have similarity 1, and documents
+// without common leaves have similarity 0.
+func Similarity(doc1, doc2 interface{}) float64 {
+	leaves := make(map[leafKey]int)
+	n1 := collectLeaves(doc1, "", func(k leafKey) { leaves[k]++ })
+	shared := 0
+	n2 := collectLeaves(doc2, "", func(k leafKey) {
+		if leaves[k] > 0 {
+			leaves[k]--
+			shared++
+		}
+	})
+	if n1+n2 == 0 {
+		return 1
+	}
+	return float64(2*shared) / float64(n1+n2)
+}
+
+// collectLeaves calls fn for each leaf of node at path, and returns
+// the number of leaves
+func collectLeaves(node interface{}, path string, fn func(leafKey)) int {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if len(n) > 0 {
+			count := 0
+			for k, v := range n {
+				count += collectLeaves(v, path+"/"+pointerEscaper.Replace(k), fn)
+			}
+			return count
+		}
+	case []interface{}:
+		if len(n) > 0 {
+			count := 0
+			for _, v := range n {
+				// Keys always follow a "/"
+				count += collectLeaves(v, path+"#", fn)
+			}
+			return count
+		}
+	}
+	fn(leafKey{path: path, hash: NodeHash(node)})
+	return 1
+}
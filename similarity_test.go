@@ -0,0 +1,30 @@
+package jsondiff
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimilarity(t *testing.T) {
+	for _, tc := range []struct {
+		doc1, doc2 string
+		expected   float64
+	}{
+		{`{"a":1,"b":[1,2]}`, `{"a":1,"b":[1,2]}`, 1},
+		{`{"a":1,"b":[1,2]}`, `{"b":[2,1],"a":1}`, 1},
+		{`{"a":1,"b":2}`, `{"a":1,"b":3}`, 0.5},
+		{`{"a":1,"b":2}`, `{"a":1}`, 2.0 / 3},
+		{`{"a":1}`, `{"b":1}`, 0},
+		{`{"a":[1,1]}`, `{"a":[1]}`, 2.0 / 3},
+		{`{"a":{}}`, `{"a":{}}`, 1},
+		{`{"a":{"*":1}}`, `{"a":[1]}`, 0},
+		{`null`, `null`, 1},
+		{`1`, `"1"`, 0},
+	} {
+		doc1, _ := parse(tc.doc1)
+		doc2, _ := parse(tc.doc2)
+		if s := Similarity(doc1, doc2); math.Abs(s-tc.expected) > 1e-9 {
+			t.Errorf("Wrong similarity for %s %s: %v, expected %v", tc.doc1, tc.doc2, s, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package jsondiff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// StableHashVersion is the version of the StableHash algorithm. It is
+// included in every hash returned by StableHash. The algorithm for a
+// version never changes. If the algorithm has to change, a new version
+// is introduced, so hashes with different versions must not be
+// compared.
+const StableHashVersion = "v1"
+
+// StableHash returns a hash of the node that is suitable for
+// storing. Unlike NodeHash, which is a fast hash used for
+// array matching and may change between package versions, StableHash
+// is a SHA-256 hash of a canonical encoding of the node. It does not
+// depend on the map iteration order, and it is stable across package
+// versions. The hash is returned as "<version>:<hex digest>".
+//
+// Numbers are encoded by their decimal value, so the same number
+// gives the same hash regardless of its Go type, including
+// json.Number. Integral values are written as integers, so 1e6 and
+// 1000000 are the same number, and so are -0 and 0.
+func StableHash(node interface{}) string {
+	h := sha256.New()
+	canonicalEncode(h, node)
+	return StableHashVersion + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalEncode writes the canonical encoding of the node to h. Each
+// value is written as a type tag followed by a length prefixed
+// content. Object keys are sorted.
+func canonicalEncode(h hash.Hash, node interface{}) {
+	writeItem := func(tag byte, content string) {
+		fmt.Fprintf(h, "%c%d:%s", tag, len(content), content)
+	}
+	switch n := node.(type) {
+	case nil:
+		writeItem('n', "")
+	case bool:
+		if n {
+			writeItem('b', "1")
+		} else {
+			writeItem('b', "0")
+		}
+	case string:
+		writeItem('s', n)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(h, "o%d:", len(keys))
+		for _, k := range keys {
+			writeItem('s', k)
+			canonicalEncode(h, n[k])
+		}
+	case []interface{}:
+		fmt.Fprintf(h, "a%d:", len(n))
+		for _, v := range n {
+			canonicalEncode(h, v)
+		}
+	default:
+		if s, ok := canonicalNumber(node); ok {
+			writeItem('d', s)
+		} else {
+			writeItem('?', fmt.Sprint(node))
+		}
+	}
+}
+
+// canonicalNumber returns the shortest decimal representation of a
+// numeric value. Integral values are written without an exponent
+func canonicalNumber(value interface{}) (string, bool) {
+	switch k := value.(type) {
+	case int:
+		return strconv.FormatInt(int64(k), 10), true
+	case int8:
+		return strconv.FormatInt(int64(k), 10), true
+	case int16:
+		return strconv.FormatInt(int64(k), 10), true
+	case int32:
+		return strconv.FormatInt(int64(k), 10), true
+	case int64:
+		return strconv.FormatInt(k, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(k), 10), true
+	case uint8:
+		return strconv.FormatUint(uint64(k), 10), true
+	case uint16:
+		return strconv.FormatUint(uint64(k), 10), true
+	case uint32:
+		return strconv.FormatUint(uint64(k), 10), true
+	case uint64:
+		return strconv.FormatUint(k, 10), true
+	case float32:
+		return canonicalFloat(float64(k), 32), true
+	case float64:
+		return canonicalFloat(k, 64), true
+	case json.Number:
+		if i, ok := new(big.Int).SetString(string(k), 10); ok {
+			return i.String(), true
+		}
+		f, err := k.Float64()
+		if err != nil {
+			return "", false
+		}
+		return canonicalFloat(f, 64), true
+	case big.Int:
+		return k.String(), true
+	case big.Float:
+		if k.IsInt() {
+			i, _ := k.Int(nil)
+			return i.String(), true
+		}
+		return k.Text('g', -1), true
+	}
+	return "", false
+}
+
+// canonicalFloat returns the decimal representation of a float with
+// the given bit size. Integral values are written as integers
+func canonicalFloat(f float64, bitSize int) string {
+	if f == math.Trunc(f) && !math.IsInf(f, 0) {
+		i, _ := big.NewFloat(f).Int(nil)
+		return i.String()
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
@@ -0,0 +1,54 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestStableHash(t *testing.T) {
+	doc1, err := parse(`{"a":1,"b":[true,null,"x"],"c":{"d":1.5,"e":"f"}}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"c":{"e":"f","d":1.5},"b":[true,null,"x"],"a":1}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	h := StableHash(doc1)
+	if !strings.HasPrefix(h, StableHashVersion+":") {
+		t.Errorf("Missing version: %s", h)
+	}
+	if h != StableHash(doc2) {
+		t.Errorf("Hashes differ: %s %s", h, StableHash(doc2))
+	}
+	// The hash must not change between versions
+	if h != "v1:0341c50a363a23ce15464f8ccf0114bdd40faa9b5a84633cae2740e84864cdaf" {
+		t.Errorf("Hash changed: %s", h)
+	}
+	if StableHash("ab") == StableHash("ba") {
+		t.Errorf("Hash collision")
+	}
+	if StableHash([]interface{}{"a", "b"}) == StableHash([]interface{}{"ab"}) {
+		t.Errorf("Hash collision")
+	}
+	same := [][]interface{}{
+		{1, 1.0, json.Number("1"), json.Number("1.0"), json.Number("1e0")},
+		{1000000, 1e6, json.Number("1e6"), uint64(1000000)},
+		{0, 0.0, math.Copysign(0, -1), json.Number("-0")},
+		{1.5, json.Number("1.5"), json.Number("15e-1"), float32(1.5)},
+	}
+	for _, values := range same {
+		for _, v := range values[1:] {
+			if StableHash(v) != StableHash(values[0]) {
+				t.Errorf("Numbers hash differently: %v (%T) and %v (%T)", values[0], values[0], v, v)
+			}
+		}
+	}
+	if StableHash(1) == StableHash(json.Number("1.5")) {
+		t.Errorf("Hash collision")
+	}
+}
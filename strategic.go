@@ -0,0 +1,43 @@
+package jsondiff
+
+// strategicMergeKeys are the merge keys of the lists of Kubernetes
+// manifests, in the order they are tried. Lists of objects not listed
+// here are keyed by name
+var strategicMergeKeys = []pathArrayMatcher{
+	{pattern: "**/containers/*/ports", matcher: KeyMatcher("containerPort")},
+	{pattern: "**/initContainers/*/ports", matcher: KeyMatcher("containerPort")},
+	{pattern: "**/ephemeralContainers/*/ports", matcher: KeyMatcher("containerPort")},
+	{pattern: "**/ports", matcher: KeyMatcher("port")},
+	{pattern: "**/volumeMounts", matcher: KeyMatcher("mountPath")},
+	{pattern: "**/volumeDevices", matcher: KeyMatcher("devicePath")},
+	{pattern: "**/hostAliases", matcher: KeyMatcher("ip")},
+	{pattern: "**", matcher: KeyMatcher("name")},
+}
+
+// StrategicMerge matches the elements of arrays the way a Kubernetes
+// strategic merge patch does: the elements of lists of objects, such
+// as containers, env, and volumes, are matched by their name field,
+// container ports by containerPort, service ports by port, and volume
+// mounts by mountPath. The changes to an element are reported as
+// changes to its fields, instead of a replacement of the element.
+// Elements without the key field are matched by value. The arrays
+// matched by MatchArrays, ArrayKey, or ArraysAsSets are not affected.
+func StrategicMerge() Option {
+	return func(d *Differ) {
+		d.strategic = true
+	}
+}
+
+// strategicMatcher returns the matcher for the array at fieldName if
+// strategic merge keys are used
+func (d *Differ) strategicMatcher(fieldName FieldName) (ArrayMatcher, bool) {
+	if !d.strategic || d.arraysAsSets.matches(fieldName) {
+		return nil, false
+	}
+	for _, m := range strategicMergeKeys {
+		if MatchPath(m.pattern, fieldName) {
+			return m.matcher, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,39 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestStrategicMerge(t *testing.T) {
+	doc1, _ := parse(`{"spec":{"containers":[
+		{"name":"app","image":"app:1","ports":[{"containerPort":80,"protocol":"TCP"}],"env":[{"name":"A","value":"1"},{"name":"B","value":"2"}]},
+		{"name":"sidecar","image":"proxy:1"}]}}`)
+	doc2, _ := parse(`{"spec":{"containers":[
+		{"name":"sidecar","image":"proxy:1"},
+		{"name":"app","image":"app:2","ports":[{"containerPort":80,"protocol":"UDP"}],"env":[{"name":"B","value":"2"},{"name":"A","value":"3"}]}]}}`)
+	deltas := NewDiffer(StrategicMerge()).Difference(doc1, doc2)
+	fields := make(map[string]bool)
+	for _, d := range deltas {
+		if d.GetType() == DiffMod {
+			fields[d.GetField().String()] = true
+		}
+		if d.GetType() == DiffIns || d.GetType() == DiffDel {
+			t.Errorf("Unexpected delta: %v", d)
+		}
+	}
+	for _, f := range []string{"spec/containers/1/image", "spec/containers/1/ports/0/protocol", "spec/containers/1/env/1/value"} {
+		if !fields[f] {
+			t.Errorf("Missing change to %s: %v", f, deltas)
+		}
+	}
+	result, err := Apply(doc1, deltas)
+	if err != nil || !IsEqual(result, doc2) {
+		t.Errorf("Wrong result: %v %v", result, err)
+	}
+
+	// Explicit matchers take precedence
+	deltas = NewDiffer(StrategicMerge(), ArraysAsSets("spec/containers")).Difference(doc1, doc2)
+	if s := Summarize(deltas); s.Counts[DiffIns] != 1 || s.Counts[DiffDel] != 1 {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+}
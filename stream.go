@@ -0,0 +1,129 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+)
+
+// RecordDelta describes the difference of a record between two JSON
+// record streams
+type RecordDelta struct {
+	// Key is the identity of the record
+	Key string
+	// Type is DiffIns if the record is only in the second stream,
+	// DiffDel if it is only in the first stream, and DiffMod if it is
+	// in both streams but is different
+	Type DiffType
+	// Old is the record in the first stream, nil if added
+	Old interface{}
+	// New is the record in the second stream, nil if removed
+	New interface{}
+	// Deltas are the differences between Old and New for modified
+	// records
+	Deltas []Delta
+	// Err is set for the last value sent on the channel if reading
+	// one of the streams failed
+	Err error
+}
+
+// streamRecord is a record read from a stream that is not matched yet
+type streamRecord struct {
+	key string
+	doc interface{}
+	seq int
+}
+
+// StreamDifference compares two streams of JSON records, such as
+// newline-delimited JSON files. Records are matched using the key
+// returned by keyFn, and the added, removed, and modified records are
+// sent on the returned channel. Unchanged records are not reported.
+//
+// The streams are read in parallel, and only the records that are not
+// matched yet are kept in memory, so streams containing the records
+// in the same or a similar order can be compared using little memory.
+// Modified records are reported as soon as they are matched. Removed
+// and added records are reported at the end, in the order they appear
+// in the streams. Records with the same key are matched in order.
+//
+// If reading one of the streams fails, the last value sent on the
+// channel has the Err field set to a *ParseError. The channel is
+// closed when the comparison is done, and it must be drained to
+// release the resources.
+func StreamDifference(r1, r2 io.Reader, keyFn func(doc interface{}) string, opts ...Option) (<-chan RecordDelta, error) {
+	if r1 == nil || r2 == nil {
+		return nil, errors.New("nil stream")
+	}
+	if keyFn == nil {
+		return nil, errors.New("nil key function")
+	}
+	d := NewDiffer(opts...)
+	out := make(chan RecordDelta)
+	go func() {
+		defer close(out)
+		decoders := [2]*json.Decoder{json.NewDecoder(r1), json.NewDecoder(r2)}
+		done := [2]bool{}
+		pending := [2]map[string][]streamRecord{{}, {}}
+		seq := 0
+		for !done[0] || !done[1] {
+			for i, dec := range decoders {
+				if done[i] {
+					continue
+				}
+				var doc interface{}
+				offset := dec.InputOffset()
+				if err := dec.Decode(&doc); err != nil {
+					if err == io.EOF {
+						done[i] = true
+						continue
+					}
+					out <- RecordDelta{Err: newParseError(i+1, offset, err)}
+					return
+				}
+				seq++
+				key := keyFn(doc)
+				other := pending[1-i]
+				if matches := other[key]; len(matches) > 0 {
+					match := matches[0]
+					if len(matches) == 1 {
+						delete(other, key)
+					} else {
+						other[key] = matches[1:]
+					}
+					old, new := match.doc, doc
+					if i == 0 {
+						old, new = doc, match.doc
+					}
+					if deltas := d.Difference(old, new); len(deltas) > 0 {
+						out <- RecordDelta{Key: key, Type: DiffMod, Old: old, New: new, Deltas: deltas}
+					}
+					continue
+				}
+				pending[i][key] = append(pending[i][key], streamRecord{key: key, doc: doc, seq: seq})
+			}
+		}
+		for i, typ := range []DiffType{DiffDel, DiffIns} {
+			for _, rec := range unmatchedRecords(pending[i]) {
+				x := RecordDelta{Key: rec.key, Type: typ}
+				if typ == DiffDel {
+					x.Old = rec.doc
+				} else {
+					x.New = rec.doc
+				}
+				out <- x
+			}
+		}
+	}()
+	return out, nil
+}
+
+// unmatchedRecords returns the records in the order they are read
+func unmatchedRecords(pending map[string][]streamRecord) []streamRecord {
+	var ret []streamRecord
+	for _, recs := range pending {
+		ret = append(ret, recs...)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].seq < ret[j].seq })
+	return ret
+}
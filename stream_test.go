@@ -0,0 +1,49 @@
+package jsondiff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStreamDifference(t *testing.T) {
+	s1 := `{"id":1,"v":"a"}
+{"id":2,"v":"b"}
+{"id":3,"v":"c"}
+{"id":4,"v":"d"}
+`
+	s2 := `{"id":2,"v":"b"}
+{"id":1,"v":"x"}
+{"id":5,"v":"e"}
+{"id":3,"v":"c"}
+`
+	key := func(doc interface{}) string {
+		return fmt.Sprint(doc.(map[string]interface{})["id"])
+	}
+	ch, err := StreamDifference(strings.NewReader(s1), strings.NewReader(s2), key)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	var result []string
+	for x := range ch {
+		if x.Err != nil {
+			t.Errorf("Error: %s", x.Err)
+		}
+		result = append(result, fmt.Sprintf("%s%s:%d", x.Type, x.Key, len(x.Deltas)))
+	}
+	if strings.Join(result, " ") != "*1:1 -4:0 +5:0" {
+		t.Errorf("Wrong result: %v", result)
+	}
+
+	ch, _ = StreamDifference(strings.NewReader(s1), strings.NewReader(`{"id":1`), key)
+	var last RecordDelta
+	for x := range ch {
+		last = x
+	}
+	var perr *ParseError
+	if !errors.As(last.Err, &perr) || perr.Doc != 2 {
+		t.Errorf("Expected parse error: %v", last.Err)
+	}
+}
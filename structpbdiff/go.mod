@@ -0,0 +1,10 @@
+module github.com/bserdar/jsondiff/structpbdiff
+
+go 1.23
+
+require (
+	github.com/bserdar/jsondiff/v2 v2.0.0
+	google.golang.org/protobuf v1.36.11
+)
+
+replace github.com/bserdar/jsondiff/v2 => ../
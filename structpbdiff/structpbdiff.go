@@ -0,0 +1,39 @@
+// Package structpbdiff computes differences between protobuf Struct
+// and Value messages using the jsondiff engine. It is a separate
+// module so that only the programs that use it depend on the
+// protobuf runtime.
+package structpbdiff
+
+import (
+	"github.com/bserdar/jsondiff/v2"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DifferenceStructpb computes difference between two protobuf
+// Structs. A nil Struct is compared as a null document.
+func DifferenceStructpb(a, b *structpb.Struct, opts ...jsondiff.Option) []jsondiff.Delta {
+	return jsondiff.NewDiffer(opts...).Difference(StructNode(a), StructNode(b))
+}
+
+// DifferenceValues computes difference between two protobuf Values.
+// A nil Value is compared as null.
+func DifferenceValues(a, b *structpb.Value, opts ...jsondiff.Option) []jsondiff.Delta {
+	return jsondiff.NewDiffer(opts...).Difference(ValueNode(a), ValueNode(b))
+}
+
+// StructNode converts a protobuf Struct into the node model used by
+// jsondiff. A nil Struct is converted to nil.
+func StructNode(s *structpb.Struct) interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
+
+// ValueNode converts a protobuf Value into the node model used by
+// jsondiff. Numbers are float64, lists are []interface{}, and structs
+// are map[string]interface{}. A nil Value, or a Value without a kind,
+// is converted to nil.
+func ValueNode(v *structpb.Value) interface{} {
+	return v.AsInterface()
+}
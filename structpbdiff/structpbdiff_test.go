@@ -0,0 +1,48 @@
+package structpbdiff
+
+import (
+	"testing"
+
+	"github.com/bserdar/jsondiff/v2"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestDifferenceStructpb(t *testing.T) {
+	a, err := structpb.NewStruct(map[string]interface{}{
+		"a": 1,
+		"b": []interface{}{"x", true, nil},
+		"c": map[string]interface{}{"d": 1.5},
+	})
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	b, _ := structpb.NewStruct(map[string]interface{}{
+		"a": 1.0,
+		"b": []interface{}{"x", false, nil},
+		"c": map[string]interface{}{"d": 2},
+	})
+	deltas := DifferenceStructpb(a, a)
+	if len(deltas) != 0 {
+		t.Errorf("Unexpected diff: %v", deltas)
+	}
+	deltas = DifferenceStructpb(a, b)
+	if len(deltas) != 3 {
+		t.Errorf("Unexpected diff: %v", deltas)
+	}
+	for _, d := range deltas {
+		switch d.GetField().String() {
+		case "b/1", "c/d":
+		default:
+			t.Errorf("Unexpected delta: %v", d)
+		}
+	}
+	deltas = DifferenceStructpb(nil, b)
+	if len(deltas) != 1 || deltas[0].(jsondiff.Modification).Old != nil {
+		t.Errorf("Unexpected diff: %v", deltas)
+	}
+	deltas = DifferenceValues(structpb.NewNumberValue(1), structpb.NewStringValue("1"))
+	if len(deltas) != 1 || !deltas[0].(jsondiff.Modification).TypeChanged {
+		t.Errorf("Unexpected diff: %v", deltas)
+	}
+}
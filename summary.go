@@ -0,0 +1,77 @@
+package jsondiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Summary contains statistics about a set of deltas
+type Summary struct {
+	// Counts gives the number of deltas for each diff type
+	Counts map[DiffType]int
+	// TopLevelFields is the number of distinct top-level fields
+	// containing changes
+	TopLevelFields int
+	// Deepest is the longest changed field name
+	Deepest FieldName
+}
+
+// Summarize returns statistics about the deltas
+func Summarize(deltas []Delta) Summary {
+	ret := Summary{Counts: make(map[DiffType]int)}
+	topLevel := make(map[Segment]struct{})
+	for _, d := range deltas {
+		ret.Counts[d.GetType()]++
+		name := d.GetField()
+		if len(name) > 0 {
+			topLevel[name[0]] = struct{}{}
+		}
+		if len(name) > len(ret.Deepest) {
+			ret.Deepest = name
+		}
+	}
+	ret.TopLevelFields = len(topLevel)
+	return ret
+}
+
+// String returns a one line summary like "3 added, 1 removed, 7 modified"
+func (s Summary) String() string {
+	var parts []string
+	for _, x := range []struct {
+		t    DiffType
+		verb string
+	}{
+		{DiffIns, "added"},
+		{DiffDel, "removed"},
+		{DiffMove, "moved"},
+		{DiffMod, "modified"},
+		{DiffRename, "renamed"},
+	} {
+		if n := s.Counts[x.t]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, x.verb))
+		}
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// GroupByPrefix groups the deltas by the first depth segments of
+// their field names, as returned by GetField. The keys of the
+// returned map are the prefixes written as FieldName.String. Deltas
+// whose field names are shorter than depth are grouped under their
+// field names, so changes to the root are under "". The deltas of
+// each group are in their original order.
+func GroupByPrefix(deltas []Delta, depth int) map[string][]Delta {
+	ret := make(map[string][]Delta)
+	for _, d := range deltas {
+		name := d.GetField()
+		if len(name) > depth {
+			name = name[:depth]
+		}
+		key := name.String()
+		ret[key] = append(ret[key], d)
+	}
+	return ret
+}
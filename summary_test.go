@@ -0,0 +1,48 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	doc1, err := parse(`{"a":1,"b":{"c":{"d":1}},"e":[1,2,3]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	doc2, err := parse(`{"a":2,"b":{"c":{"d":2}},"e":[1,3,4,5]}`)
+	if err != nil {
+		t.Errorf("Cannot parse: %s", err)
+		return
+	}
+	s := Summarize(Difference(doc1, doc2))
+	if s.String() != "2 added, 1 removed, 2 modified" {
+		t.Errorf("Wrong summary: %s", s)
+	}
+	if s.TopLevelFields != 3 {
+		t.Errorf("Wrong top level fields: %d", s.TopLevelFields)
+	}
+	if s.Deepest.String() != "b/c/d" {
+		t.Errorf("Wrong deepest: %s", s.Deepest)
+	}
+	if Summarize(nil).String() != "no changes" {
+		t.Errorf("Wrong empty summary")
+	}
+}
+
+func TestGroupByPrefix(t *testing.T) {
+	doc1, _ := parse(`{"server":{"http":{"port":80},"tls":false},"db":{"host":"a"},"x":1}`)
+	doc2, _ := parse(`{"server":{"http":{"port":8080},"tls":true},"db":{"host":"b"},"x":2}`)
+	deltas := Difference(doc1, doc2)
+	groups := GroupByPrefix(deltas, 1)
+	if len(groups) != 3 || len(groups["server"]) != 2 || len(groups["db"]) != 1 || len(groups["x"]) != 1 {
+		t.Errorf("Wrong groups: %v", groups)
+	}
+	groups = GroupByPrefix(deltas, 2)
+	if len(groups) != 4 || len(groups["server/http"]) != 1 || len(groups["server/tls"]) != 1 || len(groups["x"]) != 1 {
+		t.Errorf("Wrong groups: %v", groups)
+	}
+	if groups := GroupByPrefix(deltas, 0); len(groups) != 1 || len(groups[""]) != 4 {
+		t.Errorf("Wrong groups: %v", groups)
+	}
+}
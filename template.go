@@ -0,0 +1,83 @@
+package jsondiff
+
+import (
+	"sort"
+	"strings"
+)
+
+// TemplateDifference compares a configuration template with a
+// document rendered from it. values maps the placeholders used in the
+// template, such as "{{ .Values.replicas }}" or "${REPLICAS}", to the
+// values substituted for them. Placeholders in the template are
+// replaced by their values before the documents are compared, so a
+// placeholder that was rendered as its value produces no delta.
+//
+// A string that consists of a single placeholder is replaced by the
+// value itself, so it can become a number, an object, or any other
+// value. Placeholders that are part of a longer string are replaced
+// by the value as text: strings are inserted as is, and other values
+// are inserted as JSON. The deltas report the template values after
+// substitution.
+func TemplateDifference(template, rendered interface{}, values map[string]interface{}, opts ...Option) []Delta {
+	return NewDiffer(opts...).Difference(substitutePlaceholders(template, values), rendered)
+}
+
+// substitutePlaceholders returns a copy of node with the placeholders
+// replaced by their values
+func substitutePlaceholders(node interface{}, values map[string]interface{}) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			ret[k] = substitutePlaceholders(v, values)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(n))
+		for i, v := range n {
+			ret[i] = substitutePlaceholders(v, values)
+		}
+		return ret
+	case string:
+		if v, ok := values[n]; ok {
+			return v
+		}
+		return substituteText(n, values)
+	}
+	return node
+}
+
+// substituteText replaces the placeholders in s with the text of
+// their values. Longer placeholders are replaced first, so a
+// placeholder that contains another one is not broken
+func substituteText(s string, values map[string]interface{}) string {
+	placeholders := make([]string, 0, len(values))
+	for p := range values {
+		if p != "" && strings.Contains(s, p) {
+			placeholders = append(placeholders, p)
+		}
+	}
+	if len(placeholders) == 0 {
+		return s
+	}
+	sort.Slice(placeholders, func(i, j int) bool {
+		return len(placeholders[i]) > len(placeholders[j])
+	})
+	args := make([]string, 0, 2*len(placeholders))
+	for _, p := range placeholders {
+		args = append(args, p, placeholderText(values[p]))
+	}
+	return strings.NewReplacer(args...).Replace(s)
+}
+
+// placeholderText returns the text inserted for a placeholder value
+func placeholderText(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := CanonicalJSON(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,24 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestTemplateDifference(t *testing.T) {
+	template, _ := parse(`{"replicas":"{{ .replicas }}","image":"nginx:{{ .tag }}","env":["${ENV}","x"],"port":"{{ .port }}"}`)
+	rendered, _ := parse(`{"replicas":3,"image":"nginx:1.25","env":["prod","x"],"port":8080}`)
+	values := map[string]interface{}{
+		"{{ .replicas }}": float64(3),
+		"{{ .tag }}":      "1.25",
+		"${ENV}":          "prod",
+		"{{ .port }}":     float64(80),
+	}
+	deltas := TemplateDifference(template, rendered, values)
+	if len(deltas) != 1 {
+		t.Errorf("Wrong deltas: %v", deltas)
+		return
+	}
+	if m, ok := deltas[0].(Modification); !ok || m.Name.String() != "port" || m.Old != float64(80) {
+		t.Errorf("Wrong delta: %v", deltas[0])
+	}
+}
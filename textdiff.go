@@ -0,0 +1,234 @@
+package jsondiff
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TextOp is the type of a text edit
+type TextOp string
+
+// Text edit types
+const (
+	TextEqual  TextOp = "="
+	TextInsert TextOp = "+"
+	TextDelete TextOp = "-"
+)
+
+// TextEdit is a part of the difference between two strings. The old
+// string is the concatenation of the equal and deleted parts, and the
+// new string is the concatenation of the equal and inserted parts.
+type TextEdit struct {
+	Op   TextOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// TextUnit is the unit used to compare strings
+type TextUnit int
+
+// Text units
+const (
+	// TextLines compares strings line by line
+	TextLines TextUnit = iota
+	// TextWords compares strings word by word. White space and
+	// punctuation are separate units.
+	TextWords
+)
+
+// TextDiffs computes a text diff for the modifications of string
+// values where both the old and the new strings are at least
+// minLength bytes long. The text diff is stored in the TextDiff field
+// of the Modification.
+func TextDiffs(minLength int, unit TextUnit) Option {
+	return func(d *Differ) {
+		d.textDiffs = true
+		d.textMinLength = minLength
+		d.textUnit = unit
+	}
+}
+
+// textDiff returns the text diff for a modification if TextDiffs is
+// set and the values are long enough strings
+func (d *Differ) textDiff(old, new interface{}) []TextEdit {
+	if !d.textDiffs {
+		return nil
+	}
+	s1, ok1 := old.(string)
+	s2, ok2 := new.(string)
+	if !ok1 || !ok2 || len(s1) < d.textMinLength || len(s2) < d.textMinLength {
+		return nil
+	}
+	return TextDifference(s1, s2, d.textUnit)
+}
+
+// TextDifference computes the difference between two strings using
+// the Myers diff algorithm. Adjacent edits of the same type are
+// merged.
+func TextDifference(s1, s2 string, unit TextUnit) []TextEdit {
+	split := splitLines
+	if unit == TextWords {
+		split = splitWords
+	}
+	a, b := split(s1), split(s2)
+	// Common prefix and suffix are not passed to the diff algorithm
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var ret []TextEdit
+	add := func(op TextOp, tokens ...string) {
+		for _, t := range tokens {
+			if n := len(ret); n > 0 && ret[n-1].Op == op {
+				ret[n-1].Text += t
+			} else {
+				ret = append(ret, TextEdit{Op: op, Text: t})
+			}
+		}
+	}
+	add(TextEqual, a[:prefix]...)
+	for _, e := range myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		add(e.Op, e.Text)
+	}
+	add(TextEqual, a[len(a)-suffix:]...)
+	return ret
+}
+
+// myersDiff returns the shortest edit script that converts a to b,
+// one edit per token
+func myersDiff(a, b []string) []TextEdit {
+	n, m := len(a), len(b)
+	// trace[d][k+d] is the furthest x reached on diagonal k after d
+	// edits
+	var trace [][]int
+	prev := []int{0}
+	found := false
+	for d := 0; d <= n+m && !found; d++ {
+		v := make([]int, 2*d+1)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if d == 0 {
+				x = 0
+			} else if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+				x = prev[k+1+d-1]
+			} else {
+				x = prev[k-1+d-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+d] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		trace = append(trace, v)
+		prev = v
+	}
+	// Walk back from (n,m) to (0,0)
+	var edits []TextEdit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d-1]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[k-1+d-1] < v[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d-1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, TextEdit{Op: TextEqual, Text: a[x]})
+		}
+		if prevK == k+1 {
+			edits = append(edits, TextEdit{Op: TextInsert, Text: b[prevY]})
+		} else {
+			edits = append(edits, TextEdit{Op: TextDelete, Text: a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 {
+		x--
+		edits = append(edits, TextEdit{Op: TextEqual, Text: a[x]})
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// splitLines splits s into lines, keeping the line terminators
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	ret := strings.SplitAfter(s, "\n")
+	if ret[len(ret)-1] == "" {
+		ret = ret[:len(ret)-1]
+	}
+	return ret
+}
+
+// splitWords splits s into runs of letters and digits, runs of white
+// space, and single punctuation characters
+func splitWords(s string) []string {
+	var ret []string
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		}
+		return 0
+	}
+	start := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		c := class(r)
+		j := i + size
+		if c != 0 {
+			for j < len(s) {
+				r, size := utf8.DecodeRuneInString(s[j:])
+				if class(r) != c {
+					break
+				}
+				j += size
+			}
+		}
+		ret = append(ret, s[start:j])
+		start = j
+		i = j
+	}
+	return ret
+}
+
+// invertTextDiff returns the text diff from the new string to the old
+// string
+func invertTextDiff(edits []TextEdit) []TextEdit {
+	if edits == nil {
+		return nil
+	}
+	ret := make([]TextEdit, len(edits))
+	for i, e := range edits {
+		switch e.Op {
+		case TextInsert:
+			e.Op = TextDelete
+		case TextDelete:
+			e.Op = TextInsert
+		}
+		ret[i] = e
+	}
+	return ret
+}
@@ -0,0 +1,93 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// applyTextDiff returns the old and new strings of a text diff
+func applyTextDiff(edits []TextEdit) (string, string) {
+	var s1, s2 strings.Builder
+	for _, e := range edits {
+		if e.Op != TextInsert {
+			s1.WriteString(e.Text)
+		}
+		if e.Op != TextDelete {
+			s2.WriteString(e.Text)
+		}
+	}
+	return s1.String(), s2.String()
+}
+
+func TestTextDifference(t *testing.T) {
+	tests := []struct {
+		s1, s2 string
+		unit   TextUnit
+		edits  []TextEdit
+	}{
+		{"a\nb\nc\n", "a\nx\nc\n", TextLines, []TextEdit{{TextEqual, "a\n"}, {TextDelete, "b\n"}, {TextInsert, "x\n"}, {TextEqual, "c\n"}}},
+		{"a\nb", "a\nb\nc", TextLines, []TextEdit{{TextEqual, "a\n"}, {TextDelete, "b"}, {TextInsert, "b\nc"}}},
+		{"the quick fox", "the slow fox", TextWords, []TextEdit{{TextEqual, "the "}, {TextDelete, "quick"}, {TextInsert, "slow"}, {TextEqual, " fox"}}},
+		{"", "new text", TextWords, []TextEdit{{TextInsert, "new text"}}},
+		{"same", "same", TextWords, []TextEdit{{TextEqual, "same"}}},
+	}
+	for _, test := range tests {
+		edits := TextDifference(test.s1, test.s2, test.unit)
+		if len(edits) != len(test.edits) {
+			t.Errorf("Wrong edits for %q -> %q: %v", test.s1, test.s2, edits)
+			continue
+		}
+		for i := range edits {
+			if edits[i] != test.edits[i] {
+				t.Errorf("Wrong edits for %q -> %q: %v", test.s1, test.s2, edits)
+				break
+			}
+		}
+	}
+
+	// Edits reproduce both strings
+	s1 := "Lorem ipsum dolor sit amet, consectetur adipiscing elit.\nSed do eiusmod tempor incididunt.\nUt labore et dolore magna aliqua."
+	s2 := "Lorem ipsum dolor sit amet; adipiscing elit.\nSed do tempor incididunt ut labore.\nEt dolore magna aliqua!\nNew line."
+	for _, unit := range []TextUnit{TextLines, TextWords} {
+		edits := TextDifference(s1, s2, unit)
+		if a, b := applyTextDiff(edits); a != s1 || b != s2 {
+			t.Errorf("Edits do not reproduce strings: %v", edits)
+		}
+		if a, b := applyTextDiff(invertTextDiff(edits)); a != s2 || b != s1 {
+			t.Errorf("Inverted edits do not reproduce strings: %v", edits)
+		}
+	}
+}
+
+func TestTextDiffs(t *testing.T) {
+	doc1, _ := parse(`{"short":"a","desc":"first line\nsecond line\n","n":1}`)
+	doc2, _ := parse(`{"short":"b","desc":"first line\nchanged line\n","n":2}`)
+	deltas := NewDiffer(TextDiffs(10, TextLines)).Difference(doc1, doc2)
+	if len(deltas) != 3 {
+		t.Errorf("Unexpected diff: %v", deltas)
+		return
+	}
+	for _, d := range deltas {
+		m := d.(Modification)
+		if m.Name.String() == "desc" {
+			if len(m.TextDiff) != 3 || m.TextDiff[1] != (TextEdit{TextDelete, "second line\n"}) {
+				t.Errorf("Wrong text diff: %v", m.TextDiff)
+			}
+		} else if m.TextDiff != nil {
+			t.Errorf("Unexpected text diff: %v", m)
+		}
+	}
+	// Text diffs are serialized
+	for _, d := range deltas {
+		data, _ := json.Marshal(d)
+		x, err := UnmarshalDelta(data)
+		if err != nil {
+			t.Errorf("Error: %s", err)
+			continue
+		}
+		if len(x.(Modification).TextDiff) != len(d.(Modification).TextDiff) {
+			t.Errorf("Text diff not serialized: %s", data)
+		}
+	}
+}
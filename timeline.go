@@ -0,0 +1,82 @@
+package jsondiff
+
+import (
+	"sort"
+	"time"
+)
+
+// DiffRun is the result of comparing two versions of a document at a
+// point in time
+type DiffRun struct {
+	Time   time.Time
+	Deltas []Delta
+}
+
+// TimelineBucket is the number of changes to a field in a time
+// interval
+type TimelineBucket struct {
+	Start   time.Time `json:"start"`
+	Changes int       `json:"changes"`
+}
+
+// PathTimeline describes how often a field changed over time
+type PathTimeline struct {
+	Path FieldName `json:"path"`
+	// Changes is the total number of changes
+	Changes int `json:"changes"`
+	// First and Last are the times of the first and the last runs
+	// that changed the field
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+	// Buckets gives the number of changes in each interval. Intervals
+	// without changes are omitted
+	Buckets []TimelineBucket `json:"buckets"`
+}
+
+// Timeline aggregates timestamped difference runs of the same
+// document into a change frequency timeline for each changed field.
+// Runs are grouped into intervals of the given length, aligned to the
+// interval boundaries since the zero time. The result is sorted by the
+// time of the first change, so the fields that started changing
+// earliest come first. It can be encoded as JSON.
+func Timeline(runs []DiffRun, interval time.Duration) []PathTimeline {
+	sorted := make([]DiffRun, len(runs))
+	copy(sorted, runs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+	byPath := make(map[string]*PathTimeline)
+	var ret []*PathTimeline
+	for _, run := range sorted {
+		start := run.Time
+		if interval > 0 {
+			start = run.Time.Truncate(interval)
+		}
+		for _, d := range run.Deltas {
+			name := d.GetField()
+			key := name.String()
+			tl, ok := byPath[key]
+			if !ok {
+				tl = &PathTimeline{Path: name, First: run.Time}
+				byPath[key] = tl
+				ret = append(ret, tl)
+			}
+			tl.Changes++
+			tl.Last = run.Time
+			if n := len(tl.Buckets); n > 0 && tl.Buckets[n-1].Start.Equal(start) {
+				tl.Buckets[n-1].Changes++
+			} else {
+				tl.Buckets = append(tl.Buckets, TimelineBucket{Start: start, Changes: 1})
+			}
+		}
+	}
+	result := make([]PathTimeline, len(ret))
+	for i, tl := range ret {
+		result[i] = *tl
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].First.Equal(result[j].First) {
+			return result[i].First.Before(result[j].First)
+		}
+		return result[i].Path.String() < result[j].Path.String()
+	})
+	return result
+}
@@ -0,0 +1,41 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeline(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	versions := []string{`{"a":1,"b":1}`, `{"a":2,"b":1}`, `{"a":3,"b":1}`, `{"a":3,"b":2}`}
+	var runs []DiffRun
+	for i := 1; i < len(versions); i++ {
+		doc1, _ := parse(versions[i-1])
+		doc2, _ := parse(versions[i])
+		runs = append(runs, DiffRun{Time: base.Add(time.Duration(i) * 12 * time.Hour), Deltas: Difference(doc1, doc2)})
+	}
+	// Out of order runs are sorted
+	runs[0], runs[2] = runs[2], runs[0]
+	tl := Timeline(runs, 24*time.Hour)
+	if len(tl) != 2 {
+		t.Errorf("Wrong timeline: %v", tl)
+		return
+	}
+	a := tl[0]
+	if a.Path.String() != "a" || a.Changes != 2 || !a.First.Equal(base.Add(12*time.Hour)) || len(a.Buckets) != 2 {
+		t.Errorf("Wrong timeline for a: %+v", a)
+	}
+	b := tl[1]
+	if b.Path.String() != "b" || b.Changes != 1 || len(b.Buckets) != 1 || !b.Buckets[0].Start.Equal(base.Add(24*time.Hour)) {
+		t.Errorf("Wrong timeline for b: %+v", b)
+	}
+	data, err := json.Marshal(tl)
+	if err != nil {
+		t.Errorf("Cannot encode: %s", err)
+	}
+	var decoded []PathTimeline
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded) != 2 || decoded[0].Path.String() != "a" {
+		t.Errorf("Cannot decode %s: %v", data, err)
+	}
+}
@@ -0,0 +1,83 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// CompareTimes compares timestamps as instants instead of comparing
+// their text, so "2024-01-02T03:04:05Z" and
+// "2024-01-02T04:04:05+01:00" are equal. Two timestamps are equal if
+// they are at most tolerance apart, so a tolerance of 999ms ignores
+// sub-second differences.
+//
+// RFC 3339 strings are recognized as timestamps. If paths are given,
+// only the fields matching one of the path patterns are compared as
+// timestamps, and numbers in those fields are also recognized as Unix
+// epoch times: in seconds, or in milliseconds if the absolute value is
+// at least 1e11. Patterns use the same syntax as ArraysAsSets. Values
+// that are not timestamps are compared as usual.
+//
+// Without paths, array elements are still matched by value, so this
+// option does not slow down array matching. An array element whose
+// timestamp changes only its format is then reported as deleted and
+// inserted, unless the timestamp is also matched by a path.
+func CompareTimes(tolerance time.Duration, paths ...string) Option {
+	return func(d *Differ) {
+		if len(paths) == 0 {
+			eq := timeEqual(tolerance, false)
+			d.equalFuncs = append(d.equalFuncs, pathEqualFunc{pattern: "**", fn: eq, scalars: true, hashed: true})
+			return
+		}
+		eq := timeEqual(tolerance, true)
+		for _, p := range paths {
+			d.equalFuncs = append(d.equalFuncs, pathEqualFunc{pattern: p, fn: eq, scalars: true})
+		}
+	}
+}
+
+// timeEqual returns an equality function that compares timestamps
+// with the given tolerance. If epoch is set, numbers are Unix times
+func timeEqual(tolerance time.Duration, epoch bool) func(a, b interface{}) bool {
+	return func(a, b interface{}) bool {
+		t1, ok1 := timeValue(a, epoch)
+		t2, ok2 := timeValue(b, epoch)
+		if !ok1 || !ok2 {
+			return IsEqual(a, b)
+		}
+		diff := t1.Sub(t2)
+		if diff < 0 {
+			diff = -diff
+		}
+		return diff <= tolerance
+	}
+}
+
+// timeValue returns the instant of an RFC 3339 string, or of a Unix
+// epoch number if epoch is set
+func timeValue(v interface{}, epoch bool) (time.Time, bool) {
+	var f float64
+	switch x := v.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, x)
+		return t, err == nil
+	case float64:
+		f = x
+	case json.Number:
+		var err error
+		if f, err = x.Float64(); err != nil {
+			return time.Time{}, false
+		}
+	default:
+		return time.Time{}, false
+	}
+	if !epoch || math.IsNaN(f) || math.IsInf(f, 0) {
+		return time.Time{}, false
+	}
+	if math.Abs(f) >= 1e11 {
+		f /= 1000
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), true
+}
@@ -0,0 +1,50 @@
+package jsondiff
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareTimes(t *testing.T) {
+	doc1, _ := parse(`{"a":"2024-01-02T03:04:05Z","b":"2024-01-02T03:04:05.200Z","c":1704164645,"d":[{"t":"2024-01-02T03:04:05Z"}],"e":"x","f":1}`)
+	doc2, _ := parse(`{"a":"2024-01-02T04:04:05+01:00","b":"2024-01-02T03:04:05.900Z","c":"2024-01-02T03:04:05Z","d":[{"t":"2024-01-02T03:04:05.000Z"}],"e":"y","f":1.5}`)
+
+	// Without paths, array elements are matched by value
+	deltas := NewDiffer(CompareTimes(0)).Difference(doc1, doc2)
+	if fields := sortedFields(deltas); fields != "b c d/0 d/0 e f" {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+	deltas = NewDiffer(CompareTimes(time.Second)).Difference(doc1, doc2)
+	if fields := sortedFields(deltas); fields != "c d/0 d/0 e f" {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+	if NewDiffer(CompareTimes(0)).customEquality() {
+		t.Errorf("Timestamps without paths should not need custom array matching")
+	}
+	deltas = NewDiffer(CompareTimes(time.Second), CompareTimes(time.Second, "d/*/t")).Difference(doc1, doc2)
+	if fields := sortedFields(deltas); fields != "c e f" {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+	// Epoch times are only recognized at the given paths
+	deltas = NewDiffer(CompareTimes(time.Second, "c", "f")).Difference(doc1, doc2)
+	if fields := sortedFields(deltas); fields != "a b d/0 d/0 e" {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+	ms, _ := parse(`{"c":1704164645000}`)
+	if deltas := NewDiffer(CompareTimes(0, "c")).Difference(ms, map[string]interface{}{"c": "2024-01-02T03:04:05Z"}); len(deltas) != 0 {
+		t.Errorf("Wrong deltas for milliseconds: %v", deltas)
+	}
+}
+
+// sortedFields returns the sorted field names of the deltas separated
+// by spaces
+func sortedFields(deltas []Delta) string {
+	var ret []string
+	for _, d := range deltas {
+		ret = append(ret, d.GetField().String())
+	}
+	sort.Strings(ret)
+	return strings.Join(ret, " ")
+}
@@ -0,0 +1,278 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// PatchIndexMode selects how ToJSONPatch writes array indexes
+type PatchIndexMode int
+
+// Array index modes
+const (
+	// SequentialIndexes adjusts the array indexes of each operation for
+	// the operations before it, as required by RFC 6902, where
+	// operations are applied one after the other
+	SequentialIndexes PatchIndexMode = iota
+	// OriginalIndexes writes the array indexes of the deltas
+	// unchanged: removals and move sources refer to the original
+	// array, and additions, move targets, and nested changes refer to
+	// the resulting array. This is for consumers that resolve all
+	// operations against the original document, like Apply does
+	OriginalIndexes
+)
+
+// ToJSONPatch converts deltas to an RFC 6902 JSON patch. The deltas
+// are expected to be in the form returned by Difference. For object
+// fields, a Modification with nil Old value is written as an add, and
+// a Modification with nil New value is written as a remove, the same
+// way Apply interprets them. With the SetNullFields option, such
+// modifications are written as replace operations setting or
+// replacing null, which is how deltas computed with the
+// ObjectFieldDeltas option are converted.
+func ToJSONPatch(deltas []Delta, mode PatchIndexMode, opts ...ApplyOption) ([]byte, error) {
+	var a applier
+	for _, opt := range opts {
+		opt(&a)
+	}
+	var ops []jsonPatchOp
+	emit := func(op jsonPatchOp) {
+		ops = append(ops, op)
+	}
+	if err := patchNode(FieldName{}, deltas, patchConfig{mode: mode, setNulls: a.setNulls}, emit); err != nil {
+		return nil, err
+	}
+	if ops == nil {
+		ops = []jsonPatchOp{}
+	}
+	return json.Marshal(ops)
+}
+
+// patchValue returns a JSON patch operation value. nil is written as
+// null, instead of being omitted
+func patchValue(v interface{}) interface{} {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v
+}
+
+// patchConfig selects how deltas are converted to patch operations
+type patchConfig struct {
+	mode PatchIndexMode
+	// setNulls is set if a Modification with a nil value sets the
+	// field to null instead of adding or removing it
+	setNulls bool
+}
+
+// patchNode writes the patch operations for the deltas under path
+func patchNode(path FieldName, deltas []Delta, cfg patchConfig, emit func(jsonPatchOp)) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+	depth := len(path)
+	var own []Delta
+	children := make(map[Segment][]Delta)
+	isArray := false
+	for _, d := range deltas {
+		name := d.GetField()
+		if len(name) == depth {
+			own = append(own, d)
+			continue
+		}
+		if name[depth].IsIndex {
+			isArray = true
+		}
+		children[name[depth]] = append(children[name[depth]], d)
+	}
+	if len(own) > 0 {
+		if len(own) > 1 || len(children) > 0 {
+			return fmt.Errorf("conflicting changes at %s", path)
+		}
+		return patchOwn(path, own[0], cfg, emit)
+	}
+	if isArray {
+		return patchArray(path, children, cfg, emit)
+	}
+	keys := make([]Segment, 0, len(children))
+	for k := range children {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	for _, k := range keys {
+		if err := patchNode(path.child(k), children[k], cfg, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchOwn writes the patch operation for a delta that changes the
+// node at path
+func patchOwn(path FieldName, d Delta, cfg patchConfig, emit func(jsonPatchOp)) error {
+	ptr := path.Pointer()
+	switch x := d.(type) {
+	case Modification:
+		switch {
+		case len(path) == 0 || path[len(path)-1].IsIndex || cfg.setNulls:
+			emit(jsonPatchOp{Op: "replace", Path: ptr, Value: patchValue(x.New)})
+		case x.Old == nil:
+			emit(jsonPatchOp{Op: "add", Path: ptr, Value: patchValue(x.New)})
+		case x.New == nil:
+			emit(jsonPatchOp{Op: "remove", Path: ptr})
+		default:
+			emit(jsonPatchOp{Op: "replace", Path: ptr, Value: patchValue(x.New)})
+		}
+	case Insertion:
+		emit(jsonPatchOp{Op: "add", Path: ptr, Value: patchValue(x.NewNode)})
+	case Deletion:
+		emit(jsonPatchOp{Op: "remove", Path: ptr})
+	case Rename:
+		emit(jsonPatchOp{Op: "move", From: x.Name.child(KeySegment(x.OldKey)).Pointer(), Path: ptr})
+	default:
+		return fmt.Errorf("cannot convert %v to an object field change", d)
+	}
+	return nil
+}
+
+// patchArray writes the patch operations for the changes to the
+// elements of the array at path, followed by the changes nested
+// under the elements
+func patchArray(path FieldName, children map[Segment][]Delta, cfg patchConfig, emit func(jsonPatchOp)) error {
+	depth := len(path)
+	deleted := make(map[int]struct{})
+	moved := make(map[int]int)
+	inserted := make(map[int]interface{})
+	nested := make(map[int][]Delta)
+	changes := newArrayChanges()
+	maxIndex := 0
+	for seg, deltas := range children {
+		if !seg.IsIndex {
+			return fmt.Errorf("invalid field %s under %s: not an object", seg, path)
+		}
+		ix := seg.Index
+		if ix > maxIndex {
+			maxIndex = ix
+		}
+		for _, d := range deltas {
+			if len(d.GetField()) > depth+1 {
+				nested[ix] = append(nested[ix], d)
+				continue
+			}
+			switch x := d.(type) {
+			case Deletion:
+				deleted[ix] = struct{}{}
+				changes.remove(ix)
+			case Insertion:
+				inserted[ix] = x.NewNode
+				changes.add(ix)
+			case Move:
+				from := x.From[len(x.From)-1].Index
+				if from > maxIndex {
+					maxIndex = from
+				}
+				moved[ix] = from
+				changes.move(from, ix)
+			default:
+				nested[ix] = append(nested[ix], d)
+			}
+		}
+	}
+	indexPtr := func(ix int) string {
+		return path.child(IndexSegment(ix)).Pointer()
+	}
+	removed := make([]int, 0, len(deleted))
+	for ix := range deleted {
+		removed = append(removed, ix)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(removed)))
+	targets := make([]int, 0, len(inserted)+len(moved))
+	for ix := range inserted {
+		targets = append(targets, ix)
+	}
+	for ix := range moved {
+		targets = append(targets, ix)
+	}
+	sort.Ints(targets)
+	if cfg.mode == OriginalIndexes {
+		for _, ix := range removed {
+			emit(jsonPatchOp{Op: "remove", Path: indexPtr(ix)})
+		}
+		for _, ix := range targets {
+			if from, ok := moved[ix]; ok {
+				emit(jsonPatchOp{Op: "move", From: indexPtr(from), Path: indexPtr(ix)})
+			} else {
+				emit(jsonPatchOp{Op: "add", Path: indexPtr(ix), Value: patchValue(inserted[ix])})
+			}
+		}
+	} else {
+		// Simulate the operations on the list of original indexes,
+		// and place the elements of the resulting array one by
+		// one. Inserted elements are represented by -1
+		for _, ix := range removed {
+			emit(jsonPatchOp{Op: "remove", Path: indexPtr(ix)})
+		}
+		// The length of the original array is not known, so the
+		// original indexes are added to current as they are needed.
+		// The elements after the last one in current have not been
+		// changed yet, so they are in their original order
+		var current []int
+		last := -1
+		grow := func(ix int) {
+			for ; last < ix; last++ {
+				if _, ok := deleted[last+1]; !ok {
+					current = append(current, last+1)
+				}
+			}
+		}
+		grow(maxIndex)
+		// The original array has at most this many elements that are
+		// needed for the changes
+		limit := maxIndex + len(deleted) + len(inserted)
+		maxTarget := -1
+		if len(targets) > 0 {
+			maxTarget = targets[len(targets)-1]
+		}
+		for pos := 0; pos <= maxTarget; pos++ {
+			if x, ok := inserted[pos]; ok {
+				for len(current) < pos && last < limit {
+					grow(last + 1)
+				}
+				if len(current) < pos {
+					return fmt.Errorf("inconsistent array changes under %s", path)
+				}
+				emit(jsonPatchOp{Op: "add", Path: indexPtr(pos), Value: patchValue(x)})
+				current = append(current, 0)
+				copy(current[pos+1:], current[pos:])
+				current[pos] = -1
+				continue
+			}
+			want, _ := changes.oldIndex(pos)
+			grow(want)
+			from := pos
+			for from < len(current) && current[from] != want {
+				from++
+			}
+			if from == len(current) {
+				return fmt.Errorf("inconsistent array changes under %s", path)
+			}
+			if from != pos {
+				emit(jsonPatchOp{Op: "move", From: indexPtr(from), Path: indexPtr(pos)})
+				copy(current[pos+1:from+1], current[pos:from])
+				current[pos] = want
+			}
+		}
+	}
+	indexes := make([]int, 0, len(nested))
+	for ix := range nested {
+		indexes = append(indexes, ix)
+	}
+	sort.Ints(indexes)
+	for _, ix := range indexes {
+		if err := patchNode(path.child(IndexSegment(ix)), nested[ix], cfg, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
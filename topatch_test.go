@@ -0,0 +1,115 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestToJSONPatch(t *testing.T) {
+	docs := [][2]string{
+		{`{"a":1,"b":2}`, `{"a":2,"c":null}`},
+		{`{"a":[1,2,3]}`, `{"a":[3,1,2]}`},
+		{`{"a":[1,2,3,4,5]}`, `{"a":[5,6,2,1,7]}`},
+		{`{"a":[1,2,3]}`, `{"a":[]}`},
+		{`{"a":[]}`, `{"a":[1,2]}`},
+		{`{"a/b":{"c~d":1}}`, `{"a/b":{"c~d":2}}`},
+		{`[1,2]`, `{"x":1}`},
+		// Elements after the last changed index
+		{`["a","z",2,{"d":1}]`, `[2,{"d":1},"z"]`},
+		{`[1,2,3,4,5,6]`, `[2,1,3,4,5,6]`},
+		{`[1,2,3,4,5,6]`, `[0,2,1,3,4,5,6]`},
+	}
+	for _, doc := range docs {
+		doc1, _ := parse(doc[0])
+		doc2, _ := parse(doc[1])
+		patch, err := ToJSONPatch(Difference(doc1, doc2), SequentialIndexes)
+		if err != nil {
+			t.Errorf("Error: %s", err)
+			continue
+		}
+		var ops []jsonPatchOp
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			t.Errorf("Invalid patch %s: %s", patch, err)
+			continue
+		}
+		result, err := applyJSONPatch(deepCopy(doc1), ops)
+		if err != nil {
+			t.Errorf("Cannot apply %s: %s", patch, err)
+			continue
+		}
+		if !IsEqual(result, doc2) {
+			t.Errorf("Wrong result for %v: %s -> %v", doc, patch, result)
+		}
+	}
+}
+
+func TestToJSONPatchNullFields(t *testing.T) {
+	docs := [][2]string{
+		{`{"a":1}`, `{"a":null}`},
+		{`{"a":null}`, `{"a":1}`},
+		{`{"a":null}`, `{}`},
+		{`{}`, `{"a":null}`},
+		{`{"a":[{"b":null},1]}`, `{"a":[1,{"b":2,"c":null}]}`},
+	}
+	differ := NewDiffer(ObjectFieldDeltas())
+	for _, doc := range docs {
+		doc1, _ := parse(doc[0])
+		doc2, _ := parse(doc[1])
+		patch, err := ToJSONPatch(differ.Difference(doc1, doc2), SequentialIndexes, SetNullFields())
+		if err != nil {
+			t.Errorf("Error: %s", err)
+			continue
+		}
+		var ops []jsonPatchOp
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			t.Errorf("Invalid patch %s: %s", patch, err)
+			continue
+		}
+		result, err := applyJSONPatch(deepCopy(doc1), ops)
+		if err != nil {
+			t.Errorf("Cannot apply %s: %s", patch, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, doc2) {
+			t.Errorf("Wrong result for %v: %s -> %v", doc, patch, result)
+		}
+	}
+}
+
+func TestToJSONPatchKeyed(t *testing.T) {
+	doc1, _ := parse(`{"u":[{"id":1,"x":[1,2]},{"id":2},{"id":3,"x":1}]}`)
+	doc2, _ := parse(`{"u":[{"id":3,"x":2},{"id":4},{"id":1,"x":[2,1,3]}]}`)
+	patch, err := ToJSONPatch(NewDiffer(ArrayKey("u", "id")).Difference(doc1, doc2), SequentialIndexes)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	var ops []jsonPatchOp
+	json.Unmarshal(patch, &ops)
+	result, err := applyJSONPatch(deepCopy(doc1), ops)
+	if err != nil {
+		t.Errorf("Cannot apply %s: %s", patch, err)
+		return
+	}
+	if !IsEqual(result, doc2) {
+		t.Errorf("Wrong result: %s -> %v", patch, result)
+	}
+}
+
+func TestToJSONPatchOriginalIndexes(t *testing.T) {
+	deltas := []Delta{
+		Deletion{Name: FieldName{KeySegment("a"), IndexSegment(0)}, DeletedNode: 1},
+		Deletion{Name: FieldName{KeySegment("a"), IndexSegment(1)}, DeletedNode: 2},
+		Insertion{Name: FieldName{KeySegment("a"), IndexSegment(1)}, NewNode: nil},
+	}
+	patch, err := ToJSONPatch(deltas, OriginalIndexes)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	expected := `[{"op":"remove","path":"/a/1"},{"op":"remove","path":"/a/0"},{"op":"add","path":"/a/1","value":null}]`
+	if string(patch) != expected {
+		t.Errorf("Wrong patch: %s", patch)
+	}
+}
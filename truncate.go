@@ -0,0 +1,141 @@
+package jsondiff
+
+import (
+	"encoding/json"
+)
+
+// TruncatedValue replaces a value captured in a delta that is larger
+// than the limits set by TruncateValuesAt. It is encoded in JSON as
+// {"$truncated":{"kind":...,"bytes":...,"hash":...}}
+type TruncatedValue struct {
+	// Kind is the kind of the replaced value
+	Kind NodeKind
+	// Bytes is the size of the JSON encoding of the replaced value
+	Bytes int
+	// Hash is the StableHash of the replaced value, so truncated
+	// values can still be compared
+	Hash string
+}
+
+type truncatedValueJSON struct {
+	Kind  NodeKind `json:"kind"`
+	Bytes int      `json:"bytes"`
+	Hash  string   `json:"hash"`
+}
+
+// MarshalJSON writes the truncated value as a $truncated object
+func (t TruncatedValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]truncatedValueJSON{"$truncated": {Kind: t.Kind, Bytes: t.Bytes, Hash: t.Hash}})
+}
+
+// TruncateValuesAt limits the size of the values captured in deltas:
+// the old and new values of Modifications and Moves, inserted nodes,
+// and deleted nodes. If bytes is positive and the JSON encoding of a
+// value is longer than bytes, the value is replaced by a
+// TruncatedValue. Otherwise, if depth is positive, the containers
+// nested more than depth levels below the value are replaced by
+// TruncatedValues. The field names of the deltas are not changed.
+// Deltas with truncated values cannot be applied.
+func TruncateValuesAt(depth, bytes int) Option {
+	return func(d *Differ) {
+		d.truncDepth = depth
+		d.truncBytes = bytes
+	}
+}
+
+// truncateDelta returns the delta with its values truncated
+func (d *Differ) truncateDelta(x Delta) Delta {
+	if d.truncDepth <= 0 && d.truncBytes <= 0 {
+		return x
+	}
+	switch t := x.(type) {
+	case Modification:
+		t.Old, t.New = d.truncateValue(t.Old), d.truncateValue(t.New)
+		return t
+	case Insertion:
+		t.NewNode = d.truncateValue(t.NewNode)
+		return t
+	case Deletion:
+		t.DeletedNode = d.truncateValue(t.DeletedNode)
+		return t
+	case Move:
+		t.Old, t.New = d.truncateValue(t.Old), d.truncateValue(t.New)
+		return t
+	case Rename:
+		t.Value = d.truncateValue(t.Value)
+		return t
+	}
+	return x
+}
+
+// truncateValue returns the value truncated to the limits
+func (d *Differ) truncateValue(value interface{}) interface{} {
+	if d.truncBytes > 0 {
+		data, err := json.Marshal(value)
+		if err == nil && len(data) > d.truncBytes {
+			return newTruncatedValue(value, len(data))
+		}
+	}
+	if d.truncDepth > 0 && nodeDepth(value) > d.truncDepth {
+		return truncateDepth(value, d.truncDepth)
+	}
+	return value
+}
+
+// newTruncatedValue returns the TruncatedValue that replaces value
+func newTruncatedValue(value interface{}, size int) TruncatedValue {
+	if size < 0 {
+		if data, err := json.Marshal(value); err == nil {
+			size = len(data)
+		}
+	}
+	return TruncatedValue{Kind: KindOf(value), Bytes: size, Hash: StableHash(value)}
+}
+
+// nodeDepth returns the number of container levels of node
+func nodeDepth(node interface{}) int {
+	max := 0
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for _, v := range n {
+			if d := nodeDepth(v); d > max {
+				max = d
+			}
+		}
+	case []interface{}:
+		for _, v := range n {
+			if d := nodeDepth(v); d > max {
+				max = d
+			}
+		}
+	default:
+		return 0
+	}
+	return max + 1
+}
+
+// truncateDepth returns a copy of node in which the containers below
+// depth levels are replaced by TruncatedValues
+func truncateDepth(node interface{}, depth int) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if depth == 0 {
+			return newTruncatedValue(n, -1)
+		}
+		ret := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			ret[k] = truncateDepth(v, depth-1)
+		}
+		return ret
+	case []interface{}:
+		if depth == 0 {
+			return newTruncatedValue(n, -1)
+		}
+		ret := make([]interface{}, len(n))
+		for i, v := range n {
+			ret[i] = truncateDepth(v, depth-1)
+		}
+		return ret
+	}
+	return node
+}
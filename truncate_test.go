@@ -0,0 +1,53 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTruncateValuesAt(t *testing.T) {
+	doc1, _ := parse(`{"a":{"b":{"c":{"d":1}}},"s":"short","l":[1,2]}`)
+	doc2, _ := parse(`{"a":{"b":{"c":{"d":2}},"x":[[1]]},"s":"` + strings.Repeat("x", 100) + `","l":[1,2,{"e":[1]}]}`)
+	deltas := NewDiffer(TruncateValuesAt(1, 100)).Difference(doc1, doc2)
+	if len(deltas) != 4 {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+	for _, d := range deltas {
+		switch d.GetField().String() {
+		case "a/b/c/d":
+			if m := d.(Modification); m.Old != 1.0 || m.New != 2.0 {
+				t.Errorf("Wrong delta: %v", d)
+			}
+		case "a/x":
+			// [[1]] is two levels deep
+			arr, ok := d.(Modification).New.([]interface{})
+			if !ok || len(arr) != 1 {
+				t.Errorf("Wrong delta: %v", d)
+				break
+			}
+			if tv, ok := arr[0].(TruncatedValue); !ok || tv.Kind != KindArray || tv.Bytes != 3 || tv.Hash != StableHash([]interface{}{1.0}) {
+				t.Errorf("Wrong truncated value: %v", arr[0])
+			}
+		case "s":
+			m := d.(Modification)
+			if m.Old != "short" {
+				t.Errorf("Wrong delta: %v", d)
+			}
+			if tv, ok := m.New.(TruncatedValue); !ok || tv.Kind != KindString || tv.Bytes != 102 {
+				t.Errorf("Wrong truncated value: %v", m.New)
+			}
+			data, _ := json.Marshal(m.New)
+			if !strings.HasPrefix(string(data), `{"$truncated":{"kind":"string","bytes":102,"hash":"`) {
+				t.Errorf("Wrong encoding: %s", data)
+			}
+		case "l/2":
+			obj := d.(Insertion).NewNode.(map[string]interface{})
+			if _, ok := obj["e"].(TruncatedValue); !ok {
+				t.Errorf("Wrong delta: %v", d)
+			}
+		default:
+			t.Errorf("Unexpected delta: %v", d)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package jsondiff
+
+// OldValue returns the old value of a Modification, the deleted node
+// of a Deletion, the old value of a Move, or the value of a Rename, if
+// it is a T. It returns false if the delta has no old value, or if
+// the value is not a T. Numbers decoded by json.Unmarshal are float64.
+func OldValue[T any](d Delta) (T, bool) {
+	v, ok := oldValue(d).(T)
+	return v, ok
+}
+
+// NewValue returns the new value of a Modification, the inserted node
+// of an Insertion, the new value of a Move, or the value of a Rename,
+// if it is a T. It returns false if the delta has no new value, or if
+// the value is not a T. Numbers decoded by json.Unmarshal are float64.
+func NewValue[T any](d Delta) (T, bool) {
+	v, ok := newValue(d).(T)
+	return v, ok
+}
+
+// oldValue returns the old value of the delta, or nil
+func oldValue(d Delta) interface{} {
+	switch x := d.(type) {
+	case Deletion:
+		return x.DeletedNode
+	case Move:
+		return x.Old
+	case Modification:
+		return x.Old
+	case Rename:
+		return x.Value
+	}
+	return nil
+}
+
+// newValue returns the new value of the delta, or nil
+func newValue(d Delta) interface{} {
+	switch x := d.(type) {
+	case Insertion:
+		return x.NewNode
+	case Move:
+		return x.New
+	case Modification:
+		return x.New
+	case Rename:
+		return x.Value
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestTypedValues(t *testing.T) {
+	doc1, _ := parse(`{"a":1,"b":"x","c":{"d":true}}`)
+	doc2, _ := parse(`{"a":2,"c":{"d":true},"e":[1]}`)
+	for _, d := range NewDiffer(ObjectFieldDeltas()).Difference(doc1, doc2) {
+		switch d.GetField().String() {
+		case "a":
+			if v, ok := OldValue[float64](d); !ok || v != 1 {
+				t.Errorf("Wrong old value: %v", d)
+			}
+			if v, ok := NewValue[float64](d); !ok || v != 2 {
+				t.Errorf("Wrong new value: %v", d)
+			}
+			if _, ok := NewValue[string](d); ok {
+				t.Errorf("Wrong type accepted: %v", d)
+			}
+		case "b":
+			if v, ok := OldValue[string](d); !ok || v != "x" {
+				t.Errorf("Wrong old value: %v", d)
+			}
+			if _, ok := NewValue[interface{}](d); ok {
+				t.Errorf("New value of a deletion: %v", d)
+			}
+		case "e":
+			if v, ok := NewValue[[]interface{}](d); !ok || len(v) != 1 {
+				t.Errorf("Wrong new value: %v", d)
+			}
+			if _, ok := OldValue[[]interface{}](d); ok {
+				t.Errorf("Old value of an insertion: %v", d)
+			}
+		default:
+			t.Errorf("Unexpected delta: %v", d)
+		}
+	}
+}
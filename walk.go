@@ -0,0 +1,104 @@
+package jsondiff
+
+import (
+	"fmt"
+)
+
+// Walk calls visitor for each delta in order. It stops and returns
+// the error if visitor returns an error.
+func Walk(deltas []Delta, visitor func(d Delta) error) error {
+	for _, d := range deltas {
+		if err := visitor(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Visitor has a method for each delta type, called by WalkVisitor
+type Visitor interface {
+	OnInsert(Insertion) error
+	OnDelete(Deletion) error
+	OnMove(Move) error
+	OnModify(Modification) error
+}
+
+// RenameVisitor is implemented by Visitors that handle Rename deltas
+type RenameVisitor interface {
+	OnRename(Rename) error
+}
+
+// WalkVisitor calls the method of v for the type of each delta in
+// order. Renames are passed to OnRename if v is a RenameVisitor. It
+// stops and returns the error if a method returns an error, or if a
+// delta is not one of the delta types of this package or a Rename
+// without a RenameVisitor.
+func WalkVisitor(deltas []Delta, v Visitor) error {
+	return Walk(deltas, func(d Delta) error {
+		switch x := d.(type) {
+		case Insertion:
+			return v.OnInsert(x)
+		case Deletion:
+			return v.OnDelete(x)
+		case Move:
+			return v.OnMove(x)
+		case Modification:
+			return v.OnModify(x)
+		case Rename:
+			if rv, ok := v.(RenameVisitor); ok {
+				return rv.OnRename(x)
+			}
+		}
+		return fmt.Errorf("unknown delta type %T", d)
+	})
+}
+
+// VisitorFuncs is a Visitor that calls the functions set for each
+// delta type. Deltas whose function is nil are skipped.
+type VisitorFuncs struct {
+	Insert func(Insertion) error
+	Delete func(Deletion) error
+	Move   func(Move) error
+	Modify func(Modification) error
+	Rename func(Rename) error
+}
+
+// OnInsert calls f.Insert if it is set
+func (f VisitorFuncs) OnInsert(x Insertion) error {
+	if f.Insert == nil {
+		return nil
+	}
+	return f.Insert(x)
+}
+
+// OnDelete calls f.Delete if it is set
+func (f VisitorFuncs) OnDelete(x Deletion) error {
+	if f.Delete == nil {
+		return nil
+	}
+	return f.Delete(x)
+}
+
+// OnMove calls f.Move if it is set
+func (f VisitorFuncs) OnMove(x Move) error {
+	if f.Move == nil {
+		return nil
+	}
+	return f.Move(x)
+}
+
+// OnModify calls f.Modify if it is set
+func (f VisitorFuncs) OnModify(x Modification) error {
+	if f.Modify == nil {
+		return nil
+	}
+	return f.Modify(x)
+}
+
+// OnRename calls f.Rename if it is set
+func (f VisitorFuncs) OnRename(x Rename) error {
+	if f.Rename == nil {
+		return nil
+	}
+	return f.Rename(x)
+}
@@ -0,0 +1,39 @@
+package jsondiff
+
+import (
+	"errors"
+	"testing"
+)
+
+type unknownDelta struct{}
+
+func (unknownDelta) GetType() DiffType   { return "?" }
+func (unknownDelta) GetField() FieldName { return nil }
+
+func TestWalkVisitor(t *testing.T) {
+	doc1, _ := parse(`{"a":1,"b":[1,2,3]}`)
+	doc2, _ := parse(`{"a":2,"b":[3,2,4]}`)
+	deltas := Difference(doc1, doc2)
+	counts := map[DiffType]int{}
+	err := WalkVisitor(deltas, VisitorFuncs{
+		Insert: func(Insertion) error { counts[DiffIns]++; return nil },
+		Delete: func(Deletion) error { counts[DiffDel]++; return nil },
+		Modify: func(Modification) error { counts[DiffMod]++; return nil },
+	})
+	if err != nil || counts[DiffIns] != 1 || counts[DiffDel] != 1 || counts[DiffMod] != 1 || counts[DiffMove] != 0 {
+		t.Errorf("Wrong visits: %v %v %v", deltas, counts, err)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = Walk(deltas, func(Delta) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("Walk did not stop: %v %d", err, n)
+	}
+	if err := WalkVisitor([]Delta{unknownDelta{}}, VisitorFuncs{}); err == nil {
+		t.Errorf("Expected error")
+	}
+}
@@ -0,0 +1,96 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// WarningKind describes the kind of a warning
+type WarningKind string
+
+// Warning kinds
+const (
+	// WarnLossyNumber is reported for numbers that are too large to be
+	// represented exactly as float64, so they may have lost precision
+	// when they were decoded
+	WarnLossyNumber WarningKind = "lossy-number"
+	// WarnUnsupportedType is reported for values that are not part of
+	// the JSON node model. Such values are compared using
+	// reflect.DeepEqual
+	WarnUnsupportedType WarningKind = "unsupported-type"
+	// WarnTruncated is reported when the result is incomplete because a
+	// limit is reached
+	WarnTruncated WarningKind = "truncated"
+	// WarnAutoTuned is reported for each option selected by
+	// WithAutoTune. It does not mean the deltas are approximate
+	WarnAutoTuned WarningKind = "auto-tuned"
+)
+
+// Warning is a non-fatal problem found while computing the
+// difference. A warning means the deltas may be approximate.
+type Warning struct {
+	Kind    WarningKind
+	Name    FieldName
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s: %s", w.Kind, w.Name, w.Message)
+}
+
+// OnWarning registers fn to be called for each warning found while
+// computing the difference. Without it, warnings are discarded.
+func OnWarning(fn func(Warning)) Option {
+	return func(d *Differ) {
+		d.warn = fn
+	}
+}
+
+// DifferenceWithWarnings computes difference between two documents,
+// and returns the warnings found along with the deltas. The warning
+// callback registered with OnWarning, if any, is not called.
+func (d *Differ) DifferenceWithWarnings(node1, node2 interface{}) ([]Delta, []Warning) {
+	var warnings []Warning
+	dw := *d
+	dw.warn = func(w Warning) {
+		warnings = append(warnings, w)
+	}
+	return dw.Difference(node1, node2), warnings
+}
+
+// warnf reports a warning for the field
+func (d *Differ) warnf(kind WarningKind, name FieldName, format string, args ...interface{}) {
+	if d.warn == nil {
+		return
+	}
+	d.warn(Warning{Kind: kind,
+		Name:    append(FieldName{}, name...),
+		Message: fmt.Sprintf(format, args...)})
+}
+
+// maxExactFloat is the largest integer such that all integers up to
+// it can be represented exactly as float64
+const maxExactFloat = 1 << 53
+
+// checkValue reports warnings for a value node
+func (d *Differ) checkValue(name FieldName, node interface{}) {
+	if d.warn == nil {
+		return
+	}
+	switch k := node.(type) {
+	case nil, bool, string, json.Number:
+	case float64:
+		if math.Abs(k) > maxExactFloat && k == math.Trunc(k) {
+			d.warnf(WarnLossyNumber, name, "%g may not be exact", k)
+		}
+	default:
+		d.warnf(WarnUnsupportedType, name, "unsupported value type %T", node)
+	}
+}
+
+// isComparable returns if the value can be compared using ==
+func isComparable(node interface{}) bool {
+	return node == nil || reflect.TypeOf(node).Comparable()
+}
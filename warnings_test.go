@@ -0,0 +1,30 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestWarnings(t *testing.T) {
+	doc1 := map[string]interface{}{"big": float64(1 << 60), "small": float64(1),
+		"custom": map[string]string{"a": "b"}}
+	doc2 := map[string]interface{}{"big": float64(1 << 60), "small": float64(2),
+		"custom": map[string]string{"a": "c"}}
+	deltas, warnings := NewDiffer().DifferenceWithWarnings(doc1, doc2)
+	if len(deltas) != 2 {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+	kinds := map[WarningKind]string{}
+	for _, w := range warnings {
+		kinds[w.Kind] = w.Name.String()
+	}
+	if len(kinds) != 2 || kinds[WarnLossyNumber] != "big" || kinds[WarnUnsupportedType] != "custom" {
+		t.Errorf("Wrong warnings: %v", warnings)
+	}
+
+	var called []Warning
+	differ := NewDiffer(OnWarning(func(w Warning) { called = append(called, w) }))
+	differ.Difference(doc1, doc1)
+	if len(called) == 0 {
+		t.Errorf("Warning callback not called")
+	}
+}
@@ -0,0 +1,85 @@
+// Package yamldiff computes differences between YAML documents using
+// the jsondiff engine. YAML documents are converted to the same node
+// model as JSON documents, so the same delta types are used.
+//
+// Importing this package registers the YAML format, so YAML documents
+// can be compared with JSON documents using jsondiff.DifferenceAny.
+package yamldiff
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bserdar/jsondiff/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// YAML is the YAML document format
+const YAML jsondiff.Format = "yaml"
+
+func init() {
+	jsondiff.RegisterFormat(YAML, Unmarshal)
+}
+
+// YAMLDifference computes difference between two YAML documents. If
+// one of the documents cannot be parsed, the returned error is a
+// *jsondiff.ParseError.
+func YAMLDifference(doc1, doc2 []byte, opts ...jsondiff.Option) ([]jsondiff.Delta, error) {
+	n1, err := Unmarshal(doc1)
+	if err != nil {
+		return nil, &jsondiff.ParseError{Doc: 1, Offset: -1, Err: err}
+	}
+	n2, err := Unmarshal(doc2)
+	if err != nil {
+		return nil, &jsondiff.ParseError{Doc: 2, Offset: -1, Err: err}
+	}
+	return jsondiff.NewDiffer(opts...).Difference(n1, n2), nil
+}
+
+// Unmarshal parses a YAML document, and returns it in the node model
+// used by jsondiff
+func Unmarshal(doc []byte) (interface{}, error) {
+	var node interface{}
+	if err := yaml.Unmarshal(doc, &node); err != nil {
+		return nil, err
+	}
+	return Normalize(node), nil
+}
+
+// Normalize converts a node decoded by the YAML parser into the node
+// model used by jsondiff: maps with interface{} keys are converted to
+// map[string]interface{}, all numbers are converted to float64, and
+// timestamps are converted to RFC 3339 strings.
+func Normalize(node interface{}) interface{} {
+	switch n := node.(type) {
+	case map[interface{}]interface{}:
+		ret := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			ret[fmt.Sprint(k)] = Normalize(v)
+		}
+		return ret
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			ret[k] = Normalize(v)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(n))
+		for i, v := range n {
+			ret[i] = Normalize(v)
+		}
+		return ret
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case time.Time:
+		return n.Format(time.RFC3339Nano)
+	}
+	return node
+}
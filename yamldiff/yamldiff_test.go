@@ -0,0 +1,80 @@
+package yamldiff
+
+import (
+	"testing"
+
+	"github.com/bserdar/jsondiff/v2"
+)
+
+func TestYAMLDifference(t *testing.T) {
+	doc1 := []byte(`
+name: test
+replicas: 1
+ports:
+  - 80
+  - 443
+labels:
+  1: one
+`)
+	doc2 := []byte(`
+name: test
+replicas: 2
+ports:
+  - 80
+  - 443
+labels:
+  1: uno
+`)
+	delta, err := YAMLDifference(doc1, doc2)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	if len(delta) != 2 {
+		t.Errorf("Unexpected diff: %v", delta)
+		return
+	}
+	for _, d := range delta {
+		m, ok := d.(jsondiff.Modification)
+		if !ok {
+			t.Errorf("Wrong delta: %v", d)
+			continue
+		}
+		switch m.Name.String() {
+		case "replicas":
+			if m.Old.(float64) != 1 || m.New.(float64) != 2 {
+				t.Errorf("Wrong delta: %v", m)
+			}
+		case "labels/1":
+			if m.Old.(string) != "one" || m.New.(string) != "uno" {
+				t.Errorf("Wrong delta: %v", m)
+			}
+		default:
+			t.Errorf("Wrong delta: %v", m)
+		}
+	}
+}
+
+func TestYAMLParseError(t *testing.T) {
+	_, err := YAMLDifference([]byte(`a: b`), []byte("a: [b"))
+	if perr, ok := err.(*jsondiff.ParseError); !ok || perr.Doc != 2 {
+		t.Errorf("Wrong error: %v", err)
+	}
+}
+
+func TestDifferenceAnyYAML(t *testing.T) {
+	values := []byte(`
+replicas: 2
+image: nginx
+ports: [80, 443]
+`)
+	rendered := []byte(`{"replicas":2,"image":"nginx:1.25","ports":[80,443]}`)
+	deltas, err := jsondiff.DifferenceAny(values, rendered, YAML, jsondiff.JSON)
+	if err != nil {
+		t.Errorf("Error: %s", err)
+		return
+	}
+	if len(deltas) != 1 || deltas[0].GetField().String() != "image" {
+		t.Errorf("Wrong deltas: %v", deltas)
+	}
+}